@@ -0,0 +1,196 @@
+package audit_controller
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/audit_repository"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var csvHeader = []string{"audit_id", "created_at", "actor_id", "actor_role", "action", "entity_type", "entity_id",
+	"before", "after", "ip", "user_agent", "method", "path"}
+
+type AuditController struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+	auditRepo *audit_repository.AuditRepository
+}
+
+func NewAuditController(db *sql.DB, logLogrus *logrus.Logger) *AuditController {
+	return &AuditController{db: db, logLogrus: logLogrus, auditRepo: audit_repository.NewAuditRepository(db, logLogrus)}
+}
+
+// auditFilterFromQuery reads the filters and pagination query parameters of the audit log.
+func auditFilterFromQuery(r *http.Request) (request_models.AuditFilter, error) {
+	query := r.URL.Query()
+
+	pagination, err := helpers.ParsePagination(query)
+	if err != nil {
+		return request_models.AuditFilter{}, err
+	}
+
+	var actorID int
+	if value := query.Get("actor_id"); value != "" {
+		actorID, err = strconv.Atoi(value)
+		if err != nil || actorID <= 0 {
+			return request_models.AuditFilter{}, fmt.Errorf("actor_id must be a positive number")
+		}
+	}
+
+	return request_models.AuditFilter{
+		ActorID:    actorID,
+		Action:     query.Get("action"),
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+		From:       query.Get("from"),
+		To:         query.Get("to"),
+		Pagination: pagination,
+	}, nil
+}
+
+func (controller *AuditController) parseFilter(w http.ResponseWriter, r *http.Request) (request_models.AuditFilter, bool) {
+	filter, err := auditFilterFromQuery(r)
+	if err != nil {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to parse query")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return filter, false
+	}
+
+	return filter, true
+}
+
+// GetAuditLog godoc
+// @Summary Get Audit Log
+// @Description Getting the privileged actions taken, newest first, with the entity before and after each of them. JWT token is required if you want to use it
+// @Tags Audit
+// @Produce json
+// @Security BearerAuth
+// @Param actor_id query int false "User who took the action"
+// @Param action query string false "Action, e.g. book.delete"
+// @Param entity_type query string false "book, fine, user or role"
+// @Param entity_id query string false "ID of the entity, needs entity_type"
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD"
+// @Param page query int false "Page, starting at 1"
+// @Param per_page query int false "Entries per page, at most 100"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /audit/get-logs [get]
+func (controller *AuditController) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	filter, ok := controller.parseFilter(w, r)
+	if !ok {
+		return
+	}
+
+	responseRepo, code, err := controller.auditRepo.GetAuditLogRepository(r, filter)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// ExportAuditLog godoc
+// @Summary Export Audit Log
+// @Description Download every audit entry matching the filters as CSV, newest first. JWT token is required if you want to use it
+// @Tags Audit
+// @Produce text/csv
+// @Security BearerAuth
+// @Param actor_id query int false "User who took the action"
+// @Param action query string false "Action, e.g. book.delete"
+// @Param entity_type query string false "book, fine, user or role"
+// @Param entity_id query string false "ID of the entity, needs entity_type"
+// @Param from query string false "First day, YYYY-MM-DD"
+// @Param to query string false "Last day, YYYY-MM-DD"
+// @Success 200 {file} file
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /audit/export-logs [get]
+func (controller *AuditController) ExportAuditLog(w http.ResponseWriter, r *http.Request) {
+	filter, ok := controller.parseFilter(w, r)
+	if !ok {
+		return
+	}
+
+	entries, responseRepo, code, err := controller.auditRepo.ExportAuditLogRepository(r, filter)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	filename := fmt.Sprintf("audit-log-%s.csv", time.Now().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	err = writeAuditCSV(w, entries)
+	if err != nil {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to write audit export")
+	}
+}
+
+func writeAuditCSV(w http.ResponseWriter, entries []identity.AuditEntry) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write(csvHeader)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		actorID := ""
+		if entry.ActorID > 0 {
+			actorID = strconv.Itoa(entry.ActorID)
+		}
+
+		err = writer.Write([]string{
+			strconv.Itoa(entry.AuditID),
+			entry.CreatedAt,
+			actorID,
+			csvCell(entry.ActorRole),
+			entry.Action,
+			entry.EntityType,
+			csvCell(entry.EntityID),
+			csvCell(string(entry.Before)),
+			csvCell(string(entry.After)),
+			csvCell(entry.IP),
+			csvCell(entry.UserAgent),
+			entry.Method,
+			csvCell(entry.Path),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvCell keeps spreadsheets from evaluating values that start like a formula.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,27 @@
+package auth_controller
+
+import (
+	"encoding/json"
+	"go-libraryschool/helpers"
+	"net/http"
+)
+
+type JWKSController struct{}
+
+func NewJWKSController() *JWKSController {
+	return &JWKSController{}
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys (RS256 and EdDSA) access tokens are signed with, identified by the kid of the token header. Other services use them to validate our tokens.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} helpers.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (controller *JWKSController) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(helpers.JWTKeys().JWKS())
+}
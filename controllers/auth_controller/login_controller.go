@@ -5,9 +5,13 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
 	"go-libraryschool/helpers"
 	"go-libraryschool/models/auth_models"
 	"go-libraryschool/models/identity"
+	"go-libraryschool/repository/login_attempt_repository"
+	"go-libraryschool/repository/refresh_token_repository"
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
 	"strings"
@@ -15,16 +19,26 @@ import (
 )
 
 type LoginController struct {
-	Db *sql.DB
+	Db        *sql.DB
+	logLogrus *logrus.Logger
+
+	attemptRepo *login_attempt_repository.LoginAttemptRepository
+	issuer      refresh_token_repository.Issuer
+}
+
+func NewLoginController(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *LoginController {
+	return NewLoginControllerWith(db, logLogrus, login_attempt_repository.NewLoginAttemptRepository(db, logLogrus, rdb),
+		refresh_token_repository.NewRedisIssuer(rdb))
 }
 
-func NewLoginController(db *sql.DB) *LoginController {
-	return &LoginController{db}
+// NewLoginControllerWith builds the controller on the given attempt repository and refresh token issuer.
+func NewLoginControllerWith(db *sql.DB, logLogrus *logrus.Logger, attemptRepo *login_attempt_repository.LoginAttemptRepository, issuer refresh_token_repository.Issuer) *LoginController {
+	return &LoginController{Db: db, logLogrus: logLogrus, attemptRepo: attemptRepo, issuer: issuer}
 }
 
 // Login godoc
 // @Summary Login user
-// @Description Login with email and password (only email gmail make used)
+// @Description Login with email and password (only email gmail make used). Returns a short-lived access token and a refresh token for /token/refresh. Attempts are limited per IP and per email, and repeated wrong passwords lock the account for a while.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -32,7 +46,9 @@ func NewLoginController(db *sql.DB) *LoginController {
 // @Success 200 {object} helpers.ApiResponseAuthorization
 // @Failure 400 {object} helpers.ApiResponse
 // @Failure 401 {object} helpers.ApiResponse
+// @Failure 403 {object} helpers.ApiResponse
 // @Failure 404 {object} helpers.ApiResponse
+// @Failure 429 {object} helpers.ApiResponse
 // @Router /login [post]
 func (controller LoginController) Login(w http.ResponseWriter, r *http.Request) {
 	var user auth_models.LoginRequest
@@ -48,13 +64,25 @@ func (controller LoginController) Login(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	clientIP := helpers.ClientIP(r)
+
+	responseRepo, code, _ := controller.attemptRepo.CheckLoginRepository(user.Email, clientIP)
+	if code != http.StatusOK {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
 	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
 	defer cancel()
 
-	query := "SELECT id, username, email, password, roleID, created_at FROM users WHERE email = ?"
+	query := "SELECT id, username, email, password, roleID, created_at, email_verified_at, suspended_at IS NOT NULL FROM users WHERE email = ?"
 
-	var u identity.User
-	err = controller.Db.QueryRowContext(ctx, query, user.Email).Scan(&u.Id, &u.Username, &u.Email, &u.Password, &u.RoleID, &u.CreatedAt)
+	var (
+		u               identity.User
+		emailVerifiedAt sql.NullString
+		suspended       bool
+	)
+	err = controller.Db.QueryRowContext(ctx, query, user.Email).Scan(&u.Id, &u.Username, &u.Email, &u.Password, &u.RoleID, &u.CreatedAt, &emailVerifiedAt, &suspended)
 	if errors.Is(err, sql.ErrNoRows) {
 		http.Error(w, "User not found!", http.StatusNotFound)
 		return
@@ -62,8 +90,29 @@ func (controller LoginController) Login(w http.ResponseWriter, r *http.Request)
 
 	err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(user.Password))
 	if err != nil {
-		helpers.SendJson(w, http.StatusUnauthorized, helpers.ApiResponse{
-			Message: "Wrong Password!",
+		responseRepo, code, _ := controller.attemptRepo.FailedLoginRepository(user.Email, clientIP)
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	err = controller.attemptRepo.SucceededLogin(ctx, user.Email)
+	if err != nil {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to reset failed logins",
+		}).Error("Failed to reset failed logins")
+	}
+
+	if !emailVerifiedAt.Valid {
+		helpers.SendJson(w, http.StatusForbidden, helpers.ApiResponse{
+			Message: "Please verify your email before logging in.",
+		})
+		return
+	}
+
+	if suspended {
+		helpers.SendJson(w, http.StatusForbidden, helpers.ApiResponse{
+			Message: "Your account has been suspended.",
 		})
 		return
 	}
@@ -76,11 +125,21 @@ func (controller LoginController) Login(w http.ResponseWriter, r *http.Request)
 		})
 	}
 
-	token, err := helpers.GenerateToken(u.Id, u.Email, u.Role)
+	// The refresh token family is the session of this login, its ID becomes the jti of the access token.
+	session, refreshToken, err := controller.issuer.Issue(ctx, u.Id, r.UserAgent(), clientIP)
+	if err != nil {
+		helpers.SendJson(w, http.StatusInternalServerError, helpers.ApiResponse{
+			Message: "Failed to generate refresh token!",
+		})
+		return
+	}
+
+	token, err := helpers.GenerateToken(u.Id, u.Email, u.Role, session.FamilyID)
 	if err != nil {
 		helpers.SendJson(w, http.StatusInternalServerError, helpers.ApiResponse{
 			Message: "Failed to generate token!",
 		})
+		return
 	}
 
 	var responseLogin = auth_models.LoginResponse{
@@ -91,8 +150,10 @@ func (controller LoginController) Login(w http.ResponseWriter, r *http.Request)
 	}
 
 	helpers.SendJsonAuthorization(w, http.StatusOK, helpers.ApiResponseAuthorization{
-		Message: "Success!",
-		Data:    responseLogin,
-		Token:   token,
+		Message:      "Success!",
+		Data:         responseLogin,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(helpers.AccessTokenLifetime.Seconds()),
 	})
 }
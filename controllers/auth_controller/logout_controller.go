@@ -2,10 +2,15 @@ package auth_controller
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"go-libraryschool/helpers"
 	"go-libraryschool/middlewares"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/refresh_token_repository"
+	"io"
 	"net/http"
 	"time"
 )
@@ -24,9 +29,11 @@ func NewLogoutController(rdb *redis.Client, logLogrus *logrus.Logger) *LogoutCon
 
 // Logout godoc
 // @Summary Logout user
-// @Description User Must first log in for used feature Logout
+// @Description User Must first log in for used feature Logout. Sending the refresh token revokes it as well.
 // @Tags Auth
+// @Accept json
 // @Produce json
+// @Param request body request_models.RefreshTokenRequest false "Refresh Token Request"
 // @Security BearerAuth
 // @Success 200 {object} helpers.ApiResponse
 // @Failure 401 {object} helpers.ApiResponse
@@ -93,6 +100,50 @@ func (controller *LogoutController) Logout(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Ending the session of the token also stops its refresh tokens.
+	if claims.ID != "" {
+		err = refresh_token_repository.RevokeFamily(ctx, controller.rdb, claims.ID)
+		if err != nil {
+			controller.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Error revoking session",
+			}).Error("Error revoking session")
+
+			helpers.SendJson(w, http.StatusInternalServerError, helpers.ApiResponse{
+				Message: "Failed to logout.",
+			})
+			return
+		}
+	}
+
+	// The refresh token is optional, when given its whole family stops working as well.
+	var request request_models.RefreshTokenRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil && !errors.Is(err, io.EOF) {
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Request body not compatible with request body is want.",
+		})
+		return
+	}
+
+	if request.RefreshToken != "" {
+		family, ok, err := refresh_token_repository.FamilyOf(ctx, controller.rdb, request.RefreshToken)
+		if err == nil && ok && family.UserID == claims.UserID {
+			err = refresh_token_repository.RevokeFamily(ctx, controller.rdb, family.FamilyID)
+		}
+		if err != nil {
+			controller.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Error revoking refresh token",
+			}).Error("Error revoking refresh token")
+
+			helpers.SendJson(w, http.StatusInternalServerError, helpers.ApiResponse{
+				Message: "Failed to logout.",
+			})
+			return
+		}
+	}
+
 	controller.logLogrus.Info("Successfully logged out.")
 
 	helpers.SendJson(w, http.StatusOK, helpers.ApiResponse{
@@ -0,0 +1,57 @@
+package auth_controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/refresh_token_repository"
+	"net/http"
+)
+
+type RefreshTokenController struct {
+	logLogrus *logrus.Logger
+
+	refreshRepo *refresh_token_repository.RefreshTokenRepository
+}
+
+func NewRefreshTokenController(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *RefreshTokenController {
+	return &RefreshTokenController{
+		logLogrus:   logLogrus,
+		refreshRepo: refresh_token_repository.NewRefreshTokenRepository(db, logLogrus, rdb),
+	}
+}
+
+// RefreshToken godoc
+// @Summary Refresh token
+// @Description Exchange a refresh token for a new access token and the next refresh token. Each refresh token works once, reusing one logs out the session it belongs to.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body request_models.RefreshTokenRequest true "Refresh Token Request"
+// @Success 200 {object} helpers.ApiResponseAuthorization
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 401 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /token/refresh [post]
+func (controller *RefreshTokenController) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var request request_models.RefreshTokenRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.RefreshToken == "" {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Invalid request body",
+		}).Error("Error in Refresh Token")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Refresh token is required",
+		})
+		return
+	}
+
+	responseRepo, code, _ := controller.refreshRepo.RefreshRepository(request)
+	helpers.SendJsonAuthorization(w, code, responseRepo)
+}
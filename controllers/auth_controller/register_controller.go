@@ -5,9 +5,13 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"go-libraryschool/helpers"
+	"go-libraryschool/mailer"
 	"go-libraryschool/models/auth_models"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/otp_email_repository"
 	"golang.org/x/crypto/bcrypt"
 	"net/http"
 	"strings"
@@ -17,15 +21,17 @@ import (
 type RegisterController struct {
 	Db        *sql.DB
 	logLogrus *logrus.Logger
+
+	otpEmailRepo *otp_email_repository.OtpEmailRepository
 }
 
-func NewRegisterController(db *sql.DB, logLogrus *logrus.Logger) *RegisterController {
-	return &RegisterController{db, logLogrus}
+func NewRegisterController(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *RegisterController {
+	return &RegisterController{db, logLogrus, otp_email_repository.NewOtpEmailRepository(db, logLogrus, rdb)}
 }
 
 // Register godoc
 // @Summary Register user
-// @Description Register with username, email, password (only email gmail make used)
+// @Description Register with username, email, password (only email gmail make used). The account must verify its email with the code sent to it before logging in.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -104,7 +110,7 @@ func (RC *RegisterController) Register(w http.ResponseWriter, r *http.Request) {
 
 	var roleID = 3
 
-	queryInsert := "INSERT INTO users (username, email, password, roleID, created_at) VALUES (?, ?, ?, ?, ?)"
+	queryInsert := "INSERT INTO users (username, email, password, roleID, language, created_at) VALUES (?, ?, ?, ?, ?, ?)"
 	stmt, err := RC.Db.PrepareContext(ctx, queryInsert)
 	if err != nil {
 		helpers.SendJson(w, http.StatusInternalServerError, helpers.ApiResponse{
@@ -114,7 +120,7 @@ func (RC *RegisterController) Register(w http.ResponseWriter, r *http.Request) {
 	}
 	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, req.Username, req.Email, hashPassword, roleID, time.Now())
+	_, err = stmt.ExecContext(ctx, req.Username, req.Email, hashPassword, roleID, mailer.NormalizeLanguage(req.Language), time.Now())
 	if err != nil {
 		helpers.SendJson(w, http.StatusInternalServerError, helpers.ApiResponse{
 			Message: "Something went wrong",
@@ -140,13 +146,30 @@ func (RC *RegisterController) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := auth_models.RegisterResponse{
-		Username: req.Username,
+		Username:      req.Username,
+		Email:         req.Email,
+		Role:          role,
+		EmailVerified: false,
+	}
+
+	// The account stays unverified, and unable to log in, until the code sent here is confirmed.
+	message := "User successfully registered! Please verify your email with the code sent to it."
+	_, _, err = RC.otpEmailRepo.SendOtpRepository(request_models.RequestOtpEmail{
 		Email:    req.Email,
-		Role:     role,
+		Purpose:  request_models.OtpPurposeVerifyEmail,
+		Language: req.Language,
+	}, helpers.ClientIP(r))
+	if err != nil {
+		RC.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"email": req.Email,
+		}).Error("Failed to send verification email")
+
+		message = "User successfully registered! The verification email could not be sent, please request a new code."
 	}
 
 	helpers.SendJson(w, http.StatusCreated, helpers.ApiResponse{
-		Message: "User successfully registered!",
+		Message: message,
 		Data:    user,
 	})
 }
@@ -0,0 +1,64 @@
+package auth_controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/password_reset_repository"
+	"net/http"
+)
+
+type ResetPasswordController struct {
+	logLogrus *logrus.Logger
+
+	resetRepo *password_reset_repository.PasswordResetRepository
+}
+
+func NewResetPasswordController(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *ResetPasswordController {
+	return &ResetPasswordController{
+		logLogrus: logLogrus,
+		resetRepo: password_reset_repository.NewPasswordResetRepository(db, logLogrus, rdb),
+	}
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with the reset token returned by /otp/verify-otp for purpose reset_password. Every existing session of the user is revoked.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body request_models.ResetPasswordRequest true "Reset Password Request"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 401 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /reset-password [post]
+func (controller *ResetPasswordController) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var request request_models.ResetPasswordRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Invalid request body",
+		}).Error("Error in Reset Password")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Request body not compatible with request body is want.",
+		})
+		return
+	}
+
+	if request.ResetToken == "" {
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Reset token is required",
+		})
+		return
+	}
+
+	responseRepo, code, _ := controller.resetRepo.ResetPasswordRepository(request)
+	helpers.SendJson(w, code, responseRepo)
+}
@@ -0,0 +1,63 @@
+package auth_controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/login_attempt_repository"
+	"net/http"
+)
+
+type UnlockAccountController struct {
+	logLogrus *logrus.Logger
+
+	attemptRepo *login_attempt_repository.LoginAttemptRepository
+}
+
+func NewUnlockAccountController(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *UnlockAccountController {
+	return &UnlockAccountController{
+		logLogrus:   logLogrus,
+		attemptRepo: login_attempt_repository.NewLoginAttemptRepository(db, logLogrus, rdb),
+	}
+}
+
+// UnlockAccount godoc
+// @Summary Unlock account
+// @Description Lift the lockout of an account locked after repeated failed logins. JWT token is required if you want to use it
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.UnlockAccountRequest true "Email"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /unlock-account [post]
+func (controller *UnlockAccountController) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	var request request_models.UnlockAccountRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.Email == "" {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to parse request body",
+		}).Error("Failed to parse request body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse request body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, _ := controller.attemptRepo.UnlockAccountRepository(request, claims.UserID)
+	helpers.SendJson(w, code, responseRepo)
+}
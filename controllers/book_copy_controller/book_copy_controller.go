@@ -0,0 +1,142 @@
+package book_copy_controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/book_copy_repository"
+	"net/http"
+)
+
+type BookCopyController struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+	rdb       *redis.Client
+	copyRepo  *book_copy_repository.BookCopyRepository
+}
+
+func NewBookCopyController(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *BookCopyController {
+	return &BookCopyController{db: db, logLogrus: logLogrus, rdb: rdb, copyRepo: book_copy_repository.NewBookCopyRepository(db, logLogrus, rdb)}
+}
+
+// AddCopy godoc
+// @Summary Add Book Copy
+// @Description Register a physical copy of a book. Barcode and accession number are generated when left empty. JWT token is required if you want to use it
+// @Tags Book Copies
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.BookCopyRequest true "Data Copy"
+// @Success 201 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /book/copies/add-copy [post]
+func (controller *BookCopyController) AddCopy(w http.ResponseWriter, r *http.Request) {
+	var request request_models.BookCopyRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.BookID <= 0 {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.copyRepo.AddCopyRepository(r, request)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// GetCopies godoc
+// @Summary Get Book Copies
+// @Description Getting the copies of a book with their status, condition and shelf location. JWT token is required if you want to use it
+// @Tags Book Copies
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.BookCopyByBookId true "Book ID"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /book/copies/get-copies [get]
+func (controller *BookCopyController) GetCopies(w http.ResponseWriter, r *http.Request) {
+	var request request_models.BookCopyByBookId
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.copyRepo.GetCopiesRepository(request.BookID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// UpdateCopy godoc
+// @Summary Update Book Copy
+// @Description Update the condition, shelf location or status (available, lost, repair) of a copy. JWT token is required if you want to use it
+// @Tags Book Copies
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.BookCopyUpdate true "Data Copy"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /book/copies/update-copy [put]
+func (controller *BookCopyController) UpdateCopy(w http.ResponseWriter, r *http.Request) {
+	var request request_models.BookCopyUpdate
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.CopyID <= 0 {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.copyRepo.UpdateCopyRepository(r, request)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
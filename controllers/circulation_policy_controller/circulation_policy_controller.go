@@ -0,0 +1,158 @@
+package circulation_policy_controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/circulation_policy_repository"
+	"net/http"
+)
+
+type CirculationPolicyController struct {
+	db         *sql.DB
+	logLogrus  *logrus.Logger
+	policyRepo *circulation_policy_repository.CirculationPolicyRepository
+}
+
+func NewCirculationPolicyController(db *sql.DB, logLogrus *logrus.Logger) *CirculationPolicyController {
+	return &CirculationPolicyController{db: db, logLogrus: logLogrus, policyRepo: circulation_policy_repository.NewCirculationPolicyRepository(db, logLogrus)}
+}
+
+// GetPolicies godoc
+// @Summary Get Circulation Policies
+// @Description Getting the configured loan periods and fine rules per role and genre, together with the built-in default. JWT token is required if you want to use it
+// @Tags Circulation Policies
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /circulation-policy/get-policies [get]
+func (controller *CirculationPolicyController) GetPolicies(w http.ResponseWriter, r *http.Request) {
+	responseRepo, code, err := controller.policyRepo.GetPoliciesRepository()
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// AddPolicy godoc
+// @Summary Add Circulation Policy
+// @Description Add a loan period and fine rule. Leave role_id and/or genre_id empty to apply the policy to every role or genre. JWT token is required if you want to use it
+// @Tags Circulation Policies
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.CirculationPolicyRequest true "Data Policy"
+// @Success 201 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /circulation-policy/add-policy [post]
+func (controller *CirculationPolicyController) AddPolicy(w http.ResponseWriter, r *http.Request) {
+	var request request_models.CirculationPolicyRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.policyRepo.AddPolicyRepository(r, request)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// UpdatePolicy godoc
+// @Summary Update Circulation Policy
+// @Description Update the loan period, daily fine, fine cap, grace days or currency of a policy. JWT token is required if you want to use it
+// @Tags Circulation Policies
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.CirculationPolicyRequest true "Data Policy"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /circulation-policy/update-policy [put]
+func (controller *CirculationPolicyController) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	var request request_models.CirculationPolicyRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.PolicyID <= 0 {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.policyRepo.UpdatePolicyRepository(r, request)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// DeletePolicy godoc
+// @Summary Delete Circulation Policy
+// @Description Delete a policy, loans it covered fall back to the next most specific policy. JWT token is required if you want to use it
+// @Tags Circulation Policies
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.CirculationPolicyById true "Policy ID"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /circulation-policy/delete-policy [delete]
+func (controller *CirculationPolicyController) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	var request request_models.CirculationPolicyById
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.PolicyID <= 0 {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.policyRepo.DeletePolicyRepository(r, request.PolicyID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
@@ -0,0 +1,168 @@
+package fine_controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/fine_repository"
+	"io"
+	"net/http"
+)
+
+type FineController struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+	fineRepo  *fine_repository.FineRepository
+}
+
+func NewFineController(db *sql.DB, logLogrus *logrus.Logger) *FineController {
+	return &FineController{db: db, logLogrus: logLogrus, fineRepo: fine_repository.NewFineRepository(db, logLogrus)}
+}
+
+// PayFine godoc
+// @Summary Pay Fine
+// @Description Record a cash payment against a fine. The amount is in minor units and may be less than the outstanding balance. JWT token is required if you want to use it
+// @Tags Fines
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.FinePaymentRequest true "Data Payment"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /fine/pay-fine [post]
+func (controller *FineController) PayFine(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	var request request_models.FinePaymentRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.DueDateID <= 0 {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.fineRepo.PayFineRepository(r, request, claims.UserID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// WaiveFine godoc
+// @Summary Waive Fine
+// @Description Waive part of a fine, or everything still outstanding when amount is left empty. A reason is required. JWT token is required if you want to use it
+// @Tags Fines
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.FineWaiverRequest true "Data Waiver"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /fine/waive-fine [post]
+func (controller *FineController) WaiveFine(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	var request request_models.FineWaiverRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.DueDateID <= 0 {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.fineRepo.WaiveFineRepository(r, request, claims.UserID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// MyFines godoc
+// @Summary My Fines
+// @Description Getting the fines of the logged in user with payments, waivers and the outstanding balance. JWT token is required if you want to use it
+// @Tags Fines
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /fine/my-fines [get]
+func (controller *FineController) MyFines(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	responseRepo, code, err := controller.fineRepo.MyFinesRepository(claims.UserID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// GetFines godoc
+// @Summary Get Fines
+// @Description Getting the fines ledger, optionally filtered by user and status (outstanding, partially_paid, settled). JWT token is required if you want to use it
+// @Tags Fines
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.FineFilter false "Filter"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /fine/get-fines [get]
+func (controller *FineController) GetFines(w http.ResponseWriter, r *http.Request) {
+	var filter request_models.FineFilter
+
+	err := json.NewDecoder(r.Body).Decode(&filter)
+	if err != nil && !errors.Is(err, io.EOF) {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.fineRepo.GetFinesRepository(filter)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
@@ -0,0 +1,162 @@
+package hold_controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/hold_repository"
+	"net/http"
+)
+
+type HoldController struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+	rdb       *redis.Client
+	holdRepo  *hold_repository.HoldRepository
+}
+
+func NewHoldController(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *HoldController {
+	return &HoldController{db: db, logLogrus: logLogrus, rdb: rdb, holdRepo: hold_repository.NewHoldRepository(db, logLogrus, rdb)}
+}
+
+// PlaceHold godoc
+// @Summary Place Hold
+// @Description Join the waiting queue of a book whose copies are all lent out. JWT token is required if you want to use it
+// @Tags Holds
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.HoldRequest true "Book ID"
+// @Success 201 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /hold/place-hold [post]
+func (controller *HoldController) PlaceHold(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	var request request_models.HoldRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.BookID <= 0 {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.holdRepo.PlaceHoldRepository(r, claims.UserID, request.BookID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// MyHolds godoc
+// @Summary My Holds
+// @Description Getting the active holds of the logged in user with their queue position. JWT token is required if you want to use it
+// @Tags Holds
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /hold/my-holds [get]
+func (controller *HoldController) MyHolds(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	responseRepo, code, err := controller.holdRepo.GetHoldsRepository(0, claims.UserID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// GetHolds godoc
+// @Summary Get Holds
+// @Description Getting the active hold queues, optionally for a single book. JWT token is required if you want to use it
+// @Tags Holds
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.HoldRequest false "Book ID"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /hold/get-holds [get]
+func (controller *HoldController) GetHolds(w http.ResponseWriter, r *http.Request) {
+	var request request_models.HoldRequest
+
+	// The body is optional, without it every queue is listed.
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	responseRepo, code, err := controller.holdRepo.GetHoldsRepository(request.BookID, 0)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// CancelHold godoc
+// @Summary Cancel Hold
+// @Description Cancel a hold. Students can only cancel their own holds. JWT token is required if you want to use it
+// @Tags Holds
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.HoldById true "Hold ID"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 403 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /hold/cancel-hold [delete]
+func (controller *HoldController) CancelHold(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	var request request_models.HoldById
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.HoldID <= 0 {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	staff := middlewares.HasPermission(r, identity.PermissionHoldManage)
+
+	responseRepo, code, err := controller.holdRepo.CancelHoldRepository(r, request.HoldID, claims.UserID, staff)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
@@ -0,0 +1,98 @@
+package mail_outbox_controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/mail_outbox_repository"
+	"net/http"
+)
+
+type MailOutboxController struct {
+	db         *sql.DB
+	logLogrus  *logrus.Logger
+	outboxRepo *mail_outbox_repository.MailOutboxRepository
+}
+
+func NewMailOutboxController(db *sql.DB, logLogrus *logrus.Logger) *MailOutboxController {
+	return &MailOutboxController{db: db, logLogrus: logLogrus, outboxRepo: mail_outbox_repository.NewMailOutboxRepository(db, logLogrus)}
+}
+
+// GetMails godoc
+// @Summary Get Outbox Mails
+// @Description Getting queued, sent and failed emails with their delivery attempts. JWT token is required if you want to use it
+// @Tags Mail
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, sending, sent or failed"
+// @Param recipient query string false "Recipient email address"
+// @Param page query int false "Page, starting at 1"
+// @Param per_page query int false "Mails per page, at most 100"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /mail/outbox [get]
+func (controller *MailOutboxController) GetMails(w http.ResponseWriter, r *http.Request) {
+	pagination, err := helpers.ParsePagination(r.URL.Query())
+	if err != nil {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to parse query")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.outboxRepo.GetMailsRepository(r, r.URL.Query().Get("status"), r.URL.Query().Get("recipient"), pagination)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// RetryMail godoc
+// @Summary Retry Mail
+// @Description Queue an email whose delivery attempts were exhausted for another round of attempts. JWT token is required if you want to use it
+// @Tags Mail
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.MailById true "Mail ID"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /mail/retry-mail [post]
+func (controller *MailOutboxController) RetryMail(w http.ResponseWriter, r *http.Request) {
+	var request request_models.MailById
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.MailID <= 0 {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.outboxRepo.RetryMailRepository(r, request.MailID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
@@ -3,6 +3,7 @@ package management_book_controller
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
@@ -348,7 +349,7 @@ func (controller *ManagementBookController) SearchBooks(w http.ResponseWriter, r
 
 // BorrowedBook godoc
 // @Summary Borrowed Books
-// @Description Borrowed Book. JWT token is required if you want to use it
+// @Description Borrowed Book. Students always borrow for themselves, Librarians and Managers have to name the borrower in user_id and are recorded as the staff member who checked the book out. The return date follows the circulation policy, only staff may set borrowed_date or return_date explicitly. The loan is refused with 403 and a list of reasons when the borrower has reached the loan limit of their role, owes too much in fines or has overdue books. JWT token is required if you want to use it
 // @Tags Books
 // @Accept json
 // @Produce json
@@ -356,9 +357,14 @@ func (controller *ManagementBookController) SearchBooks(w http.ResponseWriter, r
 // @Param request body request_models.BookBorrowedRequest true "Data Borrowed"
 // @Success 200 {object} helpers.ApiResponse
 // @Success 400 {object} helpers.ApiResponse
+// @Failure 403 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
 // @Failure 500 {object} helpers.ApiResponse
 // @Router /book/borrowed-book [post]
 func (controller *ManagementBookController) BorrowedBook(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
 	var book request_models.BookBorrowedRequest
 
 	err := json.NewDecoder(r.Body).Decode(&book)
@@ -375,6 +381,46 @@ func (controller *ManagementBookController) BorrowedBook(w http.ResponseWriter,
 		return
 	}
 
+	// Students borrow for themselves, staff check out on behalf of the user named in the request.
+	if !middlewares.HasPermission(r, identity.PermissionLoanManage) {
+		if book.UserID != 0 && book.UserID != claims.UserID {
+			controller.logLogrus.WithFields(logrus.Fields{
+				"user_id": claims.UserID,
+				"data":    book,
+			}).Warn("Student tried to borrow for another user")
+
+			helpers.SendJson(w, http.StatusForbidden, helpers.ApiResponse{
+				Message: "Students can only borrow books for themselves",
+				Data:    nil,
+			})
+			return
+		}
+
+		// Loan dates always follow the circulation policy unless staff set them explicitly.
+		if book.BorrowedDate != "" || book.ReturnDate != "" {
+			controller.logLogrus.WithFields(logrus.Fields{
+				"user_id": claims.UserID,
+				"data":    book,
+			}).Warn("Student tried to set loan dates")
+
+			helpers.SendJson(w, http.StatusForbidden, helpers.ApiResponse{
+				Message: "Only staff can set the borrowed or return date",
+				Data:    nil,
+			})
+			return
+		}
+
+		book.UserID = claims.UserID
+	} else if book.UserID <= 0 {
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "user_id is required when borrowing on behalf of a user",
+			Data:    nil,
+		})
+		return
+	}
+
+	book.CheckedOutBy = claims.UserID
+
 	responseRepo, code, err := controller.BookEntityRepository().BorrowedBookRepository(r, book)
 	if err != nil {
 		helpers.SendJson(w, code, responseRepo)
@@ -384,17 +430,177 @@ func (controller *ManagementBookController) BorrowedBook(w http.ResponseWriter,
 	helpers.SendJson(w, code, responseRepo)
 }
 
+// ReturnBook godoc
+// @Summary Return Book
+// @Description Check a borrowed book back in, settle the late fine and restore availability. JWT token is required if you want to use it
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.BookReturnRequest true "Borrowed ID"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /book/return-book [post]
+func (controller *ManagementBookController) ReturnBook(w http.ResponseWriter, r *http.Request) {
+	var book request_models.BookReturnRequest
+
+	err := json.NewDecoder(r.Body).Decode(&book)
+	if err != nil || book.BorrowedID <= 0 {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.BookEntityRepository().ReturnBookRepository(r, book)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// RenewBook godoc
+// @Summary Renew Book
+// @Description Extend the due date of a loan by the renewal period. Refused when the loan is overdue, other users hold the book or the renewal limit is reached. JWT token is required if you want to use it
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.BookRenewRequest true "Borrowed ID"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 403 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /book/renew-book [post]
+func (controller *ManagementBookController) RenewBook(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	var book request_models.BookRenewRequest
+
+	err := json.NewDecoder(r.Body).Decode(&book)
+	if err != nil || book.BorrowedID <= 0 {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	staff := middlewares.HasPermission(r, identity.PermissionLoanManage)
+
+	responseRepo, code, err := controller.BookEntityRepository().RenewBookRepository(r, book, claims.UserID, staff)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
 // BookBorrowingData godoc
 // @Summary Book Borrowing Data
-// @Description Getting data borrowing books. JWT token is required if you want to use it
+// @Description Getting data borrowing books with the borrower name, book title, fines and renewals. JWT token is required if you want to use it
 // @Tags Books
 // @Produce json
 // @Security BearerAuth
+// @Param status query string false "active, overdue, returned or current"
+// @Param user_id query int false "Borrower ID"
+// @Param book_id query int false "Book ID"
+// @Param from query string false "Earliest borrow date, YYYY-MM-DD"
+// @Param to query string false "Latest borrow date, YYYY-MM-DD"
+// @Param sort query string false "borrow_date or return_date"
+// @Param order query string false "asc or desc"
+// @Param page query int false "Page, starting at 1"
+// @Param per_page query int false "Loans per page, at most 100"
 // @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
 // @Failure 500 {object} helpers.ApiResponse
 // @Router /book/book-borrowing-data [get]
 func (controller *ManagementBookController) BookBorrowingData(w http.ResponseWriter, r *http.Request) {
-	responseRepo, code, err := controller.BookEntityRepository().BookBorrowingDataRepository()
+	filter, err := loanFilterFromQuery(r)
+	if err == nil {
+		filter.UserID, err = optionalID(r, "user_id")
+	}
+	if err == nil {
+		filter.BookID, err = optionalID(r, "book_id")
+	}
+	if err != nil {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to parse query")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.BookEntityRepository().BookBorrowingDataRepository(r, filter)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// MyLoans godoc
+// @Summary My Loans
+// @Description Getting the current loans and borrowing history of the logged in user with book titles, due dates and fines. JWT token is required if you want to use it
+// @Tags Books
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "active, overdue, returned or current"
+// @Param from query string false "Earliest borrow date, YYYY-MM-DD"
+// @Param to query string false "Latest borrow date, YYYY-MM-DD"
+// @Param sort query string false "borrow_date or return_date"
+// @Param order query string false "asc or desc"
+// @Param page query int false "Page, starting at 1"
+// @Param per_page query int false "Loans per page, at most 100"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /book/my-loans [get]
+func (controller *ManagementBookController) MyLoans(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	filter, err := loanFilterFromQuery(r)
+	if err != nil {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to parse query")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	filter.UserID = claims.UserID
+
+	responseRepo, code, err := controller.BookEntityRepository().MyLoansRepository(r, filter)
 	if err != nil {
 		helpers.SendJson(w, code, responseRepo)
 		return
@@ -403,6 +609,40 @@ func (controller *ManagementBookController) BookBorrowingData(w http.ResponseWri
 	helpers.SendJson(w, code, responseRepo)
 }
 
+// loanFilterFromQuery reads the status, sort, order and pagination query parameters of a loan listing.
+func loanFilterFromQuery(r *http.Request) (request_models.LoanFilter, error) {
+	query := r.URL.Query()
+
+	pagination, err := helpers.ParsePagination(query)
+	if err != nil {
+		return request_models.LoanFilter{}, err
+	}
+
+	return request_models.LoanFilter{
+		Status:     query.Get("status"),
+		From:       query.Get("from"),
+		To:         query.Get("to"),
+		Sort:       query.Get("sort"),
+		Order:      query.Get("order"),
+		Pagination: pagination,
+	}, nil
+}
+
+// optionalID reads a positive ID from the query string, zero when the parameter is absent.
+func optionalID(r *http.Request, name string) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%s must be a positive number", name)
+	}
+
+	return id, nil
+}
+
 // GetBooksCategory godoc
 // @Summary Get Books Category
 // @Description Getting Books By CategoryID. JWT token is required if you want to use it
@@ -0,0 +1,142 @@
+package role_controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/permission_repository"
+	"net/http"
+)
+
+type RoleController struct {
+	db             *sql.DB
+	logLogrus      *logrus.Logger
+	permissionRepo *permission_repository.PermissionRepository
+}
+
+func NewRoleController(db *sql.DB, logLogrus *logrus.Logger) *RoleController {
+	return &RoleController{db: db, logLogrus: logLogrus, permissionRepo: permission_repository.NewPermissionRepository(db, logLogrus)}
+}
+
+// GetRoles godoc
+// @Summary Get Roles
+// @Description Getting every role with the permissions it holds. JWT token is required if you want to use it
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /role/get-roles [get]
+func (controller *RoleController) GetRoles(w http.ResponseWriter, r *http.Request) {
+	responseRepo, code, err := controller.permissionRepo.GetRolesRepository()
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// GetPermissions godoc
+// @Summary Get Permissions
+// @Description Getting every permission a role can be given. JWT token is required if you want to use it
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /role/get-permissions [get]
+func (controller *RoleController) GetPermissions(w http.ResponseWriter, r *http.Request) {
+	responseRepo, code, err := controller.permissionRepo.GetPermissionsRepository()
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// GrantPermission godoc
+// @Summary Grant Permission
+// @Description Give a role a permission. Takes effect on every instance within 30 seconds. JWT token is required if you want to use it
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.RolePermissionRequest true "Role ID and permission"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /role/grant-permission [post]
+func (controller *RoleController) GrantPermission(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	request, ok := controller.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	responseRepo, code, err := controller.permissionRepo.GrantPermissionRepository(r, request, claims.UserID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// RevokePermission godoc
+// @Summary Revoke Permission
+// @Description Take a permission away from a role. The last role holding role:manage keeps it. JWT token is required if you want to use it
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.RolePermissionRequest true "Role ID and permission"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /role/revoke-permission [delete]
+func (controller *RoleController) RevokePermission(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	request, ok := controller.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	responseRepo, code, err := controller.permissionRepo.RevokePermissionRepository(r, request, claims.UserID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+func (controller *RoleController) parseRequest(w http.ResponseWriter, r *http.Request) (request_models.RolePermissionRequest, bool) {
+	var request request_models.RolePermissionRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.RoleID <= 0 || request.Permission == "" {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return request, false
+	}
+
+	return request, true
+}
@@ -0,0 +1,128 @@
+package session_controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/session_repository"
+	"net/http"
+)
+
+type SessionController struct {
+	db          *sql.DB
+	logLogrus   *logrus.Logger
+	rdb         *redis.Client
+	sessionRepo *session_repository.SessionRepository
+}
+
+func NewSessionController(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *SessionController {
+	return &SessionController{db: db, logLogrus: logLogrus, rdb: rdb, sessionRepo: session_repository.NewSessionRepository(db, logLogrus, rdb)}
+}
+
+// MySessions godoc
+// @Summary My Sessions
+// @Description Getting the active sessions of the logged in user with device, IP and issued time. The session of the presented token is flagged as current. JWT token is required if you want to use it
+// @Tags Sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /session/my-sessions [get]
+func (controller *SessionController) MySessions(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	responseRepo, code, err := controller.sessionRepo.GetSessionsRepository(claims.UserID, claims.ID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// RevokeSession godoc
+// @Summary Revoke Session
+// @Description Log out one of the sessions of the logged in user. JWT token is required if you want to use it
+// @Tags Sessions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.RevokeSessionRequest true "Session ID"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /session/revoke-session [delete]
+func (controller *SessionController) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	var request request_models.RevokeSessionRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.SessionID == "" {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.sessionRepo.RevokeSessionRepository(claims.UserID, request.SessionID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// ForceLogout godoc
+// @Summary Force Logout
+// @Description Log a user out of every session, e.g. when a student card is lost. JWT token is required if you want to use it
+// @Tags Sessions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.ForceLogoutRequest true "User ID"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /session/force-logout [post]
+func (controller *SessionController) ForceLogout(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	var request request_models.ForceLogoutRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.UserID <= 0 {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse body")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: "Failed to parse body",
+			Data:    nil,
+		})
+		return
+	}
+
+	responseRepo, code, err := controller.sessionRepo.ForceLogoutRepository(request.UserID, claims.UserID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
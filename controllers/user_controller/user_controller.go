@@ -0,0 +1,288 @@
+package user_controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/user_repository"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// minPasswordLength matches what registration asks of users.
+const minPasswordLength = 6
+
+type UserController struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+	userRepo  *user_repository.UserRepository
+}
+
+func NewUserController(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *UserController {
+	return &UserController{db: db, logLogrus: logLogrus, userRepo: user_repository.NewUserRepository(db, logLogrus, rdb)}
+}
+
+func (controller *UserController) badRequest(w http.ResponseWriter, r *http.Request, err error, message string) {
+	controller.logLogrus.WithFields(logrus.Fields{
+		"error": err,
+		"data":  r.Body,
+	}).Error(message)
+
+	helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+		Message: message,
+		Data:    nil,
+	})
+}
+
+// GetUsers godoc
+// @Summary Get Users
+// @Description Listing and searching users with their role and account status. JWT token is required if you want to use it
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Param search query string false "Part of the username or email"
+// @Param role_id query int false "Role ID"
+// @Param status query string false "active or suspended"
+// @Param page query int false "Page, starting at 1"
+// @Param per_page query int false "Users per page, at most 100"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /user/get-users [get]
+func (controller *UserController) GetUsers(w http.ResponseWriter, r *http.Request) {
+	pagination, err := helpers.ParsePagination(r.URL.Query())
+	if err != nil {
+		controller.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to parse query")
+
+		helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	var roleID int
+	if value := r.URL.Query().Get("role_id"); value != "" {
+		roleID, err = strconv.Atoi(value)
+		if err != nil || roleID <= 0 {
+			controller.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.URL.RawQuery,
+			}).Error("Failed to parse query")
+
+			helpers.SendJson(w, http.StatusBadRequest, helpers.ApiResponse{
+				Message: "role_id must be a positive number",
+				Data:    nil,
+			})
+			return
+		}
+	}
+
+	search := strings.TrimSpace(r.URL.Query().Get("search"))
+
+	responseRepo, code, err := controller.userRepo.GetUsersRepository(r, search, roleID, r.URL.Query().Get("status"), pagination)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// CreateUser godoc
+// @Summary Create User
+// @Description Create an account with any role, e.g. a Librarian or Manager. The email counts as verified. JWT token is required if you want to use it
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.CreateUserRequest true "New account"
+// @Success 201 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /user/create-user [post]
+func (controller *UserController) CreateUser(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	var request request_models.CreateUserRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.RoleID <= 0 {
+		controller.badRequest(w, r, err, "Failed to parse body")
+		return
+	}
+
+	request.Username = strings.TrimSpace(request.Username)
+	request.Email = strings.TrimSpace(request.Email)
+
+	if request.Username == "" {
+		controller.badRequest(w, r, err, "Username is required")
+		return
+	}
+	if !strings.Contains(request.Email, "@") {
+		controller.badRequest(w, r, err, "A valid email is required")
+		return
+	}
+	if len(request.Password) < minPasswordLength {
+		controller.badRequest(w, r, err, "Password must be at least 6 characters!")
+		return
+	}
+
+	responseRepo, code, err := controller.userRepo.CreateUserRepository(r, request, claims.UserID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// ChangeRole godoc
+// @Summary Change Role
+// @Description Move a user to another role. The user keeps their sessions and gets the new role on their next token refresh. JWT token is required if you want to use it
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.ChangeRoleRequest true "User ID and role ID"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /user/change-role [put]
+func (controller *UserController) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	var request request_models.ChangeRoleRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.UserID <= 0 || request.RoleID <= 0 {
+		controller.badRequest(w, r, err, "Failed to parse body")
+		return
+	}
+
+	responseRepo, code, err := controller.userRepo.ChangeRoleRepository(r, request, claims.UserID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// SuspendUser godoc
+// @Summary Suspend User
+// @Description Suspend an account: the user is logged out everywhere and refused, even with a valid token, until reactivated. JWT token is required if you want to use it
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.SuspendUserRequest true "User ID and reason"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /user/suspend-user [post]
+func (controller *UserController) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	var request request_models.SuspendUserRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.UserID <= 0 {
+		controller.badRequest(w, r, err, "Failed to parse body")
+		return
+	}
+
+	responseRepo, code, err := controller.userRepo.SuspendUserRepository(r, request, claims.UserID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// ReactivateUser godoc
+// @Summary Reactivate User
+// @Description Lift the suspension of an account. JWT token is required if you want to use it
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.ReactivateUserRequest true "User ID"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 409 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /user/reactivate-user [post]
+func (controller *UserController) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	var request request_models.ReactivateUserRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.UserID <= 0 {
+		controller.badRequest(w, r, err, "Failed to parse body")
+		return
+	}
+
+	responseRepo, code, err := controller.userRepo.ReactivateUserRepository(r, request, claims.UserID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
+
+// ResetUserPassword godoc
+// @Summary Reset User Password
+// @Description Set a new password for a user and log them out everywhere. JWT token is required if you want to use it
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body request_models.ResetUserPasswordRequest true "User ID and new password"
+// @Success 200 {object} helpers.ApiResponse
+// @Failure 400 {object} helpers.ApiResponse
+// @Failure 404 {object} helpers.ApiResponse
+// @Failure 500 {object} helpers.ApiResponse
+// @Router /user/reset-password [post]
+func (controller *UserController) ResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims)
+
+	var request request_models.ResetUserPasswordRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.UserID <= 0 {
+		controller.badRequest(w, r, err, "Failed to parse body")
+		return
+	}
+	if len(request.Password) < minPasswordLength {
+		controller.badRequest(w, r, err, "Password must be at least 6 characters!")
+		return
+	}
+
+	responseRepo, code, err := controller.userRepo.ResetUserPasswordRepository(r, request, claims.UserID)
+	if err != nil {
+		helpers.SendJson(w, code, responseRepo)
+		return
+	}
+
+	helpers.SendJson(w, code, responseRepo)
+}
@@ -18,6 +18,12 @@ var symbols = map[string]string{
 	"THB": "฿",
 }
 
+// minorUnitDigits lists currencies whose minor unit is not a hundredth of the major unit.
+var minorUnitDigits = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+}
+
 type FormatterMoney struct{}
 
 func NewFormatterMoney() *FormatterMoney {
@@ -37,7 +43,46 @@ func (formatter *FormatterMoney) formatIDR(amount int) string {
 }
 
 func (formatter *FormatterMoney) formatIntl(amount float64) string {
-	return fmt.Sprintf("%,.2f", amount)
+	n := fmt.Sprintf("%.2f", amount)
+	sign := ""
+	if strings.HasPrefix(n, "-") {
+		sign, n = "-", n[1:]
+	}
+
+	whole, fraction := n[:len(n)-3], n[len(n)-3:]
+	var result []string
+	for i, count := len(whole)-1, 0; i >= 0; i, count = i-1, count+1 {
+		result = append([]string{string(whole[i])}, result...)
+		if count%3 == 2 && i != 0 {
+			result = append([]string{","}, result...)
+		}
+	}
+	return sign + strings.Join(result, "") + fraction
+}
+
+// MinorUnitScale returns how many minor units make up one major unit of the currency, e.g. 100 for IDR.
+func MinorUnitScale(currencyCode string) int {
+	digits, ok := minorUnitDigits[currencyCode]
+	if !ok {
+		digits = 2
+	}
+
+	scale := 1
+	for i := 0; i < digits; i++ {
+		scale *= 10
+	}
+	return scale
+}
+
+// FormatMinorUnits formats an amount stored in minor units, e.g. 2000000 IDR as Rp20.000.
+func (formatter *FormatterMoney) FormatMinorUnits(amount int, currencyCode string) string {
+	return formatter.FormaterCurrency(float64(amount)/float64(MinorUnitScale(currencyCode)), currencyCode)
+}
+
+// IsSupportedCurrency reports whether amounts in the currency code can be formatted.
+func IsSupportedCurrency(currencyCode string) bool {
+	_, ok := symbols[currencyCode]
+	return ok
 }
 
 func (formatter *FormatterMoney) FormaterCurrency(amount float64, currencyCode string) string {
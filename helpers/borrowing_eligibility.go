@@ -0,0 +1,44 @@
+package helpers
+
+import (
+	"fmt"
+	"go-libraryschool/models/identity"
+)
+
+// CheckBorrowingEligibility returns every reason the borrower may not take out another loan under
+// the policy of their role. An empty result means the loan is allowed.
+// A MaxLoans of zero means the role has no loan limit.
+func CheckBorrowingEligibility(policy identity.CirculationPolicy, standing identity.BorrowerStanding) []identity.BorrowRefusal {
+	refusals := []identity.BorrowRefusal{}
+
+	if policy.MaxLoans > 0 && standing.ActiveLoans >= policy.MaxLoans {
+		refusals = append(refusals, identity.BorrowRefusal{
+			Code:    identity.RefusalMaxLoans,
+			Message: fmt.Sprintf("At most %d books may be borrowed at the same time", policy.MaxLoans),
+			Limit:   policy.MaxLoans,
+			Current: standing.ActiveLoans,
+		})
+	}
+
+	if standing.UnpaidFine > policy.MaxUnpaidFine {
+		formatter := NewFormatterMoney()
+		refusals = append(refusals, identity.BorrowRefusal{
+			Code: identity.RefusalUnpaidFines,
+			Message: fmt.Sprintf("Unpaid fines of %s exceed the allowed %s",
+				formatter.FormatMinorUnits(standing.UnpaidFine, policy.Currency), formatter.FormatMinorUnits(policy.MaxUnpaidFine, policy.Currency)),
+			Limit:   policy.MaxUnpaidFine,
+			Current: standing.UnpaidFine,
+		})
+	}
+
+	if standing.OverdueLoans > 0 {
+		refusals = append(refusals, identity.BorrowRefusal{
+			Code:    identity.RefusalOverdueItems,
+			Message: fmt.Sprintf("%d overdue books have to be returned first", standing.OverdueLoans),
+			Limit:   0,
+			Current: standing.OverdueLoans,
+		})
+	}
+
+	return refusals
+}
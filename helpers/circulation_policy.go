@@ -0,0 +1,84 @@
+package helpers
+
+import (
+	"go-libraryschool/models/identity"
+	"time"
+)
+
+// DefaultCirculationPolicy applies when no configured policy matches a loan.
+var DefaultCirculationPolicy = identity.CirculationPolicy{
+	LoanPeriodDays: 7,
+	DailyFine:      2000000,
+	Currency:       "IDR",
+	MaxLoans:       3,
+	MaxRenewals:    2,
+}
+
+// ResolveCirculationPolicy picks the most specific policy for a role and genre.
+// A policy for both the role and the genre wins over a genre-only policy, which wins over
+// a role-only policy, which wins over the catch-all policy. Without a match the
+// DefaultCirculationPolicy is returned.
+func ResolveCirculationPolicy(policies []identity.CirculationPolicy, roleID, genreID int) identity.CirculationPolicy {
+	resolved := DefaultCirculationPolicy
+	best := -1
+
+	for _, policy := range policies {
+		if policy.RoleID != 0 && policy.RoleID != roleID {
+			continue
+		}
+		if policy.GenreID != 0 && policy.GenreID != genreID {
+			continue
+		}
+
+		rank := 0
+		if policy.RoleID != 0 {
+			rank += 1
+		}
+		if policy.GenreID != 0 {
+			rank += 2
+		}
+
+		if rank > best {
+			resolved, best = policy, rank
+		}
+	}
+
+	return resolved
+}
+
+// LoanDueDate returns the date a loan starting on borrowDate has to be returned by.
+func LoanDueDate(policy identity.CirculationPolicy, borrowDate time.Time) time.Time {
+	return truncateDay(borrowDate).AddDate(0, 0, policy.LoanPeriodDays)
+}
+
+// DaysLate returns how many whole days after returnDate the moment at falls, or zero.
+func DaysLate(returnDate, at time.Time) int {
+	day, due := truncateDay(at), truncateDay(returnDate)
+	if !day.After(due) {
+		return 0
+	}
+
+	return int(day.Sub(due).Hours() / 24)
+}
+
+// CalculateFine returns the days a loan is late at the given moment and the fine owed for it.
+// Days within the grace period are not charged and the fine never exceeds MaxFine when one is set.
+func CalculateFine(policy identity.CirculationPolicy, returnDate, at time.Time) (int, int) {
+	difference := DaysLate(returnDate, at)
+
+	charged := difference - policy.GraceDays
+	if charged <= 0 {
+		return difference, 0
+	}
+
+	fine := charged * policy.DailyFine
+	if policy.MaxFine > 0 && fine > policy.MaxFine {
+		fine = policy.MaxFine
+	}
+
+	return difference, fine
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -3,14 +3,15 @@ package helpers
 import (
 	"github.com/golang-jwt/jwt/v5"
 	"go-libraryschool/models/jwt_models"
-	"os"
 	"time"
 )
 
-var jwtKey = []byte(os.Getenv("JWT_KEY"))
+// AccessTokenLifetime is how long an access token is valid. Clients get a new one with their refresh token.
+const AccessTokenLifetime = 15 * time.Minute
 
-func GenerateToken(userID int, email, role string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// GenerateToken signs an access token for the session, whose ID goes in the jti claim.
+func GenerateToken(userID int, email, role, sessionID string) (string, error) {
+	expirationTime := time.Now().Add(AccessTokenLifetime)
 
 	claims := &jwt_models.JWTClaims{
 		UserID: userID,
@@ -19,9 +20,9 @@ func GenerateToken(userID int, email, role string) (string, error) {
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        sessionID,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+	return JWTKeys().Sign(claims)
 }
@@ -0,0 +1,320 @@
+package helpers
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/joho/godotenv"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultKeyID is the kid of the HMAC key read from JWT_KEY when JWT_KEY_ID is not set.
+const DefaultKeyID = "default"
+
+var (
+	ErrUnknownKeyID        = errors.New("unknown signing key id")
+	ErrUnexpectedAlgorithm = errors.New("unexpected signing algorithm")
+)
+
+// SigningKey is one key of the key set. SignKey is nil for keys that are only kept to verify tokens
+// signed before a rotation, or for public keys of which we don't hold the private half.
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	SignKey   any
+	VerifyKey any
+}
+
+// KeyManager holds the keys tokens are signed and verified with. Tokens carry the kid of their key in the
+// header, so a new signing key can be rolled out while tokens signed with the previous one stay valid.
+type KeyManager struct {
+	mu         sync.RWMutex
+	keys       map[string]SigningKey
+	signingID  string
+	fallbackID string
+}
+
+func NewKeyManager() *KeyManager {
+	return &KeyManager{keys: map[string]SigningKey{}}
+}
+
+// Add registers a key, replacing any key with the same ID.
+func (manager *KeyManager) Add(key SigningKey) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	manager.keys[key.ID] = key
+}
+
+// Remove retires a key. Tokens signed with it stop being accepted.
+func (manager *KeyManager) Remove(keyID string) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	delete(manager.keys, keyID)
+}
+
+// SetSigningKey picks the key new tokens are signed with.
+func (manager *KeyManager) SetSigningKey(keyID string) error {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	key, ok := manager.keys[keyID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKeyID, keyID)
+	}
+	if key.SignKey == nil {
+		return fmt.Errorf("key %s has no private key to sign with", keyID)
+	}
+
+	manager.signingID = keyID
+	return nil
+}
+
+// SetFallbackKey picks the key used for tokens without a kid, which were issued before keys had IDs.
+func (manager *KeyManager) SetFallbackKey(keyID string) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	manager.fallbackID = keyID
+}
+
+// Sign signs the claims with the current signing key and puts its kid in the header.
+func (manager *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	manager.mu.RLock()
+	key, ok := manager.keys[manager.signingID]
+	manager.mu.RUnlock()
+
+	if !ok || key.SignKey == nil {
+		return "", errors.New("no signing key configured")
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.SignKey)
+}
+
+// Keyfunc resolves the verification key of a token from its kid, and refuses tokens whose algorithm
+// is not the one of that key, so an RSA public key can never be used as an HMAC secret.
+func (manager *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	keyID, _ := token.Header["kid"].(string)
+	if keyID == "" {
+		keyID = manager.fallbackID
+	}
+
+	key, ok := manager.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKeyID, keyID)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedAlgorithm, token.Method.Alg())
+	}
+
+	return key.VerifyKey, nil
+}
+
+// Parse verifies a token against the key set and fills the claims.
+func (manager *KeyManager) Parse(tokenStr string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenStr, claims, manager.Keyfunc, jwt.WithValidMethods([]string{
+		jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg(),
+	}))
+}
+
+// JWK is a public key in JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public keys of the set so other services can verify our tokens. HMAC secrets are never published.
+func (manager *KeyManager) JWKS() JWKSet {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range manager.keys {
+		switch public := key.VerifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+
+	return set
+}
+
+// ParsePEMKey reads an RSA or Ed25519 key from PEM. A private key gives a key that signs and verifies,
+// a public key one that only verifies.
+func ParsePEMKey(keyID string, data []byte) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, fmt.Errorf("key %s: no PEM block found", keyID)
+	}
+
+	if strings.Contains(block.Type, "PRIVATE KEY") {
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			return SigningKey{ID: keyID, Method: jwt.SigningMethodRS256, SignKey: private, VerifyKey: &private.PublicKey}, nil
+		}
+		if private, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+			return SigningKey{ID: keyID, Method: jwt.SigningMethodEdDSA, SignKey: private, VerifyKey: private.(crypto.Signer).Public()}, nil
+		}
+		return SigningKey{}, fmt.Errorf("key %s: unsupported private key, expected RSA or Ed25519", keyID)
+	}
+
+	if public, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return SigningKey{ID: keyID, Method: jwt.SigningMethodRS256, VerifyKey: public}, nil
+	}
+	if public, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return SigningKey{ID: keyID, Method: jwt.SigningMethodEdDSA, VerifyKey: public}, nil
+	}
+	return SigningKey{}, fmt.Errorf("key %s: unsupported public key, expected RSA or Ed25519", keyID)
+}
+
+// splitKeyList parses "kid=value,kid=value" lists of the JWT_* variables.
+func splitKeyList(value string) (map[string]string, error) {
+	list := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		keyID, rest, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(keyID) == "" {
+			return nil, fmt.Errorf("invalid key entry %q, expected kid=value", entry)
+		}
+		list[strings.TrimSpace(keyID)] = strings.TrimSpace(rest)
+	}
+	return list, nil
+}
+
+// NewKeyManagerFromEnv builds the key set from the environment:
+//
+//	JWT_KEY / JWT_KEY_ID      HS256 secret and its kid, also used for tokens without a kid
+//	JWT_PREVIOUS_KEYS         kid=secret list of retired HS256 secrets, only used to verify
+//	JWT_KEY_FILES             kid=path list of RSA or Ed25519 PEM files
+//	JWT_SIGNING_KEY_ID        kid new tokens are signed with, JWT_KEY_ID by default
+func NewKeyManagerFromEnv() (*KeyManager, error) {
+	manager := NewKeyManager()
+
+	keyID := strings.TrimSpace(os.Getenv("JWT_KEY_ID"))
+	if keyID == "" {
+		keyID = DefaultKeyID
+	}
+
+	if secret := os.Getenv("JWT_KEY"); secret != "" {
+		manager.Add(SigningKey{ID: keyID, Method: jwt.SigningMethodHS256, SignKey: []byte(secret), VerifyKey: []byte(secret)})
+		manager.SetFallbackKey(keyID)
+	}
+
+	previous, err := splitKeyList(os.Getenv("JWT_PREVIOUS_KEYS"))
+	if err != nil {
+		return nil, err
+	}
+	for previousID, secret := range previous {
+		manager.Add(SigningKey{ID: previousID, Method: jwt.SigningMethodHS256, VerifyKey: []byte(secret)})
+	}
+
+	files, err := splitKeyList(os.Getenv("JWT_KEY_FILES"))
+	if err != nil {
+		return nil, err
+	}
+	for fileKeyID, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", fileKeyID, err)
+		}
+
+		key, err := ParsePEMKey(fileKeyID, data)
+		if err != nil {
+			return nil, err
+		}
+		manager.Add(key)
+	}
+
+	signingID := strings.TrimSpace(os.Getenv("JWT_SIGNING_KEY_ID"))
+	if signingID == "" {
+		signingID = keyID
+	}
+	if len(manager.keys) > 0 {
+		err = manager.SetSigningKey(signingID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return manager, nil
+}
+
+var (
+	keysMu     sync.RWMutex
+	defaultKey = defaultKeyManager()
+)
+
+// defaultKeyManager serves the JWT_KEY already in the environment until ConfigureJWTKeys runs.
+func defaultKeyManager() *KeyManager {
+	manager := NewKeyManager()
+	secret := []byte(os.Getenv("JWT_KEY"))
+	manager.Add(SigningKey{ID: DefaultKeyID, Method: jwt.SigningMethodHS256, SignKey: secret, VerifyKey: secret})
+	manager.SetFallbackKey(DefaultKeyID)
+	_ = manager.SetSigningKey(DefaultKeyID)
+	return manager
+}
+
+// ConfigureJWTKeys loads the key set from .env and the environment, see NewKeyManagerFromEnv.
+func ConfigureJWTKeys() error {
+	_ = godotenv.Load(".env")
+
+	manager, err := NewKeyManagerFromEnv()
+	if err != nil {
+		return err
+	}
+
+	SetJWTKeys(manager)
+	return nil
+}
+
+func SetJWTKeys(manager *KeyManager) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	defaultKey = manager
+}
+
+func JWTKeys() *KeyManager {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	return defaultKey
+}
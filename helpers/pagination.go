@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+type Pagination struct {
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+}
+
+// PaginatedData wraps one page of results together with the total number of matching rows.
+type PaginatedData struct {
+	Items      interface{} `json:"items"`
+	Page       int         `json:"page"`
+	PerPage    int         `json:"per_page"`
+	Total      int         `json:"total"`
+	TotalPages int         `json:"total_pages"`
+}
+
+// ParsePagination reads the page and per_page query parameters, falling back to the first page of DefaultPerPage rows.
+func ParsePagination(query url.Values) (Pagination, error) {
+	pagination := Pagination{Page: 1, PerPage: DefaultPerPage}
+
+	if value := query.Get("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return pagination, fmt.Errorf("page must be a positive number")
+		}
+		pagination.Page = page
+	}
+
+	if value := query.Get("per_page"); value != "" {
+		perPage, err := strconv.Atoi(value)
+		if err != nil || perPage < 1 || perPage > MaxPerPage {
+			return pagination, fmt.Errorf("per_page must be between 1 and %d", MaxPerPage)
+		}
+		pagination.PerPage = perPage
+	}
+
+	return pagination, nil
+}
+
+func (pagination Pagination) Offset() int {
+	return (pagination.Page - 1) * pagination.PerPage
+}
+
+func NewPaginatedData(items interface{}, pagination Pagination, total int) PaginatedData {
+	return PaginatedData{
+		Items:      items,
+		Page:       pagination.Page,
+		PerPage:    pagination.PerPage,
+		Total:      total,
+		TotalPages: (total + pagination.PerPage - 1) / pagination.PerPage,
+	}
+}
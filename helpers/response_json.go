@@ -3,6 +3,8 @@ package helpers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 type ApiResponse struct {
@@ -11,13 +13,32 @@ type ApiResponse struct {
 }
 
 type ApiResponseAuthorization struct {
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-	Token   string      `json:"token"`
+	Message      string      `json:"message"`
+	Data         interface{} `json:"data,omitempty"`
+	Token        string      `json:"token"`
+	RefreshToken string      `json:"refresh_token,omitempty"`
+	ExpiresIn    int         `json:"expires_in,omitempty"`
+}
+
+// RetryAfter is the data of a 429 response, telling the client how many seconds to wait.
+type RetryAfter struct {
+	Seconds int `json:"retry_after"`
+}
+
+// NewRetryAfter rounds the wait up to whole seconds, as the Retry-After header needs them.
+func NewRetryAfter(wait time.Duration) RetryAfter {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return RetryAfter{Seconds: seconds}
 }
 
 func SendJson(w http.ResponseWriter, code int, response ApiResponse) {
 	w.Header().Set("Content-Type", "application/json")
+	if retry, ok := response.Data.(RetryAfter); ok && code == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", strconv.Itoa(retry.Seconds))
+	}
 	w.WriteHeader(code)
 	err := json.NewEncoder(w).Encode(response)
 	if err != nil {
@@ -0,0 +1,29 @@
+package mailer
+
+import "time"
+
+const (
+	// MaxAttempts is how often the outbox tries to deliver a message before giving up on it.
+	MaxAttempts = 8
+
+	baseBackoff = time.Minute
+	maxBackoff  = 2 * time.Hour
+)
+
+// Backoff returns how long to wait before the next delivery attempt after the given number of
+// failed attempts: one minute, doubling each time, capped at two hours.
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+
+	delay := baseBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+
+	return delay
+}
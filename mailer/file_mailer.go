@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes every message to a file in Dir instead of sending it, for local development.
+type FileMailer struct {
+	Dir     string
+	counter atomic.Int64
+}
+
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{Dir: dir}
+}
+
+func (mailer *FileMailer) Send(ctx context.Context, message Message) error {
+	err := os.MkdirAll(mailer.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%03d-%s.eml", time.Now().Format("20060102-150405"), mailer.counter.Add(1)%1000, sanitize(message.To))
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "To: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n", message.To, message.Subject, time.Now().Format(time.RFC1123Z))
+	content.WriteString(message.Text)
+	content.WriteString("\r\n\r\n----- HTML -----\r\n\r\n")
+	content.WriteString(message.HTML)
+
+	return os.WriteFile(filepath.Join(mailer.Dir, name), []byte(content.String()), 0o644)
+}
+
+func sanitize(address string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, address)
+}
@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"github.com/joho/godotenv"
+	"log"
+	"os"
+	"sync"
+)
+
+// Message is an email with an HTML body and its plain text alternative.
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	HTML    string `json:"-"`
+	Text    string `json:"-"`
+}
+
+// Mailer delivers a single message. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+var (
+	mu            sync.RWMutex
+	defaultMailer Mailer = NewMemoryMailer()
+)
+
+// Configure picks the mailer from the MAILER environment variable: smtp (default), file or memory.
+func Configure() {
+	err := godotenv.Load(".env")
+	if err != nil {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+
+	switch os.Getenv("MAILER") {
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		SetDefault(NewFileMailer(dir))
+	case "memory":
+		SetDefault(NewMemoryMailer())
+	default:
+		SetDefault(NewSMTPMailerFromEnv())
+	}
+}
+
+func SetDefault(mailer Mailer) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultMailer = mailer
+}
+
+func Default() Mailer {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultMailer
+}
@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryMailer keeps sent messages in memory, for tests. Setting Err makes every Send fail with it.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+	Err      error
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (mailer *MemoryMailer) Send(ctx context.Context, message Message) error {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+
+	if mailer.Err != nil {
+		return mailer.Err
+	}
+
+	mailer.messages = append(mailer.messages, message)
+	return nil
+}
+
+// Messages returns a copy of the messages sent so far.
+func (mailer *MemoryMailer) Messages() []Message {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+
+	return append([]Message(nil), mailer.messages...)
+}
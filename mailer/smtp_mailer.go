@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"context"
+	"gopkg.in/gomail.v2"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTPMailerFromEnv reads SMTP_HOST, SMTP_PORT, SMTP_USER, SMTP_PASSWORD and SMTP_FROM.
+// The host defaults to Gmail on port 587 and the sender to the SMTP user.
+func NewSMTPMailerFromEnv() *SMTPMailer {
+	mailer := &SMTPMailer{
+		Host:     strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		Port:     587,
+		Username: strings.TrimSpace(os.Getenv("SMTP_USER")),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     strings.TrimSpace(os.Getenv("SMTP_FROM")),
+	}
+
+	if mailer.Host == "" {
+		mailer.Host = "smtp.gmail.com"
+	}
+	if port, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil {
+		mailer.Port = port
+	}
+	if mailer.From == "" {
+		mailer.From = mailer.Username
+	}
+
+	return mailer
+}
+
+func (mailer *SMTPMailer) Send(ctx context.Context, message Message) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", mailer.From)
+	m.SetHeader("To", message.To)
+	m.SetHeader("Subject", message.Subject)
+	m.SetBody("text/plain", message.Text)
+	m.AddAlternative("text/html", message.HTML)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- gomail.NewDialer(mailer.Host, mailer.Port, mailer.Username, mailer.Password).DialAndSend(m)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
@@ -0,0 +1,129 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmlTemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	textTemplate "text/template"
+)
+
+const (
+	LanguageIndonesian = "id"
+	LanguageEnglish    = "en"
+
+	// DefaultLanguage is used for users without a preference and for unsupported languages.
+	DefaultLanguage = LanguageIndonesian
+)
+
+// Languages lists the languages every template has a variant for.
+var Languages = []string{LanguageIndonesian, LanguageEnglish}
+
+//go:embed templates
+var templateFiles embed.FS
+
+type mailTemplate struct {
+	html *htmlTemplate.Template
+	text *textTemplate.Template
+}
+
+// templates holds every mail template keyed by "<language>/<name>". Each variant is made of
+// templates/<language>/<name>.html, which defines the "content" block of the shared layout, and
+// templates/<language>/<name>.txt, which defines the "subject" block and holds the plain text body.
+var templates = mustLoadTemplates()
+
+func mustLoadTemplates() map[string]mailTemplate {
+	loaded := make(map[string]mailTemplate)
+
+	for _, language := range Languages {
+		names, err := fs.Glob(templateFiles, path.Join("templates", language, "*.txt"))
+		if err != nil {
+			panic(err)
+		}
+
+		funcs := htmlTemplate.FuncMap{"lang": func() string { return language }}
+
+		for _, file := range names {
+			name := strings.TrimSuffix(path.Base(file), ".txt")
+
+			html, err := htmlTemplate.New("layout.html").Funcs(funcs).ParseFS(templateFiles,
+				"templates/layout.html",
+				path.Join("templates", language, "footer.html"),
+				path.Join("templates", language, name+".html"),
+			)
+			if err != nil {
+				panic(fmt.Sprintf("mailer: parse %s/%s.html: %v", language, name, err))
+			}
+
+			text, err := textTemplate.ParseFS(templateFiles, file)
+			if err != nil {
+				panic(fmt.Sprintf("mailer: parse %s/%s.txt: %v", language, name, err))
+			}
+
+			loaded[language+"/"+name] = mailTemplate{html: html, text: text}
+		}
+	}
+
+	return loaded
+}
+
+// NormalizeLanguage maps a stored preference or an Accept-Language header to a supported language.
+func NormalizeLanguage(language string) string {
+	supported, ok := SupportedLanguage(language)
+	if !ok {
+		return DefaultLanguage
+	}
+	return supported
+}
+
+// SupportedLanguage maps a preference such as "EN" or "en-US" to a supported language, it returns false
+// when none matches.
+func SupportedLanguage(language string) (string, bool) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if len(language) > 2 {
+		language = language[:2]
+	}
+
+	for _, supported := range Languages {
+		if language == supported {
+			return supported, true
+		}
+	}
+
+	return "", false
+}
+
+// Render builds the message addressed to `to` from the named template in the given language.
+func Render(to, name, language string, data any) (Message, error) {
+	tmpl, ok := templates[NormalizeLanguage(language)+"/"+name]
+	if !ok {
+		return Message{}, fmt.Errorf("mailer: unknown template %q", name)
+	}
+
+	var subject, htmlBody, textBody bytes.Buffer
+
+	err := tmpl.text.ExecuteTemplate(&subject, "subject", data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	err = tmpl.text.Execute(&textBody, data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	err = tmpl.html.Execute(&htmlBody, data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    htmlBody.String(),
+		Text:    strings.TrimSpace(textBody.String()) + "\n",
+	}, nil
+}
@@ -0,0 +1,73 @@
+package mailer
+
+// Template names, each with a variant per language under templates/.
+const (
+	TemplateOtp           = "otp"
+	TemplateLoanReminder  = "loan_reminder"
+	TemplateHoldReady     = "hold_ready"
+	TemplatePasswordReset = "password_reset"
+	TemplateVerifyEmail   = "verify_email"
+	TemplateAccountLocked = "account_locked"
+)
+
+// OtpData fills the otp template.
+type OtpData struct {
+	Otp     string
+	Minutes int
+}
+
+// PasswordResetData fills the password_reset template.
+type PasswordResetData struct {
+	Username string
+	Otp      string
+	Minutes  int
+}
+
+// VerifyEmailData fills the verify_email template.
+type VerifyEmailData struct {
+	Username string
+	Otp      string
+	Minutes  int
+}
+
+// AccountLockedData fills the account_locked template.
+type AccountLockedData struct {
+	Username string
+	Attempts int
+	IP       string
+	Minutes  int
+}
+
+// HoldReadyData fills the hold_ready template.
+type HoldReadyData struct {
+	Username       string
+	Title          string
+	PickupDeadline string
+}
+
+// ReminderLoan is one loan listed in a loan_reminder mail. Fine is the formatted fine so far, if any.
+type ReminderLoan struct {
+	Title      string
+	ReturnDate string
+	DaysLate   int
+	Fine       string
+}
+
+func (loan ReminderLoan) Overdue() bool {
+	return loan.DaysLate > 0
+}
+
+// LoanReminderData fills the loan_reminder template.
+type LoanReminderData struct {
+	Username string
+	Loans    []ReminderLoan
+}
+
+func (data LoanReminderData) HasOverdue() bool {
+	for _, loan := range data.Loans {
+		if loan.Overdue() {
+			return true
+		}
+	}
+	return false
+}
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
 	"go-libraryschool/config"
-	"go-libraryschool/controllers/otp_email_controller"
 	"go-libraryschool/docs"
+	"go-libraryschool/helpers"
+	"go-libraryschool/mailer"
 	"go-libraryschool/middlewares"
+	"go-libraryschool/repository/hold_repository"
+	"go-libraryschool/repository/mail_outbox_repository"
+	"go-libraryschool/repository/permission_repository"
+	"go-libraryschool/repository/reminder_repository"
+	"go-libraryschool/repository/user_repository"
 	"go-libraryschool/routes"
+	"go-libraryschool/scheduler"
 	"log"
 	"net/http"
+	"time"
 )
 
 // @securityDefinitions.apikey BearerAuth
@@ -28,14 +37,52 @@ func main() {
 
 	rdb := config.ConnRedis()
 
+	err = helpers.ConfigureJWTKeys()
+	if err != nil {
+		log.Fatalf("Error loading JWT keys: %v", err)
+	}
+
 	logLogrus := config.LogrusLogger()
 
 	mux := http.NewServeMux()
 	routes.Router(mux, db, logLogrus, rdb)
 
 	middlewares.SetRedisClientMiddleware(rdb)
+	middlewares.SetPermissionChecker(permission_repository.NewPermissionRepository(db, logLogrus))
+	middlewares.SetSuspensionChecker(user_repository.NewUserRepository(db, logLogrus, rdb))
+
+	mailer.Configure()
+
+	reminderRepo := reminder_repository.NewReminderRepository(db, logLogrus)
+	holdRepo := hold_repository.NewHoldRepository(db, logLogrus, rdb)
+	outboxRepo := mail_outbox_repository.NewMailOutboxRepository(db, logLogrus)
 
-	otp_email_controller.SetOtpEmail()
+	jobs := scheduler.NewScheduler(logLogrus)
+	jobs.Add(scheduler.Job{
+		Name:       "loan-reminders",
+		Next:       scheduler.Daily(7, 0),
+		Run:        reminderRepo.SendLoanRemindersRepository,
+		Timeout:    5 * time.Minute,
+		RunOnStart: true,
+	})
+	jobs.Add(scheduler.Job{
+		Name:       "process-holds",
+		Next:       scheduler.Every(5 * time.Minute),
+		Run:        holdRepo.ProcessHoldsRepository,
+		RunOnStart: true,
+	})
+	jobs.Add(scheduler.Job{
+		Name:       "deliver-mail",
+		Next:       scheduler.Every(15 * time.Second),
+		Run:        outboxRepo.DeliverPendingRepository,
+		RunOnStart: true,
+	})
+	jobs.Add(scheduler.Job{
+		Name: "purge-mail",
+		Next: scheduler.Daily(3, 0),
+		Run:  outboxRepo.PurgeMailsRepository,
+	})
+	jobs.Start(context.Background())
 
 	err = http.ListenAndServe(":8080", mux)
 	if err != nil {
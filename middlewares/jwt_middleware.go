@@ -5,19 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
 	"go-libraryschool/helpers"
 	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/ratelimit"
 	"net/http"
-	"os"
 	"strings"
-
-	"github.com/golang-jwt/jwt/v5"
 )
 
-var (
-	jwtKey = []byte(os.Getenv("JWT_KEY"))
-	rdb    *redis.Client
-)
+var rdb *redis.Client
 
 type contextKey string
 
@@ -25,9 +21,10 @@ const UserContextKey = contextKey("user")
 
 func SetRedisClientMiddleware(redisClient *redis.Client) {
 	rdb = redisClient
+	SetRateLimiter(ratelimit.NewLimiter(redisClient))
 }
 
-func JWTMiddleware(allowedRoles ...string) func(http.Handler) http.Handler {
+func JWTMiddleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -49,9 +46,7 @@ func JWTMiddleware(allowedRoles ...string) func(http.Handler) http.Handler {
 			tokenStr := parts[1]
 			claims := &jwt_models.JWTClaims{}
 
-			token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-				return jwtKey, nil
-			})
+			token, err := helpers.JWTKeys().Parse(tokenStr, claims)
 			if err != nil || !token.Valid {
 				helpers.SendJson(w, http.StatusUnauthorized, helpers.ApiResponse{
 					Message: "Invalid or expired token",
@@ -70,21 +65,41 @@ func JWTMiddleware(allowedRoles ...string) func(http.Handler) http.Handler {
 				}
 			}
 
-			if len(allowedRoles) > 0 {
-				roleMatch := false
-				for _, role := range allowedRoles {
-					if claims.Roles == role {
-						roleMatch = true
-						break
-					}
-				}
+			revoked, err := tokenRevoked(ctxRedis, claims)
+			if err == nil && !revoked {
+				revoked, err = sessionRevoked(ctxRedis, claims)
+			}
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"error":   err,
+					"user_id": claims.UserID,
+				}).Error("Failed to check token revocation")
+
+				helpers.SendJson(w, http.StatusInternalServerError, helpers.ApiResponse{
+					Message: "Failed to check token revocation",
+				})
+				return
+			}
 
-				if !roleMatch {
-					helpers.SendJson(w, http.StatusForbidden, helpers.ApiResponse{
-						Message: "Forbidden",
-					})
-					return
-				}
+			if revoked {
+				helpers.SendJson(w, http.StatusUnauthorized, helpers.ApiResponse{
+					Message: "Token has been revoked",
+				})
+				return
+			}
+
+			suspended, err := userSuspended(r.Context(), claims.UserID)
+			if err != nil {
+				helpers.SendJson(w, http.StatusInternalServerError, helpers.ApiResponse{
+					Message: "Failed to check account status",
+				})
+				return
+			}
+			if suspended {
+				helpers.SendJson(w, http.StatusForbidden, helpers.ApiResponse{
+					Message: "Account suspended",
+				})
+				return
 			}
 
 			ctx := context.WithValue(r.Context(), UserContextKey, claims)
@@ -105,9 +120,7 @@ func ExtractTokenFromHeader(r *http.Request) (string, error) {
 
 func VerifyToken(tokenStr string) (*jwt_models.JWTClaims, error) {
 	claims := &jwt_models.JWTClaims{}
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtKey, nil
-	})
+	token, err := helpers.JWTKeys().Parse(tokenStr, claims)
 	if err != nil || !token.Valid {
 		fmt.Println("invalid token or expired token")
 		return nil, errors.New("invalid token or expired token")
@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"context"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/jwt_models"
+	"net/http"
+	"sync"
+)
+
+// PermissionChecker tells whether a role holds a permission.
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, role, permission string) (bool, error)
+}
+
+var (
+	permissionMu      sync.RWMutex
+	permissionChecker PermissionChecker
+)
+
+func SetPermissionChecker(checker PermissionChecker) {
+	permissionMu.Lock()
+	defer permissionMu.Unlock()
+	permissionChecker = checker
+}
+
+// HasPermission reports whether the role of the logged in user holds the permission.
+// It is false when the request did not pass JWTMiddleware or permissions cannot be checked.
+func HasPermission(r *http.Request, permission string) bool {
+	claims, ok := r.Context().Value(UserContextKey).(*jwt_models.JWTClaims)
+	if !ok {
+		return false
+	}
+
+	permissionMu.RLock()
+	checker := permissionChecker
+	permissionMu.RUnlock()
+
+	if checker == nil {
+		return false
+	}
+
+	allowed, err := checker.HasPermission(r.Context(), claims.Roles, permission)
+	return err == nil && allowed
+}
+
+// PermissionMiddleware lets through the users whose role holds the permission. It has to run inside JWTMiddleware.
+func PermissionMiddleware(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasPermission(r, permission) {
+				helpers.SendJson(w, http.StatusForbidden, helpers.ApiResponse{
+					Message: "Forbidden",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
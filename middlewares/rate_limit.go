@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/ratelimit"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// limiterTimeout bounds how long a request waits on the limiter before it is let through.
+const limiterTimeout = time.Second
+
+var (
+	limiterMu sync.RWMutex
+	limiter   ratelimit.Limiter = ratelimit.NewMemoryLimiter()
+)
+
+// SetRateLimiter replaces the limiter behind RateLimitMiddleware.
+func SetRateLimiter(rateLimiter ratelimit.Limiter) {
+	limiterMu.Lock()
+	defer limiterMu.Unlock()
+	limiter = rateLimiter
+}
+
+func rateLimiter() ratelimit.Limiter {
+	limiterMu.RLock()
+	defer limiterMu.RUnlock()
+	return limiter
+}
+
+// RateLimitMiddleware holds the route to its budget in ratelimit. Requests are counted per user when it runs
+// inside JWTMiddleware and per client IP otherwise, and answered with 429 and Retry-After once the budget is spent.
+// It lets requests through when the limiter fails, a broken limiter should not take the API down.
+func RateLimitMiddleware(route string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := "ip:" + helpers.ClientIP(r)
+			if claims, ok := r.Context().Value(UserContextKey).(*jwt_models.JWTClaims); ok {
+				client = fmt.Sprintf("user:%d", claims.UserID)
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), limiterTimeout)
+			wait, err := rateLimiter().Allow(ctx, route+":"+client, ratelimit.Budget(route))
+			cancel()
+			if err == nil && wait > 0 {
+				helpers.SendJson(w, http.StatusTooManyRequests, helpers.ApiResponse{
+					Message: "Too many requests, please try again later",
+					Data:    helpers.NewRetryAfter(wait),
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
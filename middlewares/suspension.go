@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"context"
+	"sync"
+)
+
+// SuspensionChecker tells whether a user account has been suspended by a Manager.
+type SuspensionChecker interface {
+	IsSuspended(ctx context.Context, userID int) (bool, error)
+}
+
+var (
+	suspensionMu      sync.RWMutex
+	suspensionChecker SuspensionChecker
+)
+
+func SetSuspensionChecker(checker SuspensionChecker) {
+	suspensionMu.Lock()
+	defer suspensionMu.Unlock()
+	suspensionChecker = checker
+}
+
+// userSuspended reports whether the account of the token holder is suspended. Without a checker nobody is.
+func userSuspended(ctx context.Context, userID int) (bool, error) {
+	suspensionMu.RLock()
+	checker := suspensionChecker
+	suspensionMu.RUnlock()
+
+	if checker == nil {
+		return false, nil
+	}
+
+	return checker.IsSuspended(ctx, userID)
+}
@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/repository/refresh_token_repository"
+	"strconv"
+	"time"
+)
+
+func revokedBeforeKey(userID int) string {
+	return fmt.Sprintf("revoked-before:%d", userID)
+}
+
+// RevokeUserTokens invalidates every access token issued to the user so far, e.g. after a password reset.
+// The marker holds the revocation time in milliseconds and only has to outlive the tokens it invalidates.
+func RevokeUserTokens(ctx context.Context, userID int) error {
+	if rdb == nil {
+		return errors.New("redis client not configured")
+	}
+
+	return rdb.Set(ctx, revokedBeforeKey(userID), time.Now().UnixMilli(), helpers.AccessTokenLifetime).Err()
+}
+
+// tokenRevoked reports whether the token was issued no later than the user's tokens were last revoked.
+// Tokens carry their issue time in whole seconds, so a token issued within the second of the revocation
+// is revoked too, even if it came right after. An error means the marker could not be read, callers refuse
+// the token then.
+func tokenRevoked(ctx context.Context, claims *jwt_models.JWTClaims) (bool, error) {
+	if rdb == nil {
+		return false, nil
+	}
+
+	value, err := rdb.Get(ctx, revokedBeforeKey(claims.UserID)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	revokedBefore, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false, err
+	}
+
+	// A token without an issue time cannot prove it is newer than the revocation.
+	if claims.IssuedAt == nil {
+		return true, nil
+	}
+
+	return claims.IssuedAt.Unix() <= revokedBefore/1000, nil
+}
+
+// sessionRevoked reports whether the session the token was issued for has been logged out or revoked.
+// Tokens without a session ID predate sessions and are only subject to the checks above. An error means
+// the session could not be read, callers refuse the token then.
+func sessionRevoked(ctx context.Context, claims *jwt_models.JWTClaims) (bool, error) {
+	if rdb == nil || claims.ID == "" {
+		return false, nil
+	}
+
+	_, ok, err := refresh_token_repository.LoadFamily(ctx, rdb, claims.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return !ok, nil
+}
@@ -0,0 +1,15 @@
+-- Book returns: a loan is closed by stamping returned_at, late fines are kept in due_date_data.
+
+ALTER TABLE borrowed_books
+    ADD COLUMN returned_at DATETIME NULL AFTER return_date;
+
+CREATE TABLE IF NOT EXISTS due_date_data
+(
+    due_date_id    INT AUTO_INCREMENT PRIMARY KEY,
+    borrow_id      INT      NOT NULL,
+    difference     INT      NOT NULL,
+    amount_of_fine INT      NOT NULL,
+    created_at     DATETIME NOT NULL,
+    updated_at     DATETIME NOT NULL,
+    CONSTRAINT fk_due_date_data_borrow FOREIGN KEY (borrow_id) REFERENCES borrowed_books (borrow_id) ON DELETE CASCADE
+);
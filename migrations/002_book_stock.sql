@@ -0,0 +1,10 @@
+-- Stock accounting: books.quantity is the total number of copies, books.available the copies on the shelf.
+
+ALTER TABLE books
+    ADD COLUMN available INT NOT NULL DEFAULT 0 AFTER quantity;
+
+UPDATE books b
+SET b.available = GREATEST(b.quantity - (SELECT COUNT(*)
+                                         FROM borrowed_books bb
+                                         WHERE bb.book_id = b.book_id
+                                           AND bb.returned_at IS NULL), 0);
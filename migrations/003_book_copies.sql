@@ -0,0 +1,60 @@
+-- Per-copy inventory: every physical copy has its own barcode and status, loans point at a copy.
+-- books.quantity and books.available are kept as counters derived from book_copies.
+
+CREATE TABLE IF NOT EXISTS book_copies
+(
+    copy_id          INT AUTO_INCREMENT PRIMARY KEY,
+    book_id          INT                                          NOT NULL,
+    barcode          VARCHAR(64)                                  NOT NULL UNIQUE,
+    accession_number VARCHAR(64)                                  NOT NULL UNIQUE,
+    `condition`      VARCHAR(32)                                  NOT NULL DEFAULT 'good',
+    shelf_location   VARCHAR(64)                                  NOT NULL DEFAULT '',
+    status           ENUM ('available', 'on_loan', 'lost', 'repair') NOT NULL DEFAULT 'available',
+    created_at       DATETIME                                     NOT NULL,
+    updated_at       DATETIME                                     NOT NULL,
+    INDEX idx_book_copies_book_status (book_id, status),
+    CONSTRAINT fk_book_copies_book FOREIGN KEY (book_id) REFERENCES books (book_id) ON DELETE CASCADE
+);
+
+ALTER TABLE borrowed_books
+    ADD COLUMN copy_id INT NULL AFTER book_id,
+    ADD CONSTRAINT fk_borrowed_books_copy FOREIGN KEY (copy_id) REFERENCES book_copies (copy_id);
+
+-- The sequence below recurses once per copy of the largest book, past the default limit of 1000 levels.
+SELECT GREATEST(COALESCE(MAX(quantity), 0) + 1, 1000) INTO @copy_seed_depth FROM books;
+SET SESSION cte_max_recursion_depth = @copy_seed_depth;
+
+-- One copy per unit of the old quantity, with the same barcode format the API generates. The pad widths grow
+-- with the value, LPAD would cut longer book IDs and copy numbers short.
+INSERT INTO book_copies (book_id, barcode, accession_number, `condition`, shelf_location, status, created_at, updated_at)
+WITH RECURSIVE seq (n) AS (SELECT 1
+                           UNION ALL
+                           SELECT n + 1
+                           FROM seq
+                           WHERE n < (SELECT COALESCE(MAX(quantity), 0) FROM books))
+SELECT b.book_id,
+       CONCAT('BK', LPAD(b.book_id, GREATEST(LENGTH(b.book_id), 6), '0'), '-', LPAD(seq.n, GREATEST(LENGTH(seq.n), 3), '0')),
+       CONCAT('ACC-', b.book_id, '-', seq.n),
+       'good',
+       '',
+       'available',
+       NOW(),
+       NOW()
+FROM books b
+         JOIN seq ON seq.n <= b.quantity;
+
+SET SESSION cte_max_recursion_depth = DEFAULT;
+
+-- Attach the loans that are still open to a copy.
+UPDATE borrowed_books bb
+    JOIN (SELECT borrow_id, book_id, ROW_NUMBER() OVER (PARTITION BY book_id ORDER BY borrow_id) AS rn
+          FROM borrowed_books
+          WHERE returned_at IS NULL) open_loans ON open_loans.borrow_id = bb.borrow_id
+    JOIN book_copies c ON c.barcode = CONCAT('BK', LPAD(open_loans.book_id, GREATEST(LENGTH(open_loans.book_id), 6), '0'), '-',
+                                             LPAD(open_loans.rn, GREATEST(LENGTH(open_loans.rn), 3), '0'))
+SET bb.copy_id = c.copy_id,
+    c.status   = 'on_loan';
+
+UPDATE books b
+SET b.quantity  = (SELECT COUNT(*) FROM book_copies c WHERE c.book_id = b.book_id AND c.status <> 'lost'),
+    b.available = (SELECT COUNT(*) FROM book_copies c WHERE c.book_id = b.book_id AND c.status = 'available');
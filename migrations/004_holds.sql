@@ -0,0 +1,22 @@
+-- Hold queue: patrons wait FIFO per title, a returned copy is set aside (on_hold) for the head of the queue.
+
+ALTER TABLE book_copies
+    MODIFY status ENUM ('available', 'on_loan', 'on_hold', 'lost', 'repair') NOT NULL DEFAULT 'available';
+
+CREATE TABLE IF NOT EXISTS holds
+(
+    hold_id         INT AUTO_INCREMENT PRIMARY KEY,
+    book_id         INT                                                              NOT NULL,
+    user_id         INT                                                              NOT NULL,
+    copy_id         INT                                                              NULL,
+    status          ENUM ('waiting', 'ready', 'fulfilled', 'expired', 'cancelled') NOT NULL DEFAULT 'waiting',
+    ready_at        DATETIME                                                         NULL,
+    pickup_deadline DATETIME                                                         NULL,
+    created_at      DATETIME                                                         NOT NULL,
+    updated_at      DATETIME                                                         NOT NULL,
+    INDEX idx_holds_queue (book_id, status, hold_id),
+    INDEX idx_holds_user (user_id, status),
+    CONSTRAINT fk_holds_book FOREIGN KEY (book_id) REFERENCES books (book_id) ON DELETE CASCADE,
+    CONSTRAINT fk_holds_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_holds_copy FOREIGN KEY (copy_id) REFERENCES book_copies (copy_id)
+);
@@ -0,0 +1,17 @@
+-- Loan renewals: every extension of a due date is kept as history, borrowed_books counts them for the cap.
+
+ALTER TABLE borrowed_books
+    ADD COLUMN renewal_count INT NOT NULL DEFAULT 0 AFTER returned_at;
+
+CREATE TABLE IF NOT EXISTS loan_renewals
+(
+    renewal_id           INT AUTO_INCREMENT PRIMARY KEY,
+    borrow_id            INT      NOT NULL,
+    previous_return_date DATE     NOT NULL,
+    new_return_date      DATE     NOT NULL,
+    renewed_by           INT      NOT NULL,
+    created_at           DATETIME NOT NULL,
+    INDEX idx_loan_renewals_borrow (borrow_id),
+    CONSTRAINT fk_loan_renewals_borrow FOREIGN KEY (borrow_id) REFERENCES borrowed_books (borrow_id) ON DELETE CASCADE,
+    CONSTRAINT fk_loan_renewals_user FOREIGN KEY (renewed_by) REFERENCES users (id)
+);
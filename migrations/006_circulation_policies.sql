@@ -0,0 +1,23 @@
+-- Circulation policies: loan period and fine rules per role and/or genre. NULL means "any".
+-- The most specific matching policy wins: role + genre, then genre, then role, then the catch-all row.
+
+CREATE TABLE IF NOT EXISTS circulation_policies
+(
+    policy_id        INT AUTO_INCREMENT PRIMARY KEY,
+    role_id          INT         NULL,
+    genre_id         INT         NULL,
+    loan_period_days INT         NOT NULL,
+    daily_fine       INT         NOT NULL DEFAULT 0,
+    max_fine         INT         NOT NULL DEFAULT 0,
+    grace_days       INT         NOT NULL DEFAULT 0,
+    currency         VARCHAR(3)  NOT NULL DEFAULT 'IDR',
+    created_at       DATETIME    NOT NULL,
+    updated_at       DATETIME    NOT NULL,
+    INDEX idx_circulation_policies_scope (role_id, genre_id),
+    CONSTRAINT fk_circulation_policies_role FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE,
+    CONSTRAINT fk_circulation_policies_genre FOREIGN KEY (genre_id) REFERENCES genres (genre_id) ON DELETE CASCADE
+);
+
+-- Catch-all policy matching the previous hard-coded behaviour.
+INSERT INTO circulation_policies (role_id, genre_id, loan_period_days, daily_fine, max_fine, grace_days, currency, created_at, updated_at)
+VALUES (NULL, NULL, 7, 20000, 0, 0, 'IDR', NOW(), NOW());
@@ -0,0 +1,43 @@
+-- Fine ledger: fines are kept in integer minor units (1/100 of the major unit, except JPY and KRW)
+-- and settled by payments and waivers recorded in fine_transactions.
+
+UPDATE circulation_policies
+SET daily_fine = daily_fine * 100,
+    max_fine   = max_fine * 100
+WHERE currency NOT IN ('JPY', 'KRW');
+
+ALTER TABLE circulation_policies
+    MODIFY daily_fine BIGINT NOT NULL DEFAULT 0,
+    MODIFY max_fine BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE due_date_data
+    ADD COLUMN user_id INT NULL AFTER borrow_id,
+    MODIFY amount_of_fine BIGINT NOT NULL,
+    ADD COLUMN amount_paid BIGINT NOT NULL DEFAULT 0 AFTER amount_of_fine,
+    ADD COLUMN amount_waived BIGINT NOT NULL DEFAULT 0 AFTER amount_paid,
+    ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'IDR' AFTER amount_waived,
+    ADD COLUMN status ENUM ('outstanding', 'partially_paid', 'settled') NOT NULL DEFAULT 'outstanding' AFTER currency;
+
+UPDATE due_date_data d
+    JOIN borrowed_books bb ON bb.borrow_id = d.borrow_id
+SET d.user_id        = bb.user_id,
+    d.amount_of_fine = d.amount_of_fine * 100;
+
+ALTER TABLE due_date_data
+    MODIFY user_id INT NOT NULL,
+    ADD INDEX idx_due_date_data_user (user_id, status),
+    ADD CONSTRAINT fk_due_date_data_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+
+CREATE TABLE IF NOT EXISTS fine_transactions
+(
+    transaction_id INT AUTO_INCREMENT PRIMARY KEY,
+    due_date_id    INT                         NOT NULL,
+    type           ENUM ('payment', 'waiver') NOT NULL,
+    amount         BIGINT                      NOT NULL,
+    reason         VARCHAR(255)                NOT NULL DEFAULT '',
+    recorded_by    INT                         NOT NULL,
+    created_at     DATETIME                    NOT NULL,
+    INDEX idx_fine_transactions_fine (due_date_id),
+    CONSTRAINT fk_fine_transactions_fine FOREIGN KEY (due_date_id) REFERENCES due_date_data (due_date_id) ON DELETE CASCADE,
+    CONSTRAINT fk_fine_transactions_user FOREIGN KEY (recorded_by) REFERENCES users (id)
+);
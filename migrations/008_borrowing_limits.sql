@@ -0,0 +1,11 @@
+-- Borrowing limits: maximum concurrent loans (0 = unlimited) and the unpaid fine a borrower may carry,
+-- in minor units, before further loans are refused. Taken from the policy of the borrower's role.
+
+ALTER TABLE circulation_policies
+    ADD COLUMN max_loans       INT    NOT NULL DEFAULT 0 AFTER currency,
+    ADD COLUMN max_unpaid_fine BIGINT NOT NULL DEFAULT 0 AFTER max_loans;
+
+UPDATE circulation_policies
+SET max_loans = 3
+WHERE role_id IS NULL
+  AND genre_id IS NULL;
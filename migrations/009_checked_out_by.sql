@@ -0,0 +1,6 @@
+-- Loans remember the staff member who checked the book out on behalf of the borrower.
+-- Loans created by the borrower themselves record their own ID, older loans stay NULL.
+
+ALTER TABLE borrowed_books
+    ADD COLUMN checked_out_by INT NULL AFTER user_id,
+    ADD CONSTRAINT fk_borrowed_books_checked_out_by FOREIGN KEY (checked_out_by) REFERENCES users (id);
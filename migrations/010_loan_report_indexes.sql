@@ -0,0 +1,6 @@
+-- Indexes for the filtered loan listings (my loans and the staff borrowing report).
+
+ALTER TABLE borrowed_books
+    ADD INDEX idx_borrowed_books_user_borrow_date (user_id, borrow_date),
+    ADD INDEX idx_borrowed_books_book_borrow_date (book_id, borrow_date),
+    ADD INDEX idx_borrowed_books_borrow_date (borrow_date);
@@ -0,0 +1,12 @@
+-- Loan reminders: one row per loan, kind and day a reminder was sent, so the daily job never mails twice.
+
+CREATE TABLE IF NOT EXISTS loan_reminders
+(
+    reminder_id INT AUTO_INCREMENT PRIMARY KEY,
+    borrow_id   INT                           NOT NULL,
+    kind        ENUM ('due_soon', 'overdue') NOT NULL,
+    sent_on     DATE                          NOT NULL,
+    created_at  DATETIME                      NOT NULL,
+    UNIQUE KEY uq_loan_reminders_day (borrow_id, kind, sent_on),
+    CONSTRAINT fk_loan_reminders_borrow FOREIGN KEY (borrow_id) REFERENCES borrowed_books (borrow_id) ON DELETE CASCADE
+);
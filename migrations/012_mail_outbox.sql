@@ -0,0 +1,20 @@
+-- Mail outbox: every email is queued here first and delivered by a background job,
+-- failed attempts are retried with exponential backoff until they are marked failed.
+
+CREATE TABLE IF NOT EXISTS mail_outbox
+(
+    mail_id         INT AUTO_INCREMENT PRIMARY KEY,
+    recipient       VARCHAR(255)                         NOT NULL,
+    subject         VARCHAR(255)                         NOT NULL,
+    html_body       MEDIUMTEXT                           NOT NULL,
+    text_body       MEDIUMTEXT                           NOT NULL,
+    status          ENUM ('pending', 'sent', 'failed') NOT NULL DEFAULT 'pending',
+    attempts        INT                                  NOT NULL DEFAULT 0,
+    last_error      VARCHAR(500)                         NULL,
+    next_attempt_at DATETIME                             NOT NULL,
+    sent_at         DATETIME                             NULL,
+    created_at      DATETIME                             NOT NULL,
+    updated_at      DATETIME                             NOT NULL,
+    INDEX idx_mail_outbox_queue (status, next_attempt_at),
+    INDEX idx_mail_outbox_recipient (recipient)
+);
@@ -0,0 +1,4 @@
+-- Language preference of each user, used to pick the variant of the mails sent to them (id or en).
+
+ALTER TABLE users
+    ADD COLUMN language VARCHAR(2) NOT NULL DEFAULT 'id' AFTER email;
@@ -0,0 +1,9 @@
+-- Email verification: new accounts stay unverified until they confirm the OTP sent on registration.
+-- Accounts that existed before are considered verified.
+
+ALTER TABLE users
+    ADD COLUMN email_verified_at DATETIME NULL;
+
+UPDATE users
+SET email_verified_at = created_at
+WHERE email_verified_at IS NULL;
@@ -0,0 +1,57 @@
+-- Permissions: routes are authorized by permission, and which role holds which permission is kept here
+-- so Managers can change it without a redeploy. The seed mirrors the role lists the routes had before.
+
+CREATE TABLE IF NOT EXISTS permissions
+(
+    permission_id INT AUTO_INCREMENT PRIMARY KEY,
+    name          VARCHAR(64)  NOT NULL,
+    description   VARCHAR(255) NOT NULL,
+    UNIQUE KEY uq_permissions_name (name)
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions
+(
+    role_id       INT NOT NULL,
+    permission_id INT NOT NULL,
+    PRIMARY KEY (role_id, permission_id),
+    CONSTRAINT fk_role_permissions_role FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE,
+    CONSTRAINT fk_role_permissions_permission FOREIGN KEY (permission_id) REFERENCES permissions (permission_id) ON DELETE CASCADE
+);
+
+INSERT INTO permissions (name, description)
+VALUES ('book:read', 'Browse, search and view books'),
+       ('book:create', 'Add books'),
+       ('book:update', 'Update books'),
+       ('book:delete', 'Delete books'),
+       ('copy:manage', 'Add, list and update book copies'),
+       ('loan:checkout', 'Borrow and renew books for yourself and list your loans'),
+       ('loan:manage', 'Check out and renew for other users, return books and view borrowing data'),
+       ('favorite:manage', 'Keep a list of favorite books'),
+       ('hold:place', 'Place, list and cancel your own holds'),
+       ('hold:manage', 'View every hold queue and cancel any hold'),
+       ('fine:read', 'List your own fines'),
+       ('fine:collect', 'Record fine payments and list every fine'),
+       ('fine:waive', 'Waive fines'),
+       ('policy:read', 'List circulation policies'),
+       ('policy:manage', 'Add, update and delete circulation policies'),
+       ('mail:manage', 'Inspect the mail outbox and retry mails'),
+       ('profile:manage', 'View and update your own profile'),
+       ('session:manage', 'List and revoke your own sessions'),
+       ('session:revoke-any', 'Log any user out of every session'),
+       ('user:unlock', 'Unlock accounts locked after failed logins'),
+       ('role:manage', 'Edit which permissions each role has');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.permission_id
+FROM roles r
+         JOIN permissions p
+WHERE (r.role = 'Manager' AND p.name IN ('book:read', 'book:create', 'book:update', 'book:delete', 'copy:manage',
+                                         'loan:checkout', 'loan:manage', 'hold:place', 'hold:manage', 'fine:read',
+                                         'fine:collect', 'fine:waive', 'policy:read', 'policy:manage', 'mail:manage',
+                                         'profile:manage', 'session:manage', 'session:revoke-any', 'user:unlock',
+                                         'role:manage'))
+   OR (r.role = 'Librarian' AND p.name IN ('book:read', 'book:create', 'book:delete', 'copy:manage', 'loan:checkout',
+                                           'loan:manage', 'favorite:manage', 'hold:place', 'hold:manage', 'fine:read',
+                                           'fine:collect', 'policy:read', 'profile:manage', 'session:manage'))
+   OR (r.role = 'Student' AND p.name IN ('book:read', 'loan:checkout', 'favorite:manage', 'hold:place', 'fine:read',
+                                         'profile:manage', 'session:manage'));
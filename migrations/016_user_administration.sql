@@ -0,0 +1,15 @@
+-- User administration: Managers can suspend accounts, and suspended users are refused even with a valid token.
+
+ALTER TABLE users
+    ADD COLUMN suspended_at     DATETIME     NULL,
+    ADD COLUMN suspended_reason VARCHAR(255) NULL;
+
+INSERT INTO permissions (name, description)
+VALUES ('user:manage', 'List users, create accounts, change roles, suspend accounts and reset passwords');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.permission_id
+FROM roles r
+         JOIN permissions p
+WHERE r.role = 'Manager'
+  AND p.name = 'user:manage';
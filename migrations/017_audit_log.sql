@@ -0,0 +1,42 @@
+-- Audit log of privileged actions. Entries are written in the transaction of the action they record and are
+-- never changed afterwards, the triggers refuse updates and deletes.
+
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    audit_id    BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor_id    INT          NULL,
+    actor_role  VARCHAR(32)  NOT NULL DEFAULT '',
+    action      VARCHAR(64)  NOT NULL,
+    entity_type VARCHAR(32)  NOT NULL,
+    entity_id   VARCHAR(64)  NOT NULL,
+    before_data JSON         NULL,
+    after_data  JSON         NULL,
+    ip          VARCHAR(45)  NOT NULL DEFAULT '',
+    user_agent  VARCHAR(255) NOT NULL DEFAULT '',
+    method      VARCHAR(10)  NOT NULL DEFAULT '',
+    path        VARCHAR(255) NOT NULL DEFAULT '',
+    created_at  DATETIME     NOT NULL,
+    INDEX idx_audit_log_actor (actor_id, created_at),
+    INDEX idx_audit_log_entity (entity_type, entity_id, created_at),
+    INDEX idx_audit_log_created (created_at)
+);
+
+CREATE TRIGGER audit_log_no_update
+    BEFORE UPDATE
+    ON audit_log
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+CREATE TRIGGER audit_log_no_delete
+    BEFORE DELETE
+    ON audit_log
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+INSERT INTO permissions (name, description)
+VALUES ('audit:read', 'Query and export the audit log');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.permission_id
+FROM roles r
+         JOIN permissions p
+WHERE r.role = 'Manager'
+  AND p.name = 'audit:read';
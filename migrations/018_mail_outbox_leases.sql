@@ -0,0 +1,11 @@
+-- Mail outbox leases: a worker claims a message as 'sending' until locked_until and sends it outside any
+-- transaction. A message whose worker died is picked up again once its lease runs out.
+
+ALTER TABLE mail_outbox
+    MODIFY status ENUM ('pending', 'sending', 'sent', 'failed') NOT NULL DEFAULT 'pending',
+    ADD COLUMN locked_until DATETIME NULL AFTER next_attempt_at,
+    ADD INDEX idx_mail_outbox_lease (status, locked_until),
+    ADD INDEX idx_mail_outbox_retention (status, updated_at);
+
+-- Bodies of delivered messages are no longer kept, they may hold one-time codes.
+UPDATE mail_outbox SET html_body = '', text_body = '' WHERE status = 'sent';
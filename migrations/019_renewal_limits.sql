@@ -0,0 +1,5 @@
+-- Renewal limits: how many times a loan may be renewed (0 = never), taken from the policy of the loan
+-- like the loan period.
+
+ALTER TABLE circulation_policies
+    ADD COLUMN max_renewals INT NOT NULL DEFAULT 2 AFTER max_unpaid_fine;
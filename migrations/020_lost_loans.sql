@@ -0,0 +1,12 @@
+-- Lost loans: a copy reported lost while on loan flags its open loan, so returning the loan later
+-- closes it without putting the lost copy back on the shelf.
+
+ALTER TABLE borrowed_books
+    ADD COLUMN lost_at DATETIME NULL AFTER returned_at;
+
+-- Copies already lost while their loan is still open.
+UPDATE borrowed_books bb
+    JOIN book_copies c ON c.copy_id = bb.copy_id
+SET bb.lost_at = c.updated_at
+WHERE bb.returned_at IS NULL
+  AND c.status = 'lost';
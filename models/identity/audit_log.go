@@ -0,0 +1,43 @@
+package identity
+
+import "encoding/json"
+
+// Actions recorded in the audit log.
+const (
+	AuditActionBookUpdate        = "book.update"
+	AuditActionBookDelete        = "book.delete"
+	AuditActionFineWaive         = "fine.waive"
+	AuditActionUserCreate        = "user.create"
+	AuditActionUserChangeRole    = "user.change_role"
+	AuditActionUserSuspend       = "user.suspend"
+	AuditActionUserReactivate    = "user.reactivate"
+	AuditActionUserResetPassword = "user.reset_password"
+	AuditActionPermissionGrant   = "role.grant_permission"
+	AuditActionPermissionRevoke  = "role.revoke_permission"
+)
+
+// Entities the actions are recorded against.
+const (
+	AuditEntityBook = "book"
+	AuditEntityFine = "fine"
+	AuditEntityUser = "user"
+	AuditEntityRole = "role"
+)
+
+// AuditEntry is one recorded action. Before and After are JSON snapshots of the entity, null when it did not
+// exist before or after the action.
+type AuditEntry struct {
+	AuditID    int             `json:"audit_id"`
+	ActorID    int             `json:"actor_id"`
+	ActorRole  string          `json:"actor_role"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	Method     string          `json:"method"`
+	Path       string          `json:"path"`
+	CreatedAt  string          `json:"created_at"`
+}
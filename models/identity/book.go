@@ -11,4 +11,5 @@ type Book struct {
 	GenreID         int    `json:"genre_id" validate:"required"`
 	Genre           string `json:"genre" validate:"required"`
 	Quantity        int    `json:"quantity" validate:"required"`
+	Available       int    `json:"available"`
 }
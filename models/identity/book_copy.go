@@ -0,0 +1,31 @@
+package identity
+
+const (
+	CopyStatusAvailable = "available"
+	CopyStatusOnLoan    = "on_loan"
+	CopyStatusOnHold    = "on_hold"
+	CopyStatusLost      = "lost"
+	CopyStatusRepair    = "repair"
+)
+
+type BookCopy struct {
+	CopyID          int    `json:"copy_id"`
+	BookID          int    `json:"book_id"`
+	Barcode         string `json:"barcode"`
+	AccessionNumber string `json:"accession_number"`
+	Condition       string `json:"condition"`
+	ShelfLocation   string `json:"shelf_location"`
+	Status          string `json:"status"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+type BookCopyAvailability struct {
+	Total     int        `json:"total"`
+	Available int        `json:"available"`
+	OnLoan    int        `json:"on_loan"`
+	OnHold    int        `json:"on_hold"`
+	Lost      int        `json:"lost"`
+	Repair    int        `json:"repair"`
+	Copies    []BookCopy `json:"copies"`
+}
@@ -1,14 +1,31 @@
 package identity
 
 type Borrowed struct {
-	BorrowedID int    `json:"borrowed_id"`
-	BookID     int    `json:"book_id"`
-	UserID     int    `json:"user_id"`
-	BorrowDate string `json:"borrow_date"`
-	ReturnDate string `json:"return_date"`
+	BorrowedID   int    `json:"borrowed_id"`
+	BookID       int    `json:"book_id"`
+	CopyID       int    `json:"copy_id,omitempty"`
+	UserID       int    `json:"user_id"`
+	BorrowDate   string `json:"borrow_date"`
+	ReturnDate   string `json:"return_date"`
+	ReturnedAt   string `json:"returned_at,omitempty"`
+	RenewalCount int    `json:"renewal_count"`
+	CheckedOutBy int    `json:"checked_out_by,omitempty"`
 }
 
-type BookBorrowingData struct {
-	Borrowed        Borrowed        `json:"borrowed"`
-	DueDateResponse DueDateResponse `json:"due_date_response"`
+const (
+	LoanStatusActive   = "active"
+	LoanStatusOverdue  = "overdue"
+	LoanStatusReturned = "returned"
+)
+
+// LoanRecord is a loan joined with its borrower, the book it is for and the fine it accrued.
+type LoanRecord struct {
+	Borrowed
+	Username string          `json:"username"`
+	Title    string          `json:"title"`
+	Author   string          `json:"author"`
+	Barcode  string          `json:"barcode,omitempty"`
+	Status   string          `json:"status"`
+	Fine     DueDateResponse `json:"fine"`
+	Renewals []LoanRenewal   `json:"renewals,omitempty"`
 }
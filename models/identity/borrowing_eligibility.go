@@ -0,0 +1,22 @@
+package identity
+
+const (
+	RefusalMaxLoans     = "max_loans_reached"
+	RefusalUnpaidFines  = "unpaid_fines"
+	RefusalOverdueItems = "overdue_items"
+)
+
+// BorrowerStanding summarises what a user currently has on loan and owes.
+type BorrowerStanding struct {
+	ActiveLoans  int `json:"active_loans"`
+	OverdueLoans int `json:"overdue_loans"`
+	UnpaidFine   int `json:"unpaid_fine"`
+}
+
+// BorrowRefusal is one reason a loan was refused. Limit and Current are the values that were compared.
+type BorrowRefusal struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Limit   int    `json:"limit"`
+	Current int    `json:"current"`
+}
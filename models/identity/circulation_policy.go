@@ -0,0 +1,22 @@
+package identity
+
+// CirculationPolicy holds the lending rules applied to a loan. RoleID and GenreID are zero
+// when the policy applies to every role or every genre. Fines are kept in minor units of Currency.
+// MaxLoans and MaxUnpaidFine are borrowing limits and are taken from the policy of the borrower's role.
+type CirculationPolicy struct {
+	PolicyID       int    `json:"policy_id"`
+	RoleID         int    `json:"role_id,omitempty"`
+	Role           string `json:"role,omitempty"`
+	GenreID        int    `json:"genre_id,omitempty"`
+	Genre          string `json:"genre,omitempty"`
+	LoanPeriodDays int    `json:"loan_period_days"`
+	DailyFine      int    `json:"daily_fine"`
+	MaxFine        int    `json:"max_fine"`
+	GraceDays      int    `json:"grace_days"`
+	Currency       string `json:"currency"`
+	MaxLoans       int    `json:"max_loans"`
+	MaxUnpaidFine  int    `json:"max_unpaid_fine"`
+	MaxRenewals    int    `json:"max_renewals"`
+	CreatedAt      string `json:"created_at,omitempty"`
+	UpdatedAt      string `json:"updated_at,omitempty"`
+}
@@ -1,12 +1,46 @@
 package identity
 
+const (
+	FineStatusOutstanding   = "outstanding"
+	FineStatusPartiallyPaid = "partially_paid"
+	FineStatusSettled       = "settled"
+
+	FineTransactionPayment = "payment"
+	FineTransactionWaiver  = "waiver"
+)
+
+// DueDate is a late fine in the ledger. Amounts are in minor units of Currency.
 type DueDate struct {
-	DueDateID    int    `json:"due_date_id"`
-	BorrowID     int    `json:"borrow_id"`
-	Difference   int    `json:"difference"`
-	AmountOfFine string `json:"amount_of_fine"`
-	CreatedAt    int    `json:"created_at"`
-	UpdatedAt    int    `json:"updated_at"`
+	DueDateID            int               `json:"due_date_id"`
+	BorrowID             int               `json:"borrow_id"`
+	UserID               int               `json:"user_id"`
+	Difference           int               `json:"difference"`
+	AmountOfFine         int               `json:"amount_of_fine"`
+	AmountPaid           int               `json:"amount_paid"`
+	AmountWaived         int               `json:"amount_waived"`
+	Outstanding          int               `json:"outstanding"`
+	FormattedOutstanding string            `json:"formatted_outstanding"`
+	Currency             string            `json:"currency"`
+	Status               string            `json:"status"`
+	CreatedAt            string            `json:"created_at"`
+	UpdatedAt            string            `json:"updated_at"`
+	Transactions         []FineTransaction `json:"transactions,omitempty"`
+}
+
+type FineTransaction struct {
+	TransactionID int    `json:"transaction_id"`
+	DueDateID     int    `json:"due_date_id"`
+	Type          string `json:"type"`
+	Amount        int    `json:"amount"`
+	Reason        string `json:"reason,omitempty"`
+	RecordedBy    int    `json:"recorded_by"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type FineBalance struct {
+	Currency             string `json:"currency"`
+	Outstanding          int    `json:"outstanding"`
+	FormattedOutstanding string `json:"formatted_outstanding"`
 }
 
 type DueDateResponse struct {
@@ -0,0 +1,24 @@
+package identity
+
+const (
+	HoldStatusWaiting   = "waiting"
+	HoldStatusReady     = "ready"
+	HoldStatusFulfilled = "fulfilled"
+	HoldStatusExpired   = "expired"
+	HoldStatusCancelled = "cancelled"
+)
+
+type Hold struct {
+	HoldID         int    `json:"hold_id"`
+	BookID         int    `json:"book_id"`
+	Title          string `json:"title"`
+	UserID         int    `json:"user_id"`
+	Username       string `json:"username,omitempty"`
+	CopyID         int    `json:"copy_id,omitempty"`
+	Barcode        string `json:"barcode,omitempty"`
+	Status         string `json:"status"`
+	Position       int    `json:"position,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	ReadyAt        string `json:"ready_at,omitempty"`
+	PickupDeadline string `json:"pickup_deadline,omitempty"`
+}
@@ -0,0 +1,21 @@
+package identity
+
+const (
+	MailStatusPending = "pending"
+	MailStatusSending = "sending"
+	MailStatusSent    = "sent"
+	MailStatusFailed  = "failed"
+)
+
+// OutboxMail is a queued email and the state of its delivery.
+type OutboxMail struct {
+	MailID        int    `json:"mail_id"`
+	Recipient     string `json:"recipient"`
+	Subject       string `json:"subject"`
+	Status        string `json:"status"`
+	Attempts      int    `json:"attempts"`
+	LastError     string `json:"last_error,omitempty"`
+	NextAttemptAt string `json:"next_attempt_at,omitempty"`
+	SentAt        string `json:"sent_at,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
@@ -0,0 +1,40 @@
+package identity
+
+// Permissions checked by the routes. Which role holds which is stored in role_permissions.
+const (
+	PermissionBookRead         = "book:read"
+	PermissionBookCreate       = "book:create"
+	PermissionBookUpdate       = "book:update"
+	PermissionBookDelete       = "book:delete"
+	PermissionCopyManage       = "copy:manage"
+	PermissionLoanCheckout     = "loan:checkout"
+	PermissionLoanManage       = "loan:manage"
+	PermissionFavoriteManage   = "favorite:manage"
+	PermissionHoldPlace        = "hold:place"
+	PermissionHoldManage       = "hold:manage"
+	PermissionFineRead         = "fine:read"
+	PermissionFineCollect      = "fine:collect"
+	PermissionFineWaive        = "fine:waive"
+	PermissionPolicyRead       = "policy:read"
+	PermissionPolicyManage     = "policy:manage"
+	PermissionMailManage       = "mail:manage"
+	PermissionProfileManage    = "profile:manage"
+	PermissionSessionManage    = "session:manage"
+	PermissionSessionRevokeAny = "session:revoke-any"
+	PermissionUserUnlock       = "user:unlock"
+	PermissionUserManage       = "user:manage"
+	PermissionRoleManage       = "role:manage"
+	PermissionAuditRead        = "audit:read"
+)
+
+type Permission struct {
+	PermissionID int    `json:"permission_id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+}
+
+type Role struct {
+	RoleID      int      `json:"role_id"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,13 @@
+package identity
+
+// RefreshFamily is the chain of refresh tokens issued from one login, and doubles as the session of that login:
+// its ID is the jti of every access token issued for it. Rotating a token keeps the family alive, replaying
+// a token that was already rotated revokes the whole family.
+type RefreshFamily struct {
+	FamilyID  string `json:"family_id"`
+	UserID    int    `json:"user_id"`
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+	CreatedAt string `json:"created_at"`
+	RotatedAt string `json:"rotated_at,omitempty"`
+}
@@ -0,0 +1,10 @@
+package identity
+
+type LoanRenewal struct {
+	RenewalID          int    `json:"renewal_id"`
+	BorrowedID         int    `json:"borrowed_id"`
+	PreviousReturnDate string `json:"previous_return_date"`
+	NewReturnDate      string `json:"new_return_date"`
+	RenewedBy          int    `json:"renewed_by"`
+	CreatedAt          string `json:"created_at"`
+}
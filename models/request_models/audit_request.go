@@ -0,0 +1,15 @@
+package request_models
+
+import "go-libraryschool/helpers"
+
+// AuditFilter narrows down the audit log. From and To bound the day the action was taken (YYYY-MM-DD, both
+// inclusive), EntityID only applies together with EntityType.
+type AuditFilter struct {
+	ActorID    int
+	Action     string
+	EntityType string
+	EntityID   string
+	From       string
+	To         string
+	helpers.Pagination
+}
@@ -0,0 +1,20 @@
+package request_models
+
+type BookCopyRequest struct {
+	BookID          int    `json:"book_id"`
+	Barcode         string `json:"barcode,omitempty"`
+	AccessionNumber string `json:"accession_number,omitempty"`
+	Condition       string `json:"condition,omitempty"`
+	ShelfLocation   string `json:"shelf_location,omitempty"`
+}
+
+type BookCopyUpdate struct {
+	CopyID        int    `json:"copy_id"`
+	Condition     string `json:"condition,omitempty"`
+	ShelfLocation string `json:"shelf_location,omitempty"`
+	Status        string `json:"status,omitempty"`
+}
+
+type BookCopyByBookId struct {
+	BookID int `json:"book_id"`
+}
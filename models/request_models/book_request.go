@@ -25,14 +25,23 @@ type BookUpdate struct {
 	Cover       string `json:"cover,omitempty"`
 	GenreID     int    `json:"genre_id,omitempty"`
 	Genre       string `json:"genre,omitempty"`
-	Quantity    int    `json:"quantity,omitempty"`
 }
 
 type BookBorrowedRequest struct {
 	BookID       int    `json:"book_id"`
-	UserID       int    `json:"user_id"`
-	BorrowedDate string `json:"borrowed_date"`
-	ReturnDate   string `json:"return_date"`
+	Barcode      string `json:"barcode,omitempty"`
+	UserID       int    `json:"user_id,omitempty"`
+	BorrowedDate string `json:"borrowed_date,omitempty"`
+	ReturnDate   string `json:"return_date,omitempty"`
+	CheckedOutBy int    `json:"checked_out_by,omitempty" swaggerignore:"true"`
+}
+
+type BookReturnRequest struct {
+	BorrowedID int `json:"borrowed_id"`
+}
+
+type BookRenewRequest struct {
+	BorrowedID int `json:"borrowed_id"`
 }
 
 type HistoryRecordingBorrowed struct {
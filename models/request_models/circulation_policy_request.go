@@ -0,0 +1,19 @@
+package request_models
+
+type CirculationPolicyRequest struct {
+	PolicyID       int    `json:"policy_id,omitempty"`
+	RoleID         int    `json:"role_id,omitempty"`
+	GenreID        int    `json:"genre_id,omitempty"`
+	LoanPeriodDays int    `json:"loan_period_days"`
+	DailyFine      int    `json:"daily_fine"`
+	MaxFine        int    `json:"max_fine"`
+	GraceDays      int    `json:"grace_days"`
+	Currency       string `json:"currency"`
+	MaxLoans       int    `json:"max_loans"`
+	MaxUnpaidFine  int    `json:"max_unpaid_fine"`
+	MaxRenewals    int    `json:"max_renewals"`
+}
+
+type CirculationPolicyById struct {
+	PolicyID int `json:"policy_id"`
+}
@@ -0,0 +1,17 @@
+package request_models
+
+type FinePaymentRequest struct {
+	DueDateID int `json:"due_date_id"`
+	Amount    int `json:"amount"`
+}
+
+type FineWaiverRequest struct {
+	DueDateID int    `json:"due_date_id"`
+	Amount    int    `json:"amount,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+type FineFilter struct {
+	UserID int    `json:"user_id,omitempty"`
+	Status string `json:"status,omitempty"`
+}
@@ -0,0 +1,9 @@
+package request_models
+
+type HoldRequest struct {
+	BookID int `json:"book_id"`
+}
+
+type HoldById struct {
+	HoldID int `json:"hold_id"`
+}
@@ -0,0 +1,17 @@
+package request_models
+
+import "go-libraryschool/helpers"
+
+// LoanFilter narrows down a list of loans. Status is one of active, overdue, returned or current
+// (active and overdue), From and To bound the borrow date, Sort is borrow_date or return_date and
+// Order is asc or desc.
+type LoanFilter struct {
+	UserID int
+	BookID int
+	Status string
+	From   string
+	To     string
+	Sort   string
+	Order  string
+	helpers.Pagination
+}
@@ -0,0 +1,5 @@
+package request_models
+
+type MailById struct {
+	MailID int `json:"mail_id"`
+}
@@ -1,9 +1,23 @@
 package request_models
 
+// Purposes an OTP can be requested for. An OTP only verifies for the purpose it was sent for.
+const (
+	OtpPurposeRegister      = "register"
+	OtpPurposeResetPassword = "reset_password"
+	OtpPurposeVerifyEmail   = "verify_email"
+)
+
 type RequestOtpEmail struct {
-	Email string `json:"email" binding:"required,email"`
+	Email    string `json:"email" binding:"required,email"`
+	Purpose  string `json:"purpose,omitempty" example:"register" enums:"register,reset_password,verify_email"`
+	Language string `json:"language,omitempty" example:"id"`
 }
 type VerificationOtpEmail struct {
+	Email   string `json:"email" binding:"required,email"`
+	Otp     string `json:"otp" binding:"required"`
+	Purpose string `json:"purpose,omitempty" example:"register" enums:"register,reset_password,verify_email"`
+}
+
+type ResendVerificationRequest struct {
 	Email string `json:"email" binding:"required,email"`
-	Otp   string `json:"otp" binding:"required"`
 }
@@ -0,0 +1,5 @@
+package request_models
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
@@ -0,0 +1,6 @@
+package request_models
+
+type ResetPasswordRequest struct {
+	ResetToken string `json:"reset_token" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+}
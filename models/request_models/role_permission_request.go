@@ -0,0 +1,6 @@
+package request_models
+
+type RolePermissionRequest struct {
+	RoleID     int    `json:"role_id"`
+	Permission string `json:"permission"`
+}
@@ -0,0 +1,9 @@
+package request_models
+
+type RevokeSessionRequest struct {
+	SessionID string `json:"session_id"`
+}
+
+type ForceLogoutRequest struct {
+	UserID int `json:"user_id"`
+}
@@ -0,0 +1,5 @@
+package request_models
+
+type UnlockAccountRequest struct {
+	Email string `json:"email"`
+}
@@ -3,4 +3,5 @@ package request_models
 type ProfileUpdate struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
+	Language string `json:"language" example:"id"`
 }
@@ -0,0 +1,28 @@
+package request_models
+
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	RoleID   int    `json:"role_id"`
+	Language string `json:"language,omitempty" example:"id"`
+}
+
+type ChangeRoleRequest struct {
+	UserID int `json:"user_id"`
+	RoleID int `json:"role_id"`
+}
+
+type SuspendUserRequest struct {
+	UserID int    `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+type ReactivateUserRequest struct {
+	UserID int `json:"user_id"`
+}
+
+type ResetUserPasswordRequest struct {
+	UserID   int    `json:"user_id"`
+	Password string `json:"password"`
+}
@@ -5,4 +5,5 @@ type ProfileResponse struct {
 	Email    string `json:"email"`
 	RoleID   int    `json:"role_id"`
 	Role     string `json:"role"`
+	Language string `json:"language"`
 }
@@ -0,0 +1,10 @@
+package response_models
+
+type SessionResponse struct {
+	SessionID  string `json:"session_id"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	IssuedAt   string `json:"issued_at"`
+	LastUsedAt string `json:"last_used_at,omitempty"`
+	Current    bool   `json:"current"`
+}
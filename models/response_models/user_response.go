@@ -0,0 +1,15 @@
+package response_models
+
+type UserResponse struct {
+	ID              int    `json:"id"`
+	Username        string `json:"username"`
+	Email           string `json:"email"`
+	RoleID          int    `json:"role_id"`
+	Role            string `json:"role"`
+	Language        string `json:"language"`
+	EmailVerified   bool   `json:"email_verified"`
+	Suspended       bool   `json:"suspended"`
+	SuspendedAt     string `json:"suspended_at,omitempty"`
+	SuspendedReason string `json:"suspended_reason,omitempty"`
+	CreatedAt       string `json:"created"`
+}
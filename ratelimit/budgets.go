@@ -0,0 +1,32 @@
+package ratelimit
+
+import "time"
+
+// DefaultBudget applies to rate limited routes without a budget of their own.
+var DefaultBudget = Limit{Requests: 120, Window: time.Minute}
+
+// budgets are the limits of the routes that are expensive or abused, per user when the route needs a login
+// and per client IP otherwise. Login has limits of its own, see login_attempt_repository.
+var budgets = map[string]Limit{
+	"/register":                {Requests: 10, Window: time.Hour},
+	"/reset-password":          {Requests: 10, Window: 15 * time.Minute},
+	"/token/refresh":           {Requests: 30, Window: time.Minute},
+	"/otp/send-otp":            {Requests: 5, Window: time.Minute},
+	"/otp/verify-otp":          {Requests: 10, Window: time.Minute},
+	"/otp/resend-verification": {Requests: 5, Window: time.Minute},
+	"/book/search-book":        {Requests: 60, Window: time.Minute},
+	"/book/add-book":           {Requests: 20, Window: time.Minute},
+	"/book/update-book":        {Requests: 20, Window: time.Minute},
+	"/profile/photo-profile":   {Requests: 5, Window: time.Minute},
+	"/session/force-logout":    {Requests: 20, Window: time.Minute},
+	"/mail/retry-mail":         {Requests: 20, Window: time.Minute},
+	"/audit/export-logs":       {Requests: 5, Window: time.Minute},
+}
+
+// Budget returns the limit of a route.
+func Budget(route string) Limit {
+	if limit, ok := budgets[route]; ok {
+		return limit
+	}
+	return DefaultBudget
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many hits pass between two sweeps of keys that went quiet.
+const sweepEvery = 1000
+
+type memoryWindow struct {
+	hits   []time.Time
+	window time.Duration
+}
+
+// MemoryLimiter keeps the sliding windows in the process. Limits are per instance, which is fine for
+// single-instance deployments and as a stand-in while Redis is unreachable.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*memoryWindow
+	calls   int
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{windows: map[string]*memoryWindow{}}
+}
+
+func (limiter *MemoryLimiter) Allow(ctx context.Context, key string, limit Limit) (time.Duration, error) {
+	now := time.Now()
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	limiter.calls++
+	if limiter.calls%sweepEvery == 0 {
+		limiter.sweep(now)
+	}
+
+	entry, ok := limiter.windows[key]
+	if !ok {
+		entry = &memoryWindow{}
+		limiter.windows[key] = entry
+	}
+	entry.window = limit.Window
+	entry.prune(now)
+
+	if len(entry.hits) < limit.Requests {
+		entry.hits = append(entry.hits, now)
+		return 0, nil
+	}
+	if limit.Requests <= 0 {
+		return limit.Window, nil
+	}
+
+	// Hits are in order, the hit fits again once enough of the oldest left the window.
+	wait := entry.hits[len(entry.hits)-limit.Requests].Add(limit.Window).Sub(now)
+	if wait <= 0 {
+		wait = time.Millisecond
+	}
+	return wait, nil
+}
+
+func (entry *memoryWindow) prune(now time.Time) {
+	cutoff := now.Add(-entry.window)
+
+	i := 0
+	for i < len(entry.hits) && !entry.hits[i].After(cutoff) {
+		i++
+	}
+	entry.hits = entry.hits[i:]
+}
+
+func (limiter *MemoryLimiter) sweep(now time.Time) {
+	for key, entry := range limiter.windows {
+		entry.prune(now)
+		if len(entry.hits) == 0 {
+			delete(limiter.windows, key)
+		}
+	}
+}
+
+// FallbackLimiter asks the primary limiter and falls back to the second one while the primary fails,
+// so an outage of Redis degrades limits to per instance instead of turning them off.
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+}
+
+func NewFallbackLimiter(primary, fallback Limiter) *FallbackLimiter {
+	return &FallbackLimiter{primary: primary, fallback: fallback}
+}
+
+func (limiter *FallbackLimiter) Allow(ctx context.Context, key string, limit Limit) (time.Duration, error) {
+	wait, err := limiter.primary.Allow(ctx, key, limit)
+	if err != nil {
+		return limiter.fallback.Allow(ctx, key, limit)
+	}
+	return wait, nil
+}
+
+// NewLimiter shares limits through Redis when a client is configured and keeps them in memory otherwise.
+func NewLimiter(rdb *redis.Client) Limiter {
+	if rdb == nil {
+		return NewMemoryLimiter()
+	}
+	return NewFallbackLimiter(NewRedisLimiter(rdb), NewMemoryLimiter())
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// Limit allows Requests hits per sliding Window.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Limiter counts hits per key. Allow records a hit and returns zero when it fits the limit, otherwise how long
+// to wait until it would. Refused hits are not recorded, so a client that keeps retrying is not locked out longer.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (time.Duration, error)
+}
+
+// RedisLimiter keeps a sorted set of hit timestamps per key, so the limit is shared by every instance.
+type RedisLimiter struct {
+	rdb *redis.Client
+}
+
+func NewRedisLimiter(rdb *redis.Client) *RedisLimiter {
+	return &RedisLimiter{rdb: rdb}
+}
+
+func redisKey(key string) string {
+	return fmt.Sprintf("rate-limit:%s", key)
+}
+
+func (limiter *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (time.Duration, error) {
+	now := time.Now()
+	setKey := redisKey(key)
+
+	buf := make([]byte, 8)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return 0, err
+	}
+	member := fmt.Sprintf("%d-%s", now.UnixNano(), hex.EncodeToString(buf))
+
+	var count *redis.IntCmd
+	_, err = limiter.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, setKey, "-inf", fmt.Sprintf("%d", now.Add(-limit.Window).UnixNano()))
+		pipe.ZAdd(ctx, setKey, redis.Z{Score: float64(now.UnixNano()), Member: member})
+		count = pipe.ZCard(ctx, setKey)
+		pipe.PExpire(ctx, setKey, limit.Window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if count.Val() <= int64(limit.Requests) {
+		return 0, nil
+	}
+
+	err = limiter.rdb.ZRem(ctx, setKey, member).Err()
+	if err != nil {
+		return 0, err
+	}
+
+	// The hit fits again once enough of the oldest hits left the window.
+	oldest, err := limiter.rdb.ZRangeWithScores(ctx, setKey, count.Val()-int64(limit.Requests)-1, count.Val()-int64(limit.Requests)-1).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(oldest) == 0 {
+		return limit.Window, nil
+	}
+
+	wait := time.Unix(0, int64(oldest[0].Score)).Add(limit.Window).Sub(now)
+	if wait <= 0 {
+		wait = time.Millisecond
+	}
+	return wait, nil
+}
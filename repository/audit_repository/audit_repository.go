@@ -0,0 +1,276 @@
+package audit_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/models/request_models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	layoutDate     = "2006-01-02"
+	layoutDateTime = "2006-01-02 15:04:05"
+
+	// ExportLimit caps the entries of one CSV export, narrower filters are needed beyond it.
+	ExportLimit = 50000
+)
+
+type Execer interface {
+	ExecContext(ctx context2.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Event is a privileged action about to be recorded. Before and After are snapshots of the entity, left nil when
+// it did not exist before or does not exist after the action. Snapshots must not hold secrets such as password hashes.
+type Event struct {
+	Action     string
+	EntityType string
+	EntityID   any
+	Before     any
+	After      any
+}
+
+func snapshot(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
+
+// Record appends an event to the audit log. Pass the transaction of the action, so the entry is kept exactly when
+// the action is. The actor is the logged in user of r, the request metadata comes from r as well.
+func Record(ctx context2.Context, q Execer, r *http.Request, event Event) error {
+	var (
+		actorID   sql.NullInt64
+		actorRole string
+	)
+	if claims, ok := r.Context().Value(middlewares.UserContextKey).(*jwt_models.JWTClaims); ok {
+		actorID = sql.NullInt64{Int64: int64(claims.UserID), Valid: true}
+		actorRole = claims.Roles
+	}
+
+	before, err := snapshot(event.Before)
+	if err != nil {
+		return err
+	}
+	after, err := snapshot(event.After)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO audit_log (actor_id, actor_role, action, entity_type, entity_id, before_data, after_data, ip, user_agent, method, path, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = q.ExecContext(ctx, query, actorID, actorRole, event.Action, event.EntityType, fmt.Sprint(event.EntityID), before, after,
+		helpers.ClientIP(r), truncate(r.UserAgent(), 255), r.Method, truncate(r.URL.Path, 255), time.Now().Format(layoutDateTime))
+	return err
+}
+
+type AuditRepository struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+}
+
+func NewAuditRepository(db *sql.DB, logLogrus *logrus.Logger) *AuditRepository {
+	return &AuditRepository{db: db, logLogrus: logLogrus}
+}
+
+// validateAuditFilter returns a message describing the first invalid filter value, or an empty string.
+func validateAuditFilter(filter request_models.AuditFilter) string {
+	for _, date := range []string{filter.From, filter.To} {
+		if _, err := time.Parse(layoutDate, date); date != "" && err != nil {
+			return "Dates must be formatted as YYYY-MM-DD"
+		}
+	}
+
+	if filter.From != "" && filter.To != "" && filter.From > filter.To {
+		return "From must not be after to"
+	}
+
+	if filter.EntityID != "" && filter.EntityType == "" {
+		return "entity_id needs entity_type"
+	}
+
+	return ""
+}
+
+// auditConditions builds the WHERE clause shared by the listing, its count and the export.
+func auditConditions(filter request_models.AuditFilter) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if filter.ActorID > 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+
+	if filter.EntityType != "" {
+		conditions = append(conditions, "entity_type = ?")
+		args = append(args, filter.EntityType)
+	}
+
+	if filter.EntityID != "" {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, filter.EntityID)
+	}
+
+	if filter.From != "" {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From)
+	}
+
+	if filter.To != "" {
+		conditions = append(conditions, "created_at < DATE_ADD(?, INTERVAL 1 DAY)")
+		args = append(args, filter.To)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// entries runs the query of the audit log, newest first.
+func (repository *AuditRepository) entries(ctx context2.Context, where string, args []any, limit, offset int) ([]identity.AuditEntry, error) {
+	query := `SELECT audit_id, IFNULL(actor_id, 0), actor_role, action, entity_type, entity_id, before_data, after_data,
+			  ip, user_agent, method, path, created_at
+			  FROM audit_log` + where + " ORDER BY audit_id DESC LIMIT ? OFFSET ?"
+	rows, err := repository.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []identity.AuditEntry{}
+	for rows.Next() {
+		var (
+			entry         identity.AuditEntry
+			before, after sql.NullString
+		)
+		err = rows.Scan(&entry.AuditID, &entry.ActorID, &entry.ActorRole, &entry.Action, &entry.EntityType, &entry.EntityID,
+			&before, &after, &entry.IP, &entry.UserAgent, &entry.Method, &entry.Path, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if before.Valid {
+			entry.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			entry.After = json.RawMessage(after.String)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetAuditLogRepository lists one page of audit entries, newest first.
+func (repository *AuditRepository) GetAuditLogRepository(r *http.Request, filter request_models.AuditFilter) (helpers.ApiResponse, int, error) {
+	if message := validateAuditFilter(filter); message != "" {
+		err := fmt.Errorf("invalid audit filter: %s", message)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Invalid audit filter")
+
+		return helpers.ApiResponse{Message: message, Data: nil}, http.StatusBadRequest, err
+	}
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	where, args := auditConditions(filter)
+
+	var total int
+	err := repository.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&total)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	entries, err := repository.entries(ctx, where, args, filter.PerPage, filter.Offset())
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to get audit log")
+
+		return helpers.ApiResponse{Message: "Failed to get audit log", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	return helpers.ApiResponse{Message: "Success", Data: helpers.NewPaginatedData(entries, filter.Pagination, total)}, http.StatusOK, nil
+}
+
+// ExportAuditLogRepository loads every entry matching the filter for a CSV export, refusing more than ExportLimit.
+func (repository *AuditRepository) ExportAuditLogRepository(r *http.Request, filter request_models.AuditFilter) ([]identity.AuditEntry, helpers.ApiResponse, int, error) {
+	if message := validateAuditFilter(filter); message != "" {
+		err := fmt.Errorf("invalid audit filter: %s", message)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Invalid audit filter")
+
+		return nil, helpers.ApiResponse{Message: message, Data: nil}, http.StatusBadRequest, err
+	}
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 30*time.Second)
+	defer cancel()
+
+	where, args := auditConditions(filter)
+
+	entries, err := repository.entries(ctx, where, args, ExportLimit+1, 0)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to export audit log")
+
+		return nil, helpers.ApiResponse{Message: "Failed to export audit log", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if len(entries) > ExportLimit {
+		err = fmt.Errorf("more than %d audit entries", ExportLimit)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Audit export too large")
+
+		message := fmt.Sprintf("More than %d entries match, please narrow down the filters", ExportLimit)
+		return nil, helpers.ApiResponse{Message: message, Data: nil}, http.StatusBadRequest, err
+	}
+
+	return entries, helpers.ApiResponse{Message: "Success"}, http.StatusOK, nil
+}
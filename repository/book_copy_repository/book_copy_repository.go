@@ -0,0 +1,398 @@
+package book_copy_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/request_models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const layoutDateTime = "2006-01-02 15:04:05"
+
+type BookCopyRepository struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+	rdb       *redis.Client
+}
+
+func NewBookCopyRepository(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *BookCopyRepository {
+	return &BookCopyRepository{db: db, logLogrus: logLogrus, rdb: rdb}
+}
+
+// GenerateBarcode builds the default barcode for the n-th copy of a book, e.g. BK000012-003. The dash keeps
+// barcodes unique once the book ID outgrows six digits or the copy number three.
+func GenerateBarcode(bookID, number int) string {
+	return fmt.Sprintf("BK%06d-%03d", bookID, number)
+}
+
+// GenerateAccessionNumber builds the default accession number for the n-th copy of a book.
+func GenerateAccessionNumber(bookID, number int) string {
+	return fmt.Sprintf("ACC-%d-%d", bookID, number)
+}
+
+// SyncBookStock recomputes books.quantity and books.available from the copies of a book.
+// Lost copies no longer count towards the total.
+func SyncBookStock(ctx context2.Context, tx *sql.Tx, bookID int) error {
+	query := `UPDATE books
+			  SET quantity  = (SELECT COUNT(*) FROM book_copies WHERE book_id = ? AND status <> ?),
+			      available = (SELECT COUNT(*) FROM book_copies WHERE book_id = ? AND status = ?)
+			  WHERE book_id = ?`
+	_, err := tx.ExecContext(ctx, query, bookID, identity.CopyStatusLost, bookID, identity.CopyStatusAvailable, bookID)
+	return err
+}
+
+// InsertGeneratedCopies registers count new available copies of a book with generated barcodes.
+func InsertGeneratedCopies(ctx context2.Context, tx *sql.Tx, bookID, count int) error {
+	var existing int
+	err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM book_copies WHERE book_id = ?", bookID).Scan(&existing)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Format(layoutDateTime)
+	query := `INSERT INTO book_copies (book_id, barcode, accession_number, ` + "`condition`" + `, shelf_location, status, created_at, updated_at)
+			  VALUES (?, ?, ?, 'good', '', ?, ?, ?)`
+	for i := 1; i <= count; i++ {
+		number := existing + i
+		_, err = tx.ExecContext(ctx, query, bookID, GenerateBarcode(bookID, number), GenerateAccessionNumber(bookID, number), identity.CopyStatusAvailable, now, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CopyAvailability lists the copies of a book together with a count per status.
+func CopyAvailability(ctx context2.Context, db *sql.DB, bookID int) (identity.BookCopyAvailability, error) {
+	var availability identity.BookCopyAvailability
+
+	query := "SELECT copy_id, book_id, barcode, accession_number, `condition`, shelf_location, status, created_at, updated_at FROM book_copies WHERE book_id = ? ORDER BY copy_id"
+	rows, err := db.QueryContext(ctx, query, bookID)
+	if err != nil {
+		return availability, err
+	}
+	defer rows.Close()
+
+	availability.Copies = []identity.BookCopy{}
+	for rows.Next() {
+		var copyBook identity.BookCopy
+		err = rows.Scan(&copyBook.CopyID, &copyBook.BookID, &copyBook.Barcode, &copyBook.AccessionNumber, &copyBook.Condition, &copyBook.ShelfLocation, &copyBook.Status, &copyBook.CreatedAt, &copyBook.UpdatedAt)
+		if err != nil {
+			return availability, err
+		}
+
+		switch copyBook.Status {
+		case identity.CopyStatusAvailable:
+			availability.Available++
+		case identity.CopyStatusOnLoan:
+			availability.OnLoan++
+		case identity.CopyStatusOnHold:
+			availability.OnHold++
+		case identity.CopyStatusLost:
+			availability.Lost++
+		case identity.CopyStatusRepair:
+			availability.Repair++
+		}
+
+		availability.Copies = append(availability.Copies, copyBook)
+	}
+
+	availability.Total = len(availability.Copies) - availability.Lost
+
+	return availability, rows.Err()
+}
+
+func (repository *BookCopyRepository) AddCopyRepository(r *http.Request, request request_models.BookCopyRequest) (helpers.ApiResponse, int, error) {
+	var copiesCount int
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to begin transaction")
+
+		return helpers.ApiResponse{Message: "Failed to begin transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	var bookID int
+	err = tx.QueryRowContext(ctx, "SELECT book_id FROM books WHERE book_id = ? FOR UPDATE", request.BookID).Scan(&bookID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("Book does not exist")
+
+			return helpers.ApiResponse{Message: "Book does not exist", Data: nil}, http.StatusNotFound, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM book_copies WHERE book_id = ?", request.BookID).Scan(&copiesCount)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to count copies")
+
+		return helpers.ApiResponse{Message: "Failed to count copies", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	copyBook := identity.BookCopy{
+		BookID:          request.BookID,
+		Barcode:         strings.TrimSpace(request.Barcode),
+		AccessionNumber: strings.TrimSpace(request.AccessionNumber),
+		Condition:       strings.TrimSpace(request.Condition),
+		ShelfLocation:   strings.TrimSpace(request.ShelfLocation),
+		Status:          identity.CopyStatusAvailable,
+		CreatedAt:       time.Now().Format(layoutDateTime),
+	}
+	copyBook.UpdatedAt = copyBook.CreatedAt
+
+	if copyBook.Barcode == "" {
+		copyBook.Barcode = GenerateBarcode(request.BookID, copiesCount+1)
+	}
+	if copyBook.AccessionNumber == "" {
+		copyBook.AccessionNumber = GenerateAccessionNumber(request.BookID, copiesCount+1)
+	}
+	if copyBook.Condition == "" {
+		copyBook.Condition = "good"
+	}
+
+	query := "INSERT INTO book_copies (book_id, barcode, accession_number, `condition`, shelf_location, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := tx.ExecContext(ctx, query, copyBook.BookID, copyBook.Barcode, copyBook.AccessionNumber, copyBook.Condition, copyBook.ShelfLocation, copyBook.Status, copyBook.CreatedAt, copyBook.UpdatedAt)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("Barcode or accession number already exists")
+
+			return helpers.ApiResponse{Message: "Barcode or accession number already exists", Data: nil}, http.StatusConflict, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to add copy")
+
+		return helpers.ApiResponse{Message: "Failed to add copy", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	copyID, err := result.LastInsertId()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to get last insert id")
+
+		return helpers.ApiResponse{Message: "Failed to get last insert id", Data: nil}, http.StatusInternalServerError, err
+	}
+	copyBook.CopyID = int(copyID)
+
+	err = SyncBookStock(ctx, tx, request.BookID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to update book stock")
+
+		return helpers.ApiResponse{Message: "Failed to update book stock", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to commit transaction")
+
+		return helpers.ApiResponse{Message: "Failed to commit transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	repository.invalidateBooksCache(ctx)
+
+	return helpers.ApiResponse{Message: "Successfully added copy", Data: copyBook}, http.StatusCreated, nil
+}
+
+func (repository *BookCopyRepository) GetCopiesRepository(bookID int) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	availability, err := CopyAvailability(ctx, repository.db, bookID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to get copies",
+		}).Error("Failed to get copies")
+
+		return helpers.ApiResponse{Message: "Failed to get copies", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if len(availability.Copies) == 0 {
+		return helpers.ApiResponse{Message: "No copies found", Data: nil}, http.StatusNotFound, nil
+	}
+
+	return helpers.ApiResponse{Message: "Success", Data: availability}, http.StatusOK, nil
+}
+
+func (repository *BookCopyRepository) UpdateCopyRepository(r *http.Request, request request_models.BookCopyUpdate) (helpers.ApiResponse, int, error) {
+	var copyBook identity.BookCopy
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	if request.Status != "" && request.Status != identity.CopyStatusAvailable && request.Status != identity.CopyStatusLost && request.Status != identity.CopyStatusRepair {
+		err := fmt.Errorf("invalid copy status %q", request.Status)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Invalid copy status")
+
+		return helpers.ApiResponse{Message: "Status must be one of available, lost or repair", Data: nil}, http.StatusBadRequest, err
+	}
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to begin transaction")
+
+		return helpers.ApiResponse{Message: "Failed to begin transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT copy_id, book_id, barcode, accession_number, `condition`, shelf_location, status, created_at FROM book_copies WHERE copy_id = ? FOR UPDATE"
+	err = tx.QueryRowContext(ctx, query, request.CopyID).Scan(&copyBook.CopyID, &copyBook.BookID, &copyBook.Barcode, &copyBook.AccessionNumber, &copyBook.Condition, &copyBook.ShelfLocation, &copyBook.Status, &copyBook.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("Copy does not exist")
+
+			return helpers.ApiResponse{Message: "Copy does not exist", Data: nil}, http.StatusNotFound, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	var openLoans int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM borrowed_books WHERE copy_id = ? AND returned_at IS NULL", copyBook.CopyID).Scan(&openLoans)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	// A copy on loan only leaves that state through a return, unless it is reported lost. A lost copy stays
+	// lost until its loan is returned. A copy waiting on the hold shelf belongs to the hold queue until it is
+	// picked up or the hold ends.
+	if request.Status != "" && ((openLoans > 0 && request.Status != identity.CopyStatusLost) || copyBook.Status == identity.CopyStatusOnHold) {
+		err = fmt.Errorf("copy is %s", copyBook.Status)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Copy is on loan")
+
+		return helpers.ApiResponse{Message: "Copy is on loan or on hold, return it or end the hold before changing its status", Data: nil}, http.StatusConflict, err
+	}
+
+	if request.Status != "" {
+		copyBook.Status = request.Status
+	}
+	if strings.TrimSpace(request.Condition) != "" {
+		copyBook.Condition = strings.TrimSpace(request.Condition)
+	}
+	if strings.TrimSpace(request.ShelfLocation) != "" {
+		copyBook.ShelfLocation = strings.TrimSpace(request.ShelfLocation)
+	}
+	copyBook.UpdatedAt = time.Now().Format(layoutDateTime)
+
+	queryUpdate := "UPDATE book_copies SET `condition` = ?, shelf_location = ?, status = ?, updated_at = ? WHERE copy_id = ?"
+	_, err = tx.ExecContext(ctx, queryUpdate, copyBook.Condition, copyBook.ShelfLocation, copyBook.Status, copyBook.UpdatedAt, copyBook.CopyID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to update copy")
+
+		return helpers.ApiResponse{Message: "Failed to update copy", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	// The loan stays open so the borrower still owes the book, the return keeps the copy lost.
+	if openLoans > 0 && copyBook.Status == identity.CopyStatusLost {
+		queryLost := "UPDATE borrowed_books SET lost_at = IFNULL(lost_at, ?) WHERE copy_id = ? AND returned_at IS NULL"
+		_, err = tx.ExecContext(ctx, queryLost, copyBook.UpdatedAt, copyBook.CopyID)
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("Failed to flag loan as lost")
+
+			return helpers.ApiResponse{Message: "Failed to flag loan as lost", Data: nil}, http.StatusInternalServerError, err
+		}
+	}
+
+	err = SyncBookStock(ctx, tx, copyBook.BookID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to update book stock")
+
+		return helpers.ApiResponse{Message: "Failed to update book stock", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to commit transaction")
+
+		return helpers.ApiResponse{Message: "Failed to commit transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	repository.invalidateBooksCache(ctx)
+
+	return helpers.ApiResponse{Message: "Successfully updated copy", Data: copyBook}, http.StatusOK, nil
+}
+
+func (repository *BookCopyRepository) invalidateBooksCache(ctx context2.Context) {
+	err := repository.rdb.Del(ctx, "Books").Err()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{"error": err}).Warn("Failed to invalidate books cache")
+	}
+}
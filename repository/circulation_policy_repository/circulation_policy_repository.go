@@ -0,0 +1,305 @@
+package circulation_policy_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/request_models"
+	"net/http"
+	"time"
+)
+
+const layoutDateTime = "2006-01-02 15:04:05"
+
+// Queryer is satisfied by both *sql.DB and *sql.Tx.
+type Queryer interface {
+	QueryContext(ctx context2.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context2.Context, query string, args ...any) *sql.Row
+}
+
+type CirculationPolicyRepository struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+}
+
+func NewCirculationPolicyRepository(db *sql.DB, logLogrus *logrus.Logger) *CirculationPolicyRepository {
+	return &CirculationPolicyRepository{db: db, logLogrus: logLogrus}
+}
+
+// LoadPolicies returns every configured circulation policy.
+func LoadPolicies(ctx context2.Context, q Queryer) ([]identity.CirculationPolicy, error) {
+	query := `SELECT p.policy_id, IFNULL(p.role_id, 0), IFNULL(r.role, ''), IFNULL(p.genre_id, 0), IFNULL(g.genre_name, ''),
+			         p.loan_period_days, p.daily_fine, p.max_fine, p.grace_days, p.currency, p.max_loans, p.max_unpaid_fine, p.max_renewals, p.created_at, p.updated_at
+			  FROM circulation_policies p
+			  LEFT JOIN roles r ON r.id = p.role_id
+			  LEFT JOIN genres g ON g.genre_id = p.genre_id
+			  ORDER BY p.policy_id`
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []identity.CirculationPolicy{}
+	for rows.Next() {
+		var policy identity.CirculationPolicy
+		err = rows.Scan(&policy.PolicyID, &policy.RoleID, &policy.Role, &policy.GenreID, &policy.Genre,
+			&policy.LoanPeriodDays, &policy.DailyFine, &policy.MaxFine, &policy.GraceDays, &policy.Currency, &policy.MaxLoans, &policy.MaxUnpaidFine, &policy.MaxRenewals, &policy.CreatedAt, &policy.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies, rows.Err()
+}
+
+// PolicyFor resolves the policy that governs a loan of a book by a user.
+func PolicyFor(ctx context2.Context, q Queryer, userID, bookID int) (identity.CirculationPolicy, error) {
+	var roleID, genreID int
+
+	query := "SELECT u.roleID, b.genre_id FROM users u, books b WHERE u.id = ? AND b.book_id = ?"
+	err := q.QueryRowContext(ctx, query, userID, bookID).Scan(&roleID, &genreID)
+	if err != nil {
+		return identity.CirculationPolicy{}, err
+	}
+
+	policies, err := LoadPolicies(ctx, q)
+	if err != nil {
+		return identity.CirculationPolicy{}, err
+	}
+
+	return helpers.ResolveCirculationPolicy(policies, roleID, genreID), nil
+}
+
+// RolePolicyFor resolves the policy of a user's role, ignoring genre specific policies.
+// It is used for limits that apply to the borrower rather than to a single loan.
+func RolePolicyFor(ctx context2.Context, q Queryer, userID int) (identity.CirculationPolicy, error) {
+	var roleID int
+
+	err := q.QueryRowContext(ctx, "SELECT roleID FROM users WHERE id = ?", userID).Scan(&roleID)
+	if err != nil {
+		return identity.CirculationPolicy{}, err
+	}
+
+	policies, err := LoadPolicies(ctx, q)
+	if err != nil {
+		return identity.CirculationPolicy{}, err
+	}
+
+	return helpers.ResolveCirculationPolicy(policies, roleID, 0), nil
+}
+
+// validatePolicy returns a message describing the first invalid field, or an empty string.
+func validatePolicy(request request_models.CirculationPolicyRequest) string {
+	switch {
+	case request.RoleID < 0 || request.GenreID < 0:
+		return "Role ID and genre ID must not be negative"
+	case request.LoanPeriodDays <= 0:
+		return "Loan period must be at least one day"
+	case request.DailyFine < 0 || request.MaxFine < 0:
+		return "Fines must not be negative"
+	case request.GraceDays < 0:
+		return "Grace days must not be negative"
+	case !helpers.IsSupportedCurrency(request.Currency):
+		return "Currency is not supported"
+	case request.MaxLoans < 0 || request.MaxUnpaidFine < 0:
+		return "Borrowing limits must not be negative"
+	case request.MaxRenewals < 0:
+		return "Renewal limit must not be negative"
+	}
+
+	return ""
+}
+
+func nullableID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+func (repository *CirculationPolicyRepository) GetPoliciesRepository() (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	policies, err := LoadPolicies(ctx, repository.db)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to execute query",
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	data := struct {
+		Default  identity.CirculationPolicy   `json:"default"`
+		Policies []identity.CirculationPolicy `json:"policies"`
+	}{
+		Default:  helpers.DefaultCirculationPolicy,
+		Policies: policies,
+	}
+
+	return helpers.ApiResponse{Message: "Success", Data: data}, http.StatusOK, nil
+}
+
+func (repository *CirculationPolicyRepository) AddPolicyRepository(r *http.Request, request request_models.CirculationPolicyRequest) (helpers.ApiResponse, int, error) {
+	if message := validatePolicy(request); message != "" {
+		err := errors.New(message)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Invalid circulation policy")
+
+		return helpers.ApiResponse{Message: message, Data: nil}, http.StatusBadRequest, err
+	}
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	var exists bool
+	queryExists := "SELECT EXISTS(SELECT 1 FROM circulation_policies WHERE role_id <=> ? AND genre_id <=> ?)"
+	err := repository.db.QueryRowContext(ctx, queryExists, nullableID(request.RoleID), nullableID(request.GenreID)).Scan(&exists)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if exists {
+		err = errors.New("policy already exists")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Policy already exists")
+
+		return helpers.ApiResponse{Message: "A policy for this role and genre already exists", Data: nil}, http.StatusConflict, err
+	}
+
+	now := time.Now().Format(layoutDateTime)
+	query := `INSERT INTO circulation_policies (role_id, genre_id, loan_period_days, daily_fine, max_fine, grace_days, currency, max_loans, max_unpaid_fine, max_renewals, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := repository.db.ExecContext(ctx, query, nullableID(request.RoleID), nullableID(request.GenreID),
+		request.LoanPeriodDays, request.DailyFine, request.MaxFine, request.GraceDays, request.Currency, request.MaxLoans, request.MaxUnpaidFine, request.MaxRenewals, now, now)
+	if err != nil {
+		return repository.execError(r, err)
+	}
+
+	policyID, err := result.LastInsertId()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to get last insert id")
+
+		return helpers.ApiResponse{Message: "Failed to get last insert id", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	request.PolicyID = int(policyID)
+
+	return helpers.ApiResponse{Message: "Successfully added circulation policy", Data: request}, http.StatusCreated, nil
+}
+
+func (repository *CirculationPolicyRepository) UpdatePolicyRepository(r *http.Request, request request_models.CirculationPolicyRequest) (helpers.ApiResponse, int, error) {
+	if message := validatePolicy(request); message != "" {
+		err := errors.New(message)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Invalid circulation policy")
+
+		return helpers.ApiResponse{Message: message, Data: nil}, http.StatusBadRequest, err
+	}
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	// The role and genre a policy applies to are fixed, delete and add a policy to change them.
+	query := `UPDATE circulation_policies
+			  SET loan_period_days = ?, daily_fine = ?, max_fine = ?, grace_days = ?, currency = ?, max_loans = ?, max_unpaid_fine = ?, max_renewals = ?, updated_at = ?
+			  WHERE policy_id = ?`
+	result, err := repository.db.ExecContext(ctx, query, request.LoanPeriodDays, request.DailyFine, request.MaxFine,
+		request.GraceDays, request.Currency, request.MaxLoans, request.MaxUnpaidFine, request.MaxRenewals, time.Now().Format(layoutDateTime), request.PolicyID)
+	if err != nil {
+		return repository.execError(r, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to get rows affected")
+
+		return helpers.ApiResponse{Message: "Failed to get rows affected", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if affected == 0 {
+		err = errors.New("policy not found")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Policy not found")
+
+		return helpers.ApiResponse{Message: "Circulation policy not found", Data: nil}, http.StatusNotFound, err
+	}
+
+	return helpers.ApiResponse{Message: "Successfully updated circulation policy", Data: request}, http.StatusOK, nil
+}
+
+func (repository *CirculationPolicyRepository) DeletePolicyRepository(r *http.Request, policyID int) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	result, err := repository.db.ExecContext(ctx, "DELETE FROM circulation_policies WHERE policy_id = ?", policyID)
+	if err != nil {
+		return repository.execError(r, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to get rows affected")
+
+		return helpers.ApiResponse{Message: "Failed to get rows affected", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if affected == 0 {
+		err = errors.New("policy not found")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Policy not found")
+
+		return helpers.ApiResponse{Message: "Circulation policy not found", Data: nil}, http.StatusNotFound, err
+	}
+
+	return helpers.ApiResponse{Message: "Successfully deleted circulation policy", Data: nil}, http.StatusOK, nil
+}
+
+func (repository *CirculationPolicyRepository) execError(r *http.Request, err error) (helpers.ApiResponse, int, error) {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1452 {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Role or genre does not exist")
+
+		return helpers.ApiResponse{Message: "Role or genre does not exist", Data: nil}, http.StatusNotFound, err
+	}
+
+	repository.logLogrus.WithFields(logrus.Fields{
+		"error": err,
+		"data":  r.Body,
+	}).Error("Failed to execute statement")
+
+	return helpers.ApiResponse{Message: "Failed to execute statement", Data: nil}, http.StatusInternalServerError, err
+}
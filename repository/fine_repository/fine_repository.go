@@ -0,0 +1,393 @@
+package fine_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/audit_repository"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const layoutDateTime = "2006-01-02 15:04:05"
+
+type FineRepository struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+}
+
+func NewFineRepository(db *sql.DB, logLogrus *logrus.Logger) *FineRepository {
+	return &FineRepository{db: db, logLogrus: logLogrus}
+}
+
+// RecordFine adds an outstanding fine for a loan to the ledger and returns its ID.
+func RecordFine(ctx context2.Context, tx *sql.Tx, borrowID, userID, difference, amount int, currency string, at time.Time) (int, error) {
+	now := at.Format(layoutDateTime)
+	query := `INSERT INTO due_date_data (borrow_id, user_id, difference, amount_of_fine, amount_paid, amount_waived, currency, status, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, 0, 0, ?, ?, ?, ?)`
+	result, err := tx.ExecContext(ctx, query, borrowID, userID, difference, amount, currency, identity.FineStatusOutstanding, now, now)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// fineStatus derives the ledger status of a fine from what has been settled so far.
+func fineStatus(fine identity.DueDate) string {
+	switch {
+	case fine.AmountPaid+fine.AmountWaived >= fine.AmountOfFine:
+		return identity.FineStatusSettled
+	case fine.AmountPaid+fine.AmountWaived > 0:
+		return identity.FineStatusPartiallyPaid
+	default:
+		return identity.FineStatusOutstanding
+	}
+}
+
+func (repository *FineRepository) PayFineRepository(r *http.Request, request request_models.FinePaymentRequest, staffID int) (helpers.ApiResponse, int, error) {
+	if request.Amount <= 0 {
+		err := errors.New("amount must be positive")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Invalid payment")
+
+		return helpers.ApiResponse{Message: "Payment amount must be greater than zero", Data: nil}, http.StatusBadRequest, err
+	}
+
+	return repository.settle(r, request.DueDateID, request.Amount, identity.FineTransactionPayment, "", staffID)
+}
+
+// WaiveFineRepository forgives part of a fine, or all that is still outstanding when no amount is given.
+func (repository *FineRepository) WaiveFineRepository(r *http.Request, request request_models.FineWaiverRequest, managerID int) (helpers.ApiResponse, int, error) {
+	request.Reason = strings.TrimSpace(request.Reason)
+	if request.Reason == "" || request.Amount < 0 {
+		err := errors.New("reason is required")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Invalid waiver")
+
+		return helpers.ApiResponse{Message: "A waiver needs a reason and a non-negative amount", Data: nil}, http.StatusBadRequest, err
+	}
+
+	return repository.settle(r, request.DueDateID, request.Amount, identity.FineTransactionWaiver, request.Reason, managerID)
+}
+
+// settle records a payment or waiver against a fine. An amount of zero settles the outstanding balance.
+func (repository *FineRepository) settle(r *http.Request, dueDateID, amount int, transactionType, reason string, actorID int) (helpers.ApiResponse, int, error) {
+	var fine identity.DueDate
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to begin transaction")
+
+		return helpers.ApiResponse{Message: "Failed to begin transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT due_date_id, borrow_id, user_id, difference, amount_of_fine, amount_paid, amount_waived, currency, created_at
+			  FROM due_date_data WHERE due_date_id = ? FOR UPDATE`
+	err = tx.QueryRowContext(ctx, query, dueDateID).Scan(&fine.DueDateID, &fine.BorrowID, &fine.UserID, &fine.Difference,
+		&fine.AmountOfFine, &fine.AmountPaid, &fine.AmountWaived, &fine.Currency, &fine.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("Fine not found")
+
+			return helpers.ApiResponse{Message: "Fine not found", Data: nil}, http.StatusNotFound, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	before := fine
+
+	outstanding := fine.AmountOfFine - fine.AmountPaid - fine.AmountWaived
+	if outstanding <= 0 {
+		err = errors.New("fine already settled")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Fine already settled")
+
+		return helpers.ApiResponse{Message: "Fine already settled", Data: nil}, http.StatusConflict, err
+	}
+
+	if amount == 0 {
+		amount = outstanding
+	}
+
+	if amount > outstanding {
+		err = fmt.Errorf("amount %d exceeds outstanding %d", amount, outstanding)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Amount exceeds outstanding balance")
+
+		formatted := helpers.NewFormatterMoney().FormatMinorUnits(outstanding, fine.Currency)
+		return helpers.ApiResponse{Message: fmt.Sprintf("Amount exceeds the outstanding balance of %s", formatted), Data: nil}, http.StatusConflict, err
+	}
+
+	if transactionType == identity.FineTransactionPayment {
+		fine.AmountPaid += amount
+	} else {
+		fine.AmountWaived += amount
+	}
+	fine.Status = fineStatus(fine)
+	fine.UpdatedAt = time.Now().Format(layoutDateTime)
+
+	queryUpdate := "UPDATE due_date_data SET amount_paid = ?, amount_waived = ?, status = ?, updated_at = ? WHERE due_date_id = ?"
+	_, err = tx.ExecContext(ctx, queryUpdate, fine.AmountPaid, fine.AmountWaived, fine.Status, fine.UpdatedAt, fine.DueDateID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to update fine")
+
+		return helpers.ApiResponse{Message: "Failed to update fine", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	transaction := identity.FineTransaction{
+		DueDateID:  fine.DueDateID,
+		Type:       transactionType,
+		Amount:     amount,
+		Reason:     reason,
+		RecordedBy: actorID,
+		CreatedAt:  fine.UpdatedAt,
+	}
+
+	queryTransaction := "INSERT INTO fine_transactions (due_date_id, type, amount, reason, recorded_by, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	result, err := tx.ExecContext(ctx, queryTransaction, transaction.DueDateID, transaction.Type, transaction.Amount, transaction.Reason, transaction.RecordedBy, transaction.CreatedAt)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to record transaction")
+
+		return helpers.ApiResponse{Message: "Failed to record transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	transactionID, err := result.LastInsertId()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to get last insert id")
+
+		return helpers.ApiResponse{Message: "Failed to get last insert id", Data: nil}, http.StatusInternalServerError, err
+	}
+	transaction.TransactionID = int(transactionID)
+
+	if transactionType == identity.FineTransactionWaiver {
+		after := fine
+		after.Transactions = []identity.FineTransaction{transaction}
+
+		err = audit_repository.Record(ctx, tx, r, audit_repository.Event{
+			Action:     identity.AuditActionFineWaive,
+			EntityType: identity.AuditEntityFine,
+			EntityID:   fine.DueDateID,
+			Before:     before,
+			After:      after,
+		})
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("Failed to record audit entry")
+
+			return helpers.ApiResponse{Message: "Failed to record audit entry", Data: nil}, http.StatusInternalServerError, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to commit transaction")
+
+		return helpers.ApiResponse{Message: "Failed to commit transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	fine.Outstanding = outstanding - amount
+	fine.FormattedOutstanding = helpers.NewFormatterMoney().FormatMinorUnits(fine.Outstanding, fine.Currency)
+	fine.Transactions = []identity.FineTransaction{transaction}
+
+	message := "Successfully recorded payment"
+	if transactionType == identity.FineTransactionWaiver {
+		message = "Successfully waived fine"
+	}
+
+	return helpers.ApiResponse{Message: message, Data: fine}, http.StatusOK, nil
+}
+
+func (repository *FineRepository) GetFinesRepository(filter request_models.FineFilter) (helpers.ApiResponse, int, error) {
+	switch filter.Status {
+	case "", identity.FineStatusOutstanding, identity.FineStatusPartiallyPaid, identity.FineStatusSettled:
+	default:
+		err := fmt.Errorf("unknown status %q", filter.Status)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Invalid status",
+		}).Error("Invalid status")
+
+		return helpers.ApiResponse{Message: "Status must be outstanding, partially_paid or settled", Data: nil}, http.StatusBadRequest, err
+	}
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	fines, balances, err := repository.fines(ctx, filter)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to execute query",
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	data := struct {
+		Fines       []identity.DueDate     `json:"fines"`
+		Outstanding []identity.FineBalance `json:"outstanding"`
+	}{
+		Fines:       fines,
+		Outstanding: balances,
+	}
+
+	return helpers.ApiResponse{Message: "Success", Data: data}, http.StatusOK, nil
+}
+
+// MyFinesRepository lists the fines of a user together with the outstanding balance per currency.
+func (repository *FineRepository) MyFinesRepository(userID int) (helpers.ApiResponse, int, error) {
+	return repository.GetFinesRepository(request_models.FineFilter{UserID: userID})
+}
+
+func (repository *FineRepository) fines(ctx context2.Context, filter request_models.FineFilter) ([]identity.DueDate, []identity.FineBalance, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if filter.UserID > 0 {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	query := `SELECT due_date_id, borrow_id, user_id, difference, amount_of_fine, amount_paid, amount_waived, currency, status, created_at, updated_at
+			  FROM due_date_data`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY due_date_id DESC"
+
+	rows, err := repository.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	fines := []identity.DueDate{}
+	index := make(map[int]int)
+	outstanding := make(map[string]int)
+	var currencies []string
+
+	formatter := helpers.NewFormatterMoney()
+	for rows.Next() {
+		var fine identity.DueDate
+		err = rows.Scan(&fine.DueDateID, &fine.BorrowID, &fine.UserID, &fine.Difference, &fine.AmountOfFine, &fine.AmountPaid,
+			&fine.AmountWaived, &fine.Currency, &fine.Status, &fine.CreatedAt, &fine.UpdatedAt)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fine.Outstanding = fine.AmountOfFine - fine.AmountPaid - fine.AmountWaived
+		fine.FormattedOutstanding = formatter.FormatMinorUnits(fine.Outstanding, fine.Currency)
+
+		if _, ok := outstanding[fine.Currency]; !ok {
+			currencies = append(currencies, fine.Currency)
+		}
+		outstanding[fine.Currency] += fine.Outstanding
+
+		index[fine.DueDateID] = len(fines)
+		fines = append(fines, fine)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(fines) > 0 {
+		err = repository.attachTransactions(ctx, fines, index)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	balances := []identity.FineBalance{}
+	for _, currency := range currencies {
+		balances = append(balances, identity.FineBalance{
+			Currency:             currency,
+			Outstanding:          outstanding[currency],
+			FormattedOutstanding: formatter.FormatMinorUnits(outstanding[currency], currency),
+		})
+	}
+
+	return fines, balances, nil
+}
+
+func (repository *FineRepository) attachTransactions(ctx context2.Context, fines []identity.DueDate, index map[int]int) error {
+	placeholders := make([]string, len(fines))
+	args := make([]any, len(fines))
+	for i, fine := range fines {
+		placeholders[i] = "?"
+		args[i] = fine.DueDateID
+	}
+
+	query := `SELECT transaction_id, due_date_id, type, amount, reason, recorded_by, created_at
+			  FROM fine_transactions WHERE due_date_id IN (` + strings.Join(placeholders, ", ") + `) ORDER BY transaction_id`
+	rows, err := repository.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transaction identity.FineTransaction
+		err = rows.Scan(&transaction.TransactionID, &transaction.DueDateID, &transaction.Type, &transaction.Amount,
+			&transaction.Reason, &transaction.RecordedBy, &transaction.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		i := index[transaction.DueDateID]
+		fines[i].Transactions = append(fines[i].Transactions, transaction)
+	}
+
+	return rows.Err()
+}
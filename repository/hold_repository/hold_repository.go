@@ -0,0 +1,509 @@
+package hold_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/mailer"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/repository/book_copy_repository"
+	"go-libraryschool/repository/mail_outbox_repository"
+	"net/http"
+	"time"
+)
+
+const (
+	layoutDateTime = "2006-01-02 15:04:05"
+	layoutPickup   = "2006-01-02 15:04"
+
+	// PickupWindow is how long a copy stays on the hold shelf for the patron at the head of the queue.
+	PickupWindow = 72 * time.Hour
+)
+
+type HoldRepository struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+	rdb       *redis.Client
+}
+
+func NewHoldRepository(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *HoldRepository {
+	return &HoldRepository{db: db, logLogrus: logLogrus, rdb: rdb}
+}
+
+// ReleaseCopy puts a copy that just came back to the library at the disposal of the hold queue of its title.
+// The oldest waiting hold gets the copy with a pickup deadline, otherwise the copy goes back on the shelf.
+// The patron is mailed through the outbox in the same transaction, a mail that cannot be rendered is logged
+// and left out rather than failing the return.
+// It returns the ID of the hold the copy was allocated to, or zero.
+func ReleaseCopy(ctx context2.Context, tx *sql.Tx, bookID, copyID int) (int, error) {
+	var holdID, userID int
+
+	now := time.Now()
+
+	query := "SELECT hold_id, user_id FROM holds WHERE book_id = ? AND status = ? ORDER BY hold_id LIMIT 1 FOR UPDATE"
+	err := tx.QueryRowContext(ctx, query, bookID, identity.HoldStatusWaiting).Scan(&holdID, &userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	copyStatus := identity.CopyStatusAvailable
+	if holdID > 0 {
+		copyStatus = identity.CopyStatusOnHold
+
+		queryReady := "UPDATE holds SET status = ?, copy_id = ?, ready_at = ?, pickup_deadline = ?, updated_at = ? WHERE hold_id = ?"
+		_, err = tx.ExecContext(ctx, queryReady, identity.HoldStatusReady, copyID, now.Format(layoutDateTime), now.Add(PickupWindow).Format(layoutDateTime), now.Format(layoutDateTime), holdID)
+		if err != nil {
+			return 0, err
+		}
+
+		err = notifyHoldReady(ctx, tx, userID, bookID, now.Add(PickupWindow))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE book_copies SET status = ?, updated_at = ? WHERE copy_id = ?", copyStatus, now.Format(layoutDateTime), copyID)
+	if err != nil {
+		return 0, err
+	}
+
+	return holdID, book_copy_repository.SyncBookStock(ctx, tx, bookID)
+}
+
+// notifyHoldReady queues the mail telling a patron their hold can be picked up.
+func notifyHoldReady(ctx context2.Context, tx *sql.Tx, userID, bookID int, deadline time.Time) error {
+	var email, language string
+
+	data := mailer.HoldReadyData{PickupDeadline: deadline.Format(layoutPickup)}
+
+	query := "SELECT u.username, u.email, u.language, b.title FROM users u JOIN books b ON b.book_id = ? WHERE u.id = ?"
+	err := tx.QueryRowContext(ctx, query, bookID, userID).Scan(&data.Username, &email, &language, &data.Title)
+	if err != nil {
+		return err
+	}
+
+	message, err := mailer.Render(email, mailer.TemplateHoldReady, language, data)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error":   err,
+			"user_id": userID,
+			"book_id": bookID,
+		}).Error("Failed to render hold ready email")
+		return nil
+	}
+
+	_, err = mail_outbox_repository.Enqueue(ctx, tx, message)
+	return err
+}
+
+// ClaimReadyHold hands the copy set aside for a patron over to their loan.
+// It returns the copy ID and false when the patron has no hold ready for the title or missed its pickup deadline.
+func ClaimReadyHold(ctx context2.Context, tx *sql.Tx, bookID, userID int) (int, bool, error) {
+	var (
+		holdID int
+		copyID int
+	)
+
+	query := "SELECT hold_id, copy_id FROM holds WHERE book_id = ? AND user_id = ? AND status = ? AND pickup_deadline >= ? LIMIT 1 FOR UPDATE"
+	err := tx.QueryRowContext(ctx, query, bookID, userID, identity.HoldStatusReady, time.Now().Format(layoutDateTime)).Scan(&holdID, &copyID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE holds SET status = ?, updated_at = ? WHERE hold_id = ?", identity.HoldStatusFulfilled, time.Now().Format(layoutDateTime), holdID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return copyID, true, nil
+}
+
+// ProcessHoldsRepository expires holds whose pickup deadline has passed, rolling their copy to the next
+// patron in line, and hands copies sitting on the shelf to titles that still have a waiting queue.
+// It runs as a scheduled job.
+func (repository *HoldRepository) ProcessHoldsRepository(ctx context2.Context) error {
+	type pendingCopy struct {
+		holdID int
+		bookID int
+		copyID int
+	}
+
+	var expired []pendingCopy
+
+	query := "SELECT hold_id, book_id, copy_id FROM holds WHERE status = ? AND pickup_deadline < ?"
+	rows, err := repository.db.QueryContext(ctx, query, identity.HoldStatusReady, time.Now().Format(layoutDateTime))
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var item pendingCopy
+		err = rows.Scan(&item.holdID, &item.bookID, &item.copyID)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		expired = append(expired, item)
+	}
+	rows.Close()
+
+	for _, item := range expired {
+		err = repository.inTx(ctx, func(tx *sql.Tx) error {
+			result, err := tx.ExecContext(ctx, "UPDATE holds SET status = ?, updated_at = ? WHERE hold_id = ? AND status = ?", identity.HoldStatusExpired, time.Now().Format(layoutDateTime), item.holdID, identity.HoldStatusReady)
+			if err != nil {
+				return err
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil || affected == 0 {
+				return err
+			}
+
+			_, err = ReleaseCopy(ctx, tx, item.bookID, item.copyID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		repository.logLogrus.Infof("Hold %d expired, copy %d released", item.holdID, item.copyID)
+	}
+
+	var shelved []pendingCopy
+
+	queryShelved := `SELECT c.book_id, c.copy_id
+					 FROM book_copies c
+					 WHERE c.status = ?
+					   AND EXISTS (SELECT 1 FROM holds h WHERE h.book_id = c.book_id AND h.status = ?)`
+	rows, err = repository.db.QueryContext(ctx, queryShelved, identity.CopyStatusAvailable, identity.HoldStatusWaiting)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var item pendingCopy
+		err = rows.Scan(&item.bookID, &item.copyID)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		shelved = append(shelved, item)
+	}
+	rows.Close()
+
+	for _, item := range shelved {
+		err = repository.inTx(ctx, func(tx *sql.Tx) error {
+			var status string
+			err := tx.QueryRowContext(ctx, "SELECT status FROM book_copies WHERE copy_id = ? FOR UPDATE", item.copyID).Scan(&status)
+			if err != nil || status != identity.CopyStatusAvailable {
+				return err
+			}
+
+			_, err = ReleaseCopy(ctx, tx, item.bookID, item.copyID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(expired) > 0 || len(shelved) > 0 {
+		err = repository.rdb.Del(ctx, "Books").Err()
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{"error": err}).Warn("Failed to invalidate books cache")
+		}
+	}
+
+	return nil
+}
+
+func (repository *HoldRepository) PlaceHoldRepository(r *http.Request, userID, bookID int) (helpers.ApiResponse, int, error) {
+	var (
+		hold      identity.Hold
+		available int
+		count     int
+	)
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to begin transaction")
+
+		return helpers.ApiResponse{Message: "Failed to begin transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "SELECT title, available FROM books WHERE book_id = ? FOR UPDATE", bookID).Scan(&hold.Title, &available)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("Book does not exist")
+
+			return helpers.ApiResponse{Message: "Book does not exist", Data: nil}, http.StatusNotFound, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if available > 0 {
+		err = errors.New("copies available")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Copies still available")
+
+		return helpers.ApiResponse{Message: "Copies of this book are available, borrow it instead", Data: nil}, http.StatusConflict, err
+	}
+
+	queryActive := "SELECT COUNT(*) FROM holds WHERE book_id = ? AND user_id = ? AND status IN (?, ?)"
+	err = tx.QueryRowContext(ctx, queryActive, bookID, userID, identity.HoldStatusWaiting, identity.HoldStatusReady).Scan(&count)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if count > 0 {
+		err = errors.New("hold already placed")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Hold already placed")
+
+		return helpers.ApiResponse{Message: "You already have a hold on this book", Data: nil}, http.StatusConflict, err
+	}
+
+	now := time.Now().Format(layoutDateTime)
+	queryInsert := "INSERT INTO holds (book_id, user_id, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?)"
+	result, err := tx.ExecContext(ctx, queryInsert, bookID, userID, identity.HoldStatusWaiting, now, now)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to place hold")
+
+		return helpers.ApiResponse{Message: "Failed to place hold", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	holdID, err := result.LastInsertId()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to get last insert id")
+
+		return helpers.ApiResponse{Message: "Failed to get last insert id", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	queryPosition := "SELECT COUNT(*) FROM holds WHERE book_id = ? AND status = ? AND hold_id <= ?"
+	err = tx.QueryRowContext(ctx, queryPosition, bookID, identity.HoldStatusWaiting, holdID).Scan(&hold.Position)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to get queue position")
+
+		return helpers.ApiResponse{Message: "Failed to get queue position", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to commit transaction")
+
+		return helpers.ApiResponse{Message: "Failed to commit transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	hold.HoldID = int(holdID)
+	hold.BookID = bookID
+	hold.UserID = userID
+	hold.Status = identity.HoldStatusWaiting
+	hold.CreatedAt = now
+
+	return helpers.ApiResponse{Message: "Successfully placed hold", Data: hold}, http.StatusCreated, nil
+}
+
+// GetHoldsRepository lists the active holds, FIFO per title. A zero bookID or userID means no filter.
+func (repository *HoldRepository) GetHoldsRepository(bookID, userID int) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	query := `SELECT h.hold_id, h.book_id, b.title, h.user_id, u.username, COALESCE(h.copy_id, 0), COALESCE(c.barcode, ''), h.status,
+					 h.created_at, COALESCE(h.ready_at, ''), COALESCE(h.pickup_deadline, ''),
+					 (SELECT COUNT(*) FROM holds q WHERE q.book_id = h.book_id AND q.status = ? AND q.hold_id <= h.hold_id)
+			  FROM holds h
+					   JOIN books b ON b.book_id = h.book_id
+					   JOIN users u ON u.id = h.user_id
+					   LEFT JOIN book_copies c ON c.copy_id = h.copy_id
+			  WHERE h.status IN (?, ?)
+				AND (? = 0 OR h.book_id = ?)
+				AND (? = 0 OR h.user_id = ?)
+			  ORDER BY h.book_id, h.hold_id`
+	rows, err := repository.db.QueryContext(ctx, query, identity.HoldStatusWaiting, identity.HoldStatusWaiting, identity.HoldStatusReady, bookID, bookID, userID, userID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to execute query",
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+
+	var holds []identity.Hold
+	for rows.Next() {
+		var hold identity.Hold
+		err = rows.Scan(&hold.HoldID, &hold.BookID, &hold.Title, &hold.UserID, &hold.Username, &hold.CopyID, &hold.Barcode, &hold.Status, &hold.CreatedAt, &hold.ReadyAt, &hold.PickupDeadline, &hold.Position)
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Failed to scan row",
+			}).Error("Failed to scan row")
+
+			return helpers.ApiResponse{Message: "Failed to scan row", Data: nil}, http.StatusInternalServerError, err
+		}
+
+		if hold.Status == identity.HoldStatusReady {
+			hold.Position = 0
+		}
+
+		holds = append(holds, hold)
+	}
+
+	if len(holds) == 0 {
+		return helpers.ApiResponse{Message: "No holds found", Data: nil}, http.StatusNotFound, nil
+	}
+
+	return helpers.ApiResponse{Message: "Success", Data: holds}, http.StatusOK, nil
+}
+
+// CancelHoldRepository cancels an active hold. Unless staff is true, only the owner of the hold may cancel it.
+func (repository *HoldRepository) CancelHoldRepository(r *http.Request, holdID, userID int, staff bool) (helpers.ApiResponse, int, error) {
+	var hold identity.Hold
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to begin transaction")
+
+		return helpers.ApiResponse{Message: "Failed to begin transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT hold_id, book_id, user_id, COALESCE(copy_id, 0), status FROM holds WHERE hold_id = ? FOR UPDATE"
+	err = tx.QueryRowContext(ctx, query, holdID).Scan(&hold.HoldID, &hold.BookID, &hold.UserID, &hold.CopyID, &hold.Status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("Hold does not exist")
+
+			return helpers.ApiResponse{Message: "Hold does not exist", Data: nil}, http.StatusNotFound, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if !staff && hold.UserID != userID {
+		err = errors.New("hold belongs to another user")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Forbidden")
+
+		return helpers.ApiResponse{Message: "Forbidden", Data: nil}, http.StatusForbidden, err
+	}
+
+	if hold.Status != identity.HoldStatusWaiting && hold.Status != identity.HoldStatusReady {
+		err = errors.New("hold is no longer active")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Hold is no longer active")
+
+		return helpers.ApiResponse{Message: "Hold is no longer active", Data: nil}, http.StatusConflict, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE holds SET status = ?, updated_at = ? WHERE hold_id = ?", identity.HoldStatusCancelled, time.Now().Format(layoutDateTime), holdID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to cancel hold")
+
+		return helpers.ApiResponse{Message: "Failed to cancel hold", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if hold.Status == identity.HoldStatusReady {
+		_, err = ReleaseCopy(ctx, tx, hold.BookID, hold.CopyID)
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("Failed to release copy")
+
+			return helpers.ApiResponse{Message: "Failed to release copy", Data: nil}, http.StatusInternalServerError, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to commit transaction")
+
+		return helpers.ApiResponse{Message: "Failed to commit transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	hold.Status = identity.HoldStatusCancelled
+
+	return helpers.ApiResponse{Message: "Successfully cancelled hold", Data: hold}, http.StatusOK, nil
+}
+
+func (repository *HoldRepository) inTx(ctx context2.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,140 @@
+package login_attempt_repository
+
+import (
+	context2 "context"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+// AttemptStore keeps the failed logins and lockouts of accounts, keyed by normalized email.
+type AttemptStore interface {
+	// LockedFor returns how long the account stays locked, zero when it is not locked.
+	LockedFor(ctx context2.Context, email string) (time.Duration, error)
+	// CountFailure counts a wrong password and returns the failures within failedLoginWindow.
+	CountFailure(ctx context2.Context, email string) (int64, error)
+	// Lock locks the account for LockoutDuration and forgets its failures.
+	Lock(ctx context2.Context, email, clientIP string) error
+	// Reset forgets the failures of the account.
+	Reset(ctx context2.Context, email string) error
+	// Unlock lifts the lockout and forgets the failures, it returns false when there was nothing to lift.
+	Unlock(ctx context2.Context, email string) (bool, error)
+}
+
+// RedisAttemptStore shares failures and lockouts between instances.
+type RedisAttemptStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisAttemptStore(rdb *redis.Client) *RedisAttemptStore {
+	return &RedisAttemptStore{rdb: rdb}
+}
+
+func (store *RedisAttemptStore) LockedFor(ctx context2.Context, email string) (time.Duration, error) {
+	locked, err := store.rdb.PTTL(ctx, lockoutKey(email)).Result()
+	if err != nil || locked < 0 {
+		return 0, err
+	}
+	return locked, nil
+}
+
+func (store *RedisAttemptStore) CountFailure(ctx context2.Context, email string) (int64, error) {
+	key := failedLoginsKey(email)
+
+	failures, err := store.rdb.Incr(ctx, key).Result()
+	if err == nil && failures == 1 {
+		err = store.rdb.Expire(ctx, key, failedLoginWindow).Err()
+	}
+	return failures, err
+}
+
+func (store *RedisAttemptStore) Lock(ctx context2.Context, email, clientIP string) error {
+	_, err := store.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, lockoutKey(email), clientIP, LockoutDuration)
+		pipe.Del(ctx, failedLoginsKey(email))
+		return nil
+	})
+	return err
+}
+
+func (store *RedisAttemptStore) Reset(ctx context2.Context, email string) error {
+	return store.rdb.Del(ctx, failedLoginsKey(email)).Err()
+}
+
+func (store *RedisAttemptStore) Unlock(ctx context2.Context, email string) (bool, error) {
+	unlocked, err := store.rdb.Del(ctx, lockoutKey(email), failedLoginsKey(email)).Result()
+	return unlocked > 0, err
+}
+
+type memoryFailures struct {
+	count   int64
+	expires time.Time
+}
+
+// MemoryAttemptStore keeps failures and lockouts in the process, for deployments without Redis.
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	failures map[string]memoryFailures
+	lockouts map[string]time.Time
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{failures: map[string]memoryFailures{}, lockouts: map[string]time.Time{}}
+}
+
+func (store *MemoryAttemptStore) LockedFor(ctx context2.Context, email string) (time.Duration, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	locked := time.Until(store.lockouts[email])
+	if locked <= 0 {
+		delete(store.lockouts, email)
+		return 0, nil
+	}
+	return locked, nil
+}
+
+func (store *MemoryAttemptStore) CountFailure(ctx context2.Context, email string) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	entry := store.failures[email]
+	if !now.Before(entry.expires) {
+		entry = memoryFailures{expires: now.Add(failedLoginWindow)}
+	}
+	entry.count++
+	store.failures[email] = entry
+
+	return entry.count, nil
+}
+
+func (store *MemoryAttemptStore) Lock(ctx context2.Context, email, clientIP string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.lockouts[email] = time.Now().Add(LockoutDuration)
+	delete(store.failures, email)
+	return nil
+}
+
+func (store *MemoryAttemptStore) Reset(ctx context2.Context, email string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.failures, email)
+	return nil
+}
+
+func (store *MemoryAttemptStore) Unlock(ctx context2.Context, email string) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	entry, failed := store.failures[email]
+	unlocked := store.lockouts[email].After(now) || (failed && now.Before(entry.expires))
+
+	delete(store.lockouts, email)
+	delete(store.failures, email)
+	return unlocked, nil
+}
@@ -0,0 +1,204 @@
+package login_attempt_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/mailer"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/ratelimit"
+	"go-libraryschool/repository/mail_outbox_repository"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxFailedLogins wrong passwords within failedLoginWindow lock the account for LockoutDuration.
+	MaxFailedLogins   = 5
+	failedLoginWindow = 15 * time.Minute
+	LockoutDuration   = 15 * time.Minute
+)
+
+var (
+	// Login attempts allowed per client IP and per email, whatever their outcome.
+	loginIPLimit    = ratelimit.Limit{Requests: 20, Window: 5 * time.Minute}
+	loginEmailLimit = ratelimit.Limit{Requests: 10, Window: 5 * time.Minute}
+)
+
+func failedLoginsKey(email string) string {
+	return fmt.Sprintf("login-failures:%s", email)
+}
+
+func lockoutKey(email string) string {
+	return fmt.Sprintf("login-lockout:%s", email)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+type LoginAttemptRepository struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+	store     AttemptStore
+	limiter   ratelimit.Limiter
+}
+
+// NewLoginAttemptRepository keeps attempts in Redis when a client is configured and in memory otherwise.
+func NewLoginAttemptRepository(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *LoginAttemptRepository {
+	if rdb == nil {
+		return NewLoginAttemptRepositoryWithStore(db, logLogrus, NewMemoryAttemptStore(), ratelimit.NewLimiter(nil))
+	}
+	return NewLoginAttemptRepositoryWithStore(db, logLogrus, NewRedisAttemptStore(rdb), ratelimit.NewLimiter(rdb))
+}
+
+func NewLoginAttemptRepositoryWithStore(db *sql.DB, logLogrus *logrus.Logger, store AttemptStore, limiter ratelimit.Limiter) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db, logLogrus: logLogrus, store: store, limiter: limiter}
+}
+
+func tooManyAttempts(message string, wait time.Duration) (helpers.ApiResponse, int, error) {
+	return helpers.ApiResponse{Message: message, Data: helpers.NewRetryAfter(wait)}, http.StatusTooManyRequests, errors.New("too many login attempts")
+}
+
+// CheckLoginRepository lets a login attempt through unless the account is locked or the client IP or the email
+// used up their attempts. It answers 200 when the password may be checked.
+func (repository *LoginAttemptRepository) CheckLoginRepository(email, clientIP string) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	email = normalizeEmail(email)
+
+	locked, err := repository.store.LockedFor(ctx, email)
+	if err == nil && locked > 0 {
+		repository.logLogrus.Warnf("Login attempt on locked account, email: %s, ip: %s", email, clientIP)
+		return tooManyAttempts("Too many failed logins, the account is temporarily locked", locked)
+	}
+
+	var wait time.Duration
+	if err == nil {
+		wait, err = repository.limiter.Allow(ctx, "login:ip:"+clientIP, loginIPLimit)
+	}
+	if err == nil && wait == 0 {
+		wait, err = repository.limiter.Allow(ctx, "login:email:"+email, loginEmailLimit)
+	}
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to check login attempts",
+		}).Error("Failed to check login attempts")
+
+		return helpers.ApiResponse{Message: "Failed to check login attempts"}, http.StatusInternalServerError, err
+	}
+	if wait > 0 {
+		repository.logLogrus.Warnf("Login attempts limited, email: %s, ip: %s", email, clientIP)
+		return tooManyAttempts("Too many login attempts, please try again later", wait)
+	}
+
+	return helpers.ApiResponse{Message: "Success!"}, http.StatusOK, nil
+}
+
+// FailedLoginRepository counts a wrong password for the account. The failure that reaches MaxFailedLogins
+// locks the account and mails its owner, and is answered with 429 instead of 401.
+func (repository *LoginAttemptRepository) FailedLoginRepository(email, clientIP string) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	email = normalizeEmail(email)
+
+	failures, err := repository.store.CountFailure(ctx, email)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to count failed login",
+		}).Error("Failed to count failed login")
+
+		return helpers.ApiResponse{Message: "Wrong Password!"}, http.StatusUnauthorized, err
+	}
+
+	if failures < MaxFailedLogins {
+		return helpers.ApiResponse{Message: "Wrong Password!"}, http.StatusUnauthorized, errors.New("wrong password")
+	}
+
+	err = repository.store.Lock(ctx, email, clientIP)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to lock account",
+		}).Error("Failed to lock account")
+
+		return helpers.ApiResponse{Message: "Wrong Password!"}, http.StatusUnauthorized, err
+	}
+
+	repository.logLogrus.Warnf("Account locked after %d failed logins, email: %s, ip: %s", failures, email, clientIP)
+
+	err = repository.notifyLocked(ctx, email, clientIP, int(failures))
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to queue lockout email",
+		}).Error("Failed to queue lockout email")
+	}
+
+	return tooManyAttempts("Too many failed logins, the account is temporarily locked", LockoutDuration)
+}
+
+// notifyLocked queues the mail telling the owner their account was locked.
+func (repository *LoginAttemptRepository) notifyLocked(ctx context2.Context, email, clientIP string, attempts int) error {
+	var language string
+
+	data := mailer.AccountLockedData{IP: clientIP, Attempts: attempts, Minutes: int(LockoutDuration.Minutes())}
+
+	err := repository.db.QueryRowContext(ctx, "SELECT username, language FROM users WHERE email = ?", email).Scan(&data.Username, &language)
+	if err != nil {
+		return err
+	}
+
+	message, err := mailer.Render(email, mailer.TemplateAccountLocked, language, data)
+	if err != nil {
+		return err
+	}
+
+	_, err = mail_outbox_repository.Enqueue(ctx, repository.db, message)
+	return err
+}
+
+// SucceededLogin forgets the failures of the account once its owner got the password right.
+func (repository *LoginAttemptRepository) SucceededLogin(ctx context2.Context, email string) error {
+	return repository.store.Reset(ctx, normalizeEmail(email))
+}
+
+// UnlockAccountRepository lifts the lockout of an account before it runs out.
+func (repository *LoginAttemptRepository) UnlockAccountRepository(request request_models.UnlockAccountRequest, managerID int) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	email := normalizeEmail(request.Email)
+
+	unlocked, err := repository.store.Unlock(ctx, email)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to unlock account",
+		}).Error("Failed to unlock account")
+
+		return helpers.ApiResponse{Message: "Failed to unlock account"}, http.StatusInternalServerError, err
+	}
+	if !unlocked {
+		err = errors.New("account not locked")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Account is not locked",
+		}).Error("Account is not locked")
+
+		return helpers.ApiResponse{Message: "Account is not locked"}, http.StatusNotFound, err
+	}
+
+	repository.logLogrus.Infof("Account unlocked, email: %s, by managerID: %d", email, managerID)
+
+	return helpers.ApiResponse{Message: "Account unlocked"}, http.StatusOK, nil
+}
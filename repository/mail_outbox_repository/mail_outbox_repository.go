@@ -0,0 +1,333 @@
+package mail_outbox_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/mailer"
+	"go-libraryschool/models/identity"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	layoutDateTime = "2006-01-02 15:04:05"
+
+	deliveryBatch   = 50
+	deliveryTimeout = 30 * time.Second
+
+	// leaseDuration is how long a claimed message stays with its worker. It outlasts deliveryTimeout, so only
+	// a worker that died mid-send loses its message to another one.
+	leaseDuration = 2 * deliveryTimeout
+
+	// MailRetention is how long sent and failed messages are kept before PurgeMailsRepository deletes them.
+	MailRetention = 30 * 24 * time.Hour
+)
+
+// Execer is satisfied by both *sql.DB and *sql.Tx, so mail can be queued inside a transaction.
+type Execer interface {
+	ExecContext(ctx context2.Context, query string, args ...any) (sql.Result, error)
+}
+
+type MailOutboxRepository struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+}
+
+func NewMailOutboxRepository(db *sql.DB, logLogrus *logrus.Logger) *MailOutboxRepository {
+	return &MailOutboxRepository{db: db, logLogrus: logLogrus}
+}
+
+// Enqueue stores a message in the outbox for delivery and returns its ID.
+func Enqueue(ctx context2.Context, q Execer, message mailer.Message) (int, error) {
+	now := time.Now().Format(layoutDateTime)
+	query := `INSERT INTO mail_outbox (recipient, subject, html_body, text_body, status, attempts, next_attempt_at, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, 0, ?, ?, ?)`
+	result, err := q.ExecContext(ctx, query, message.To, message.Subject, message.HTML, message.Text, identity.MailStatusPending, now, now, now)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// DeliverPendingRepository attempts every queued message whose next attempt is due.
+func (repository *MailOutboxRepository) DeliverPendingRepository(ctx context2.Context) error {
+	now := time.Now().Format(layoutDateTime)
+	query := `SELECT mail_id FROM mail_outbox
+			  WHERE (status = ? AND next_attempt_at <= ?) OR (status = ? AND locked_until <= ?)
+			  ORDER BY mail_id LIMIT ?`
+	rows, err := repository.db.QueryContext(ctx, query, identity.MailStatusPending, now, identity.MailStatusSending, now, deliveryBatch)
+	if err != nil {
+		return err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err = repository.Deliver(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Deliver makes one delivery attempt for a queued message and reports whether it was sent.
+// A failed attempt is scheduled again with exponential backoff until mailer.MaxAttempts is reached.
+// The returned error is only set when the outbox itself could not be read or updated.
+func (repository *MailOutboxRepository) Deliver(ctx context2.Context, mailID int) (bool, error) {
+	message, attempts, claimed, err := repository.claim(ctx, mailID)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	// No transaction is open while sending, the lease alone keeps other workers away from the message.
+	sendCtx, cancel := context2.WithTimeout(ctx, deliveryTimeout)
+	sendErr := mailer.Default().Send(sendCtx, message)
+	cancel()
+
+	now := time.Now()
+
+	var query string
+	if sendErr == nil {
+		// The bodies are dropped once delivered, they may hold one-time codes.
+		query = `UPDATE mail_outbox SET status = ?, html_body = '', text_body = '', last_error = NULL, locked_until = NULL, sent_at = ?, updated_at = ?
+				  WHERE mail_id = ? AND status = ?`
+		_, err = repository.db.ExecContext(ctx, query, identity.MailStatusSent, now.Format(layoutDateTime), now.Format(layoutDateTime), mailID, identity.MailStatusSending)
+	} else {
+		status := identity.MailStatusPending
+		if attempts >= mailer.MaxAttempts {
+			status = identity.MailStatusFailed
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":    sendErr,
+			"mail_id":  mailID,
+			"attempts": attempts,
+		}).Warn("Failed to deliver mail")
+
+		query = `UPDATE mail_outbox SET status = ?, last_error = ?, locked_until = NULL, next_attempt_at = ?, updated_at = ?
+				  WHERE mail_id = ? AND status = ?`
+		_, err = repository.db.ExecContext(ctx, query, status, truncate(sendErr.Error(), 500), now.Add(mailer.Backoff(attempts)).Format(layoutDateTime),
+			now.Format(layoutDateTime), mailID, identity.MailStatusSending)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return sendErr == nil, nil
+}
+
+// claim leases a pending message, or one whose lease ran out, to this worker and counts the attempt. The row is
+// only locked for the claim itself. claimed is false when the message is not due or another worker holds it.
+func (repository *MailOutboxRepository) claim(ctx context2.Context, mailID int) (mailer.Message, int, bool, error) {
+	var (
+		message  mailer.Message
+		attempts int
+	)
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return message, 0, false, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	// SKIP LOCKED leaves a message another worker is claiming right now alone.
+	query := `SELECT recipient, subject, html_body, text_body, attempts FROM mail_outbox
+			  WHERE mail_id = ? AND (status = ? OR (status = ? AND locked_until <= ?)) FOR UPDATE SKIP LOCKED`
+	err = tx.QueryRowContext(ctx, query, mailID, identity.MailStatusPending, identity.MailStatusSending, now.Format(layoutDateTime)).
+		Scan(&message.To, &message.Subject, &message.HTML, &message.Text, &attempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return message, 0, false, nil
+		}
+		return message, 0, false, err
+	}
+
+	// The attempt counts from the claim on, so a message that keeps crashing its worker still gives up.
+	attempts++
+
+	query = "UPDATE mail_outbox SET status = ?, attempts = ?, locked_until = ?, updated_at = ? WHERE mail_id = ?"
+	_, err = tx.ExecContext(ctx, query, identity.MailStatusSending, attempts, now.Add(leaseDuration).Format(layoutDateTime), now.Format(layoutDateTime), mailID)
+	if err != nil {
+		return message, 0, false, err
+	}
+
+	return message, attempts, true, tx.Commit()
+}
+
+// PurgeMailsRepository deletes sent and failed messages older than MailRetention.
+func (repository *MailOutboxRepository) PurgeMailsRepository(ctx context2.Context) error {
+	cutoff := time.Now().Add(-MailRetention).Format(layoutDateTime)
+	result, err := repository.db.ExecContext(ctx, "DELETE FROM mail_outbox WHERE status IN (?, ?) AND updated_at < ?",
+		identity.MailStatusSent, identity.MailStatusFailed, cutoff)
+	if err != nil {
+		return err
+	}
+
+	if purged, err := result.RowsAffected(); err == nil && purged > 0 {
+		repository.logLogrus.Infof("Purged %d mails older than %s", purged, cutoff)
+	}
+
+	return nil
+}
+
+// SendNow queues a message and makes a first delivery attempt right away.
+// Whether or not that attempt succeeds, the message stays in the outbox and is retried later if needed.
+func (repository *MailOutboxRepository) SendNow(ctx context2.Context, message mailer.Message) (int, bool, error) {
+	mailID, err := Enqueue(ctx, repository.db, message)
+	if err != nil {
+		return 0, false, err
+	}
+
+	sent, err := repository.Deliver(ctx, mailID)
+	return mailID, sent, err
+}
+
+func (repository *MailOutboxRepository) GetMailsRepository(r *http.Request, status, recipient string, pagination helpers.Pagination) (helpers.ApiResponse, int, error) {
+	switch status {
+	case "", identity.MailStatusPending, identity.MailStatusSending, identity.MailStatusSent, identity.MailStatusFailed:
+	default:
+		err := errors.New("unknown mail status")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Invalid mail status")
+
+		return helpers.ApiResponse{Message: "Status must be pending, sending, sent or failed", Data: nil}, http.StatusBadRequest, err
+	}
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	var (
+		conditions []string
+		args       []any
+		total      int
+	)
+
+	if status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, status)
+	}
+	if recipient != "" {
+		conditions = append(conditions, "recipient = ?")
+		args = append(args, recipient)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	err := repository.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM mail_outbox"+where, args...).Scan(&total)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	query := `SELECT mail_id, recipient, subject, status, attempts, IFNULL(last_error, ''), next_attempt_at, IFNULL(sent_at, ''), created_at
+			  FROM mail_outbox` + where + " ORDER BY mail_id DESC LIMIT ? OFFSET ?"
+	rows, err := repository.db.QueryContext(ctx, query, append(args, pagination.PerPage, pagination.Offset())...)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+
+	mails := []identity.OutboxMail{}
+	for rows.Next() {
+		var mail identity.OutboxMail
+		err = rows.Scan(&mail.MailID, &mail.Recipient, &mail.Subject, &mail.Status, &mail.Attempts, &mail.LastError, &mail.NextAttemptAt, &mail.SentAt, &mail.CreatedAt)
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.URL.RawQuery,
+			}).Error("Failed to scan row")
+
+			return helpers.ApiResponse{Message: "Failed to scan row", Data: nil}, http.StatusInternalServerError, err
+		}
+
+		if mail.Status != identity.MailStatusPending {
+			mail.NextAttemptAt = ""
+		}
+
+		mails = append(mails, mail)
+	}
+
+	return helpers.ApiResponse{Message: "Success", Data: helpers.NewPaginatedData(mails, pagination, total)}, http.StatusOK, nil
+}
+
+// RetryMailRepository puts a message that gave up back in the queue for immediate delivery.
+func (repository *MailOutboxRepository) RetryMailRepository(r *http.Request, mailID int) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	now := time.Now().Format(layoutDateTime)
+	query := "UPDATE mail_outbox SET status = ?, attempts = 0, next_attempt_at = ?, updated_at = ? WHERE mail_id = ? AND status = ?"
+	result, err := repository.db.ExecContext(ctx, query, identity.MailStatusPending, now, now, mailID, identity.MailStatusFailed)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute statement")
+
+		return helpers.ApiResponse{Message: "Failed to execute statement", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to get rows affected")
+
+		return helpers.ApiResponse{Message: "Failed to get rows affected", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if affected == 0 {
+		err = errors.New("no failed mail with this id")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Mail not found")
+
+		return helpers.ApiResponse{Message: "No failed mail with this ID", Data: nil}, http.StatusNotFound, err
+	}
+
+	return helpers.ApiResponse{Message: "Mail queued for delivery", Data: nil}, http.StatusOK, nil
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+	return value[:length]
+}
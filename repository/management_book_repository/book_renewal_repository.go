@@ -0,0 +1,244 @@
+package management_book_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/circulation_policy_repository"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RenewBookRepository pushes the due date of an open loan forward by the loan period of its circulation policy,
+// at most MaxRenewals times.
+// Unless staff is true, only the borrower may renew the loan.
+func (repository *ManagementBookRepository) RenewBookRepository(r *http.Request, book request_models.BookRenewRequest, actorID int, staff bool) (helpers.ApiResponse, int, error) {
+	var (
+		bookID        int
+		userID        int
+		returnDateStr string
+		returnedAt    sql.NullString
+		renewalCount  int
+		pendingHolds  int
+	)
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to begin transaction")
+
+		return helpers.ApiResponse{Message: "Failed to begin transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT book_id, user_id, return_date, returned_at, renewal_count FROM borrowed_books WHERE borrow_id = ? FOR UPDATE"
+	err = tx.QueryRowContext(ctx, query, book.BorrowedID).Scan(&bookID, &userID, &returnDateStr, &returnedAt, &renewalCount)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("Borrowed book not found")
+
+			return helpers.ApiResponse{Message: "Borrowed book not found", Data: nil}, http.StatusNotFound, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if !staff && userID != actorID {
+		err = errors.New("loan belongs to another user")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Forbidden")
+
+		return helpers.ApiResponse{Message: "Forbidden", Data: nil}, http.StatusForbidden, err
+	}
+
+	if returnedAt.Valid {
+		err = errors.New("book already returned")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Book already returned")
+
+		return helpers.ApiResponse{Message: "Book already returned", Data: nil}, http.StatusConflict, err
+	}
+
+	returnDate, err := time.Parse(layoutDate, returnDateStr)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse date")
+
+		return helpers.ApiResponse{Message: "Failed to parse date", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	now := time.Now()
+	if helpers.DaysLate(returnDate, now) > 0 {
+		err = errors.New("loan is overdue")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Loan is overdue")
+
+		return helpers.ApiResponse{Message: "Overdue loans cannot be renewed, please return the book", Data: nil}, http.StatusConflict, err
+	}
+
+	policy, err := circulation_policy_repository.PolicyFor(ctx, tx, userID, bookID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to resolve circulation policy")
+
+		return helpers.ApiResponse{Message: "Failed to resolve circulation policy", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if renewalCount >= policy.MaxRenewals {
+		err = fmt.Errorf("loan already renewed %d times", renewalCount)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Renewal limit reached")
+
+		return helpers.ApiResponse{Message: fmt.Sprintf("A loan can be renewed at most %d times", policy.MaxRenewals), Data: nil}, http.StatusConflict, err
+	}
+
+	queryHolds := "SELECT COUNT(*) FROM holds WHERE book_id = ? AND status = ?"
+	err = tx.QueryRowContext(ctx, queryHolds, bookID, identity.HoldStatusWaiting).Scan(&pendingHolds)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if pendingHolds > 0 {
+		err = fmt.Errorf("%d pending holds", pendingHolds)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Book has pending holds")
+
+		return helpers.ApiResponse{Message: "Other users are waiting for this book, it cannot be renewed", Data: nil}, http.StatusConflict, err
+	}
+
+	renewal := identity.LoanRenewal{
+		BorrowedID:         book.BorrowedID,
+		PreviousReturnDate: returnDateStr,
+		NewReturnDate:      helpers.LoanDueDate(policy, returnDate).Format(layoutDate),
+		RenewedBy:          actorID,
+		CreatedAt:          now.Format(layoutDateTime),
+	}
+
+	queryRenew := "UPDATE borrowed_books SET return_date = ?, renewal_count = renewal_count + 1 WHERE borrow_id = ?"
+	_, err = tx.ExecContext(ctx, queryRenew, renewal.NewReturnDate, book.BorrowedID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to renew loan")
+
+		return helpers.ApiResponse{Message: "Failed to renew loan", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	queryHistory := "INSERT INTO loan_renewals (borrow_id, previous_return_date, new_return_date, renewed_by, created_at) VALUES (?, ?, ?, ?, ?)"
+	result, err := tx.ExecContext(ctx, queryHistory, renewal.BorrowedID, renewal.PreviousReturnDate, renewal.NewReturnDate, renewal.RenewedBy, renewal.CreatedAt)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to record renewal")
+
+		return helpers.ApiResponse{Message: "Failed to record renewal", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	renewalID, err := result.LastInsertId()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to get last insert id")
+
+		return helpers.ApiResponse{Message: "Failed to get last insert id", Data: nil}, http.StatusInternalServerError, err
+	}
+	renewal.RenewalID = int(renewalID)
+
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to commit transaction")
+
+		return helpers.ApiResponse{Message: "Failed to commit transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	data := struct {
+		identity.LoanRenewal
+		RenewalCount int `json:"renewal_count"`
+		RenewalsLeft int `json:"renewals_left"`
+	}{
+		LoanRenewal:  renewal,
+		RenewalCount: renewalCount + 1,
+		RenewalsLeft: policy.MaxRenewals - renewalCount - 1,
+	}
+
+	return helpers.ApiResponse{Message: "Successfully renewed loan", Data: data}, http.StatusOK, nil
+}
+
+// loanRenewals loads the renewal history of the given loans, keyed by borrow ID.
+func (repository *ManagementBookRepository) loanRenewals(ctx context2.Context, borrowIDs []int) (map[int][]identity.LoanRenewal, error) {
+	renewals := make(map[int][]identity.LoanRenewal)
+	if len(borrowIDs) == 0 {
+		return renewals, nil
+	}
+
+	placeholders := make([]string, len(borrowIDs))
+	args := make([]any, len(borrowIDs))
+	for i, id := range borrowIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := `SELECT renewal_id, borrow_id, previous_return_date, new_return_date, renewed_by, created_at
+			  FROM loan_renewals WHERE borrow_id IN (` + strings.Join(placeholders, ", ") + `) ORDER BY renewal_id`
+	rows, err := repository.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var renewal identity.LoanRenewal
+		err = rows.Scan(&renewal.RenewalID, &renewal.BorrowedID, &renewal.PreviousReturnDate, &renewal.NewReturnDate, &renewal.RenewedBy, &renewal.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		renewals[renewal.BorrowedID] = append(renewals[renewal.BorrowedID], renewal)
+	}
+
+	return renewals, rows.Err()
+}
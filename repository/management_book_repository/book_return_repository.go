@@ -0,0 +1,183 @@
+package management_book_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/book_copy_repository"
+	"go-libraryschool/repository/circulation_policy_repository"
+	"go-libraryschool/repository/fine_repository"
+	"go-libraryschool/repository/hold_repository"
+	"net/http"
+	"time"
+)
+
+const (
+	layoutDate     = "2006-01-02"
+	layoutDateTime = "2006-01-02 15:04:05"
+)
+
+func (repository *ManagementBookRepository) ReturnBookRepository(r *http.Request, book request_models.BookReturnRequest) (helpers.ApiResponse, int, error) {
+	var (
+		bookID        int
+		userID        int
+		returnDateStr string
+		returnedAt    sql.NullString
+		lostAt        sql.NullString
+		copyID        sql.NullInt64
+	)
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to begin transaction")
+
+		return helpers.ApiResponse{Message: "Failed to begin transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT book_id, copy_id, user_id, return_date, returned_at, lost_at FROM borrowed_books WHERE borrow_id = ? FOR UPDATE"
+	err = tx.QueryRowContext(ctx, query, book.BorrowedID).Scan(&bookID, &copyID, &userID, &returnDateStr, &returnedAt, &lostAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("Borrowed book not found")
+
+			return helpers.ApiResponse{Message: "Borrowed book not found", Data: nil}, http.StatusNotFound, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if returnedAt.Valid {
+		err = errors.New("book already returned")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Book already returned")
+
+		return helpers.ApiResponse{Message: "Book already returned", Data: nil}, http.StatusConflict, err
+	}
+
+	returnDate, err := time.Parse(layoutDate, returnDateStr)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse date")
+
+		return helpers.ApiResponse{Message: "Failed to parse date", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	policy, err := circulation_policy_repository.PolicyFor(ctx, tx, userID, bookID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to resolve circulation policy")
+
+		return helpers.ApiResponse{Message: "Failed to resolve circulation policy", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	now := time.Now()
+	difference, fine := helpers.CalculateFine(policy, returnDate, now)
+
+	queryReturn := "UPDATE borrowed_books SET returned_at = ? WHERE borrow_id = ?"
+	_, err = tx.ExecContext(ctx, queryReturn, now.Format(layoutDateTime), book.BorrowedID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to mark book as returned")
+
+		return helpers.ApiResponse{Message: "Failed to mark book as returned", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	// Loans recorded before per-copy inventory have no copy to put back on the shelf, and a copy reported lost
+	// during the loan stays lost until staff mark it otherwise.
+	var allocatedHoldID int
+	if copyID.Valid && !lostAt.Valid {
+		allocatedHoldID, err = hold_repository.ReleaseCopy(ctx, tx, bookID, int(copyID.Int64))
+	} else {
+		err = book_copy_repository.SyncBookStock(ctx, tx, bookID)
+	}
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to update available copies")
+
+		return helpers.ApiResponse{Message: "Failed to update available copies", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	var dueDateID int
+	if fine > 0 {
+		dueDateID, err = fine_repository.RecordFine(ctx, tx, book.BorrowedID, userID, difference, fine, policy.Currency, now)
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("Failed to record fine")
+
+			return helpers.ApiResponse{Message: "Failed to record fine", Data: nil}, http.StatusInternalServerError, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to commit transaction")
+
+		return helpers.ApiResponse{Message: "Failed to commit transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	err = repository.rdb.Del(ctx, "Books").Err()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{"error": err}).Warn("Failed to invalidate books cache")
+	}
+
+	repository.logLogrus.Infof("Book returned, borrow_id: %d", book.BorrowedID)
+
+	data := struct {
+		BorrowedID      int    `json:"borrowed_id"`
+		BookID          int    `json:"book_id"`
+		UserID          int    `json:"user_id"`
+		ReturnDate      string `json:"return_date"`
+		ReturnedAt      string `json:"returned_at"`
+		Difference      int    `json:"difference"`
+		AmountOfFine    string `json:"amount_of_fine"`
+		DueDateID       int    `json:"due_date_id,omitempty"`
+		AllocatedHoldID int    `json:"allocated_hold_id,omitempty"`
+		CopyLost        bool   `json:"copy_lost,omitempty"`
+	}{
+		BorrowedID:      book.BorrowedID,
+		BookID:          bookID,
+		UserID:          userID,
+		ReturnDate:      returnDateStr,
+		ReturnedAt:      now.Format(layoutDateTime),
+		Difference:      difference,
+		AmountOfFine:    helpers.NewFormatterMoney().FormatMinorUnits(fine, policy.Currency),
+		DueDateID:       dueDateID,
+		AllocatedHoldID: allocatedHoldID,
+		CopyLost:        lostAt.Valid,
+	}
+
+	return helpers.ApiResponse{Message: "Successfully returned book", Data: data}, http.StatusOK, nil
+}
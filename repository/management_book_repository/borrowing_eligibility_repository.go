@@ -0,0 +1,26 @@
+package management_book_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"go-libraryschool/models/identity"
+	"time"
+)
+
+// borrowerStanding counts the open and overdue loans of a user and sums their unpaid fines in the given currency.
+func borrowerStanding(ctx context2.Context, tx *sql.Tx, userID int, currency string) (identity.BorrowerStanding, error) {
+	var standing identity.BorrowerStanding
+
+	queryLoans := `SELECT COUNT(*), IFNULL(SUM(return_date < ?), 0)
+				   FROM borrowed_books WHERE user_id = ? AND returned_at IS NULL`
+	err := tx.QueryRowContext(ctx, queryLoans, time.Now().Format(layoutDate), userID).Scan(&standing.ActiveLoans, &standing.OverdueLoans)
+	if err != nil {
+		return standing, err
+	}
+
+	queryFines := `SELECT IFNULL(SUM(amount_of_fine - amount_paid - amount_waived), 0)
+				   FROM due_date_data WHERE user_id = ? AND currency = ? AND status <> ?`
+	err = tx.QueryRowContext(ctx, queryFines, userID, currency, identity.FineStatusSettled).Scan(&standing.UnpaidFine)
+
+	return standing, err
+}
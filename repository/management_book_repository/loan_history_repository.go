@@ -0,0 +1,265 @@
+package management_book_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/circulation_policy_repository"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// loanSortColumns whitelists the columns a list of loans may be sorted by.
+var loanSortColumns = map[string]string{
+	"borrow_date": "bb.borrow_date",
+	"return_date": "bb.return_date",
+}
+
+// validateLoanFilter returns a message describing the first invalid filter value, or an empty string.
+func validateLoanFilter(filter request_models.LoanFilter) string {
+	switch filter.Status {
+	case "", identity.LoanStatusActive, identity.LoanStatusOverdue, identity.LoanStatusReturned, "current":
+	default:
+		return "Status must be active, overdue, returned or current"
+	}
+
+	for _, date := range []string{filter.From, filter.To} {
+		if _, err := time.Parse(layoutDate, date); date != "" && err != nil {
+			return "Dates must be formatted as YYYY-MM-DD"
+		}
+	}
+
+	if filter.From != "" && filter.To != "" && filter.From > filter.To {
+		return "From must not be after to"
+	}
+
+	if _, ok := loanSortColumns[filter.Sort]; filter.Sort != "" && !ok {
+		return "Sort must be borrow_date or return_date"
+	}
+
+	switch strings.ToLower(filter.Order) {
+	case "", "asc", "desc":
+	default:
+		return "Order must be asc or desc"
+	}
+
+	return ""
+}
+
+// loanConditions builds the WHERE clause shared by the loan listing and its count.
+func loanConditions(filter request_models.LoanFilter, today string) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if filter.UserID > 0 {
+		conditions = append(conditions, "bb.user_id = ?")
+		args = append(args, filter.UserID)
+	}
+
+	if filter.BookID > 0 {
+		conditions = append(conditions, "bb.book_id = ?")
+		args = append(args, filter.BookID)
+	}
+
+	if filter.From != "" {
+		conditions = append(conditions, "bb.borrow_date >= ?")
+		args = append(args, filter.From)
+	}
+
+	if filter.To != "" {
+		conditions = append(conditions, "bb.borrow_date <= ?")
+		args = append(args, filter.To)
+	}
+
+	switch filter.Status {
+	case "":
+	case identity.LoanStatusActive:
+		conditions = append(conditions, "bb.returned_at IS NULL AND bb.return_date >= ?")
+		args = append(args, today)
+	case identity.LoanStatusOverdue:
+		conditions = append(conditions, "bb.returned_at IS NULL AND bb.return_date < ?")
+		args = append(args, today)
+	case identity.LoanStatusReturned:
+		conditions = append(conditions, "bb.returned_at IS NOT NULL")
+	case "current":
+		conditions = append(conditions, "bb.returned_at IS NULL")
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// loanOrder returns the ORDER BY clause for the requested sort, newest loans first by default.
+func loanOrder(filter request_models.LoanFilter) string {
+	column, ok := loanSortColumns[filter.Sort]
+	if !ok {
+		column = loanSortColumns["borrow_date"]
+	}
+
+	direction := "DESC"
+	if strings.ToLower(filter.Order) == "asc" {
+		direction = "ASC"
+	}
+
+	return fmt.Sprintf(" ORDER BY %s %s, bb.borrow_id %s", column, direction, direction)
+}
+
+// findLoans returns one page of loans matching the filter and the total number of matches.
+// Returned loans carry the fine recorded in the ledger, open loans the fine accrued so far.
+func (repository *ManagementBookRepository) findLoans(ctx context2.Context, filter request_models.LoanFilter) ([]identity.LoanRecord, int, error) {
+	var total int
+
+	now := time.Now()
+	where, args := loanConditions(filter, now.Format(layoutDate))
+	order := loanOrder(filter)
+
+	err := repository.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM borrowed_books bb"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	policies, err := circulation_policy_repository.LoadPolicies(ctx, repository.db)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT bb.borrow_id, bb.book_id, bb.copy_id, bb.user_id, bb.checked_out_by, bb.borrow_date, bb.return_date, bb.returned_at, bb.renewal_count,
+			         u.username, b.title, b.author, b.genre_id, u.roleID, IFNULL(c.barcode, ''), d.difference, d.amount_of_fine, d.currency
+			  FROM borrowed_books bb
+			  JOIN books b ON b.book_id = bb.book_id
+			  JOIN users u ON u.id = bb.user_id
+			  LEFT JOIN book_copies c ON c.copy_id = bb.copy_id
+			  LEFT JOIN due_date_data d ON d.borrow_id = bb.borrow_id` + where + order + " LIMIT ? OFFSET ?"
+	rows, err := repository.db.QueryContext(ctx, query, append(args, filter.PerPage, filter.Offset())...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	formatter := helpers.NewFormatterMoney()
+	loans := []identity.LoanRecord{}
+	for rows.Next() {
+		var (
+			loan         identity.LoanRecord
+			copyID       sql.NullInt64
+			checkedOutBy sql.NullInt64
+			returnedAt   sql.NullString
+			genreID      int
+			roleID       int
+			difference   sql.NullInt64
+			fine         sql.NullInt64
+			currency     sql.NullString
+		)
+
+		err = rows.Scan(&loan.BorrowedID, &loan.BookID, &copyID, &loan.UserID, &checkedOutBy, &loan.BorrowDate, &loan.ReturnDate, &returnedAt,
+			&loan.RenewalCount, &loan.Username, &loan.Title, &loan.Author, &genreID, &roleID, &loan.Barcode, &difference, &fine, &currency)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		loan.CopyID = int(copyID.Int64)
+		loan.CheckedOutBy = int(checkedOutBy.Int64)
+
+		if returnedAt.Valid {
+			loan.ReturnedAt = returnedAt.String
+			loan.Status = identity.LoanStatusReturned
+
+			if fine.Valid {
+				loan.Fine.Difference = int(difference.Int64)
+				loan.Fine.AmountOfFine = formatter.FormatMinorUnits(int(fine.Int64), currency.String)
+			}
+
+			loans = append(loans, loan)
+			continue
+		}
+
+		returnDate, err := time.Parse(layoutDate, loan.ReturnDate)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		loan.Status = identity.LoanStatusActive
+		policy := helpers.ResolveCirculationPolicy(policies, roleID, genreID)
+		if days, amount := helpers.CalculateFine(policy, returnDate, now); days > 0 {
+			loan.Status = identity.LoanStatusOverdue
+			loan.Fine.Difference = days
+			loan.Fine.AmountOfFine = formatter.FormatMinorUnits(amount, policy.Currency)
+		}
+
+		loans = append(loans, loan)
+	}
+
+	return loans, total, rows.Err()
+}
+
+// listLoans validates the filter and responds with one page of matching loans.
+func (repository *ManagementBookRepository) listLoans(r *http.Request, filter request_models.LoanFilter, withRenewals bool) (helpers.ApiResponse, int, error) {
+	if message := validateLoanFilter(filter); message != "" {
+		err := errors.New(message)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Invalid loan filter")
+
+		return helpers.ApiResponse{Message: message, Data: nil}, http.StatusBadRequest, err
+	}
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	loans, total, err := repository.findLoans(ctx, filter)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if withRenewals {
+		borrowIDs := make([]int, 0, len(loans))
+		for _, loan := range loans {
+			if loan.RenewalCount > 0 {
+				borrowIDs = append(borrowIDs, loan.BorrowedID)
+			}
+		}
+
+		renewals, err := repository.loanRenewals(ctx, borrowIDs)
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.URL.RawQuery,
+			}).Error("Failed to get renewal history")
+
+			return helpers.ApiResponse{Message: "Failed to get renewal history", Data: nil}, http.StatusInternalServerError, err
+		}
+
+		for i := range loans {
+			loans[i].Renewals = renewals[loans[i].BorrowedID]
+		}
+	}
+
+	return helpers.ApiResponse{Message: "Success", Data: helpers.NewPaginatedData(loans, filter.Pagination, total)}, http.StatusOK, nil
+}
+
+// MyLoansRepository lists the current and past loans of a user.
+func (repository *ManagementBookRepository) MyLoansRepository(r *http.Request, filter request_models.LoanFilter) (helpers.ApiResponse, int, error) {
+	return repository.listLoans(r, filter, false)
+}
+
+// BookBorrowingDataRepository lists the loans of every user for staff, with their renewal history.
+func (repository *ManagementBookRepository) BookBorrowingDataRepository(r *http.Request, filter request_models.LoanFilter) (helpers.ApiResponse, int, error) {
+	return repository.listLoans(r, filter, true)
+}
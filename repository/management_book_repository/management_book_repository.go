@@ -11,6 +11,10 @@ import (
 	"go-libraryschool/helpers"
 	"go-libraryschool/models/identity"
 	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/audit_repository"
+	"go-libraryschool/repository/book_copy_repository"
+	"go-libraryschool/repository/circulation_policy_repository"
+	"go-libraryschool/repository/hold_repository"
 	"net/http"
 	"time"
 )
@@ -29,9 +33,20 @@ func (repository *ManagementBookRepository) AddBookRepository(r *http.Request, b
 	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
 	defer cancel()
 
-	query := "INSERT INTO books (title, author, cover, genre_id, isbn, publication_year, quantity) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to begin transaction")
 
-	stmt, err := repository.db.PrepareContext(ctx, query)
+		return helpers.ApiResponse{Message: "Failed to begin transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO books (title, author, cover, genre_id, isbn, publication_year, quantity, available) VALUES (?, ?, ?, ?, ?, ?, 0, 0)"
+
+	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
 			"error": err,
@@ -47,7 +62,7 @@ func (repository *ManagementBookRepository) AddBookRepository(r *http.Request, b
 	}
 	defer stmt.Close()
 
-	result, err := stmt.ExecContext(ctx, book.Title, book.Author, book.Cover, book.GenreID, book.Isbn, book.PublicationYear, book.Quantity)
+	result, err := stmt.ExecContext(ctx, book.Title, book.Author, book.Cover, book.GenreID, book.Isbn, book.PublicationYear)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
 			"error": err,
@@ -62,22 +77,53 @@ func (repository *ManagementBookRepository) AddBookRepository(r *http.Request, b
 		return resultExec, http.StatusInternalServerError, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	bookID, err := result.LastInsertId()
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
 			"error": err,
 			"data":  r.Body,
-		}).Error("Failed to get rows affected")
+		}).Error("Failed to get last insert id")
 
-		resultRowsAffected := helpers.ApiResponse{
-			Message: "Failed to get rows affected",
+		resultLastInsert := helpers.ApiResponse{
+			Message: "Failed to get last insert id",
 			Data:    nil,
 		}
 
-		return resultRowsAffected, http.StatusInternalServerError, err
+		return resultLastInsert, http.StatusInternalServerError, err
 	}
+	book.BookID = int(bookID)
 
-	repository.logLogrus.Info("Rows affected: ", rowsAffected)
+	err = book_copy_repository.InsertGeneratedCopies(ctx, tx, book.BookID, book.Quantity)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to register book copies")
+
+		return helpers.ApiResponse{Message: "Failed to register book copies", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	err = book_copy_repository.SyncBookStock(ctx, tx, book.BookID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to update book stock")
+
+		return helpers.ApiResponse{Message: "Failed to update book stock", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to commit transaction")
+
+		return helpers.ApiResponse{Message: "Failed to commit transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	repository.logLogrus.Info("Book added with copies: ", book.Quantity)
 
 	queryGenre := "SELECT genre_name FROM genres WHERE genre_id = ?"
 	stmt, err = repository.db.PrepareContext(ctx, queryGenre)
@@ -111,6 +157,7 @@ func (repository *ManagementBookRepository) AddBookRepository(r *http.Request, b
 	}
 
 	data := map[string]any{
+		"book_id":         book.BookID,
 		"title":           book.Title,
 		"author":          book.Author,
 		"cover":           book.Cover,
@@ -118,6 +165,7 @@ func (repository *ManagementBookRepository) AddBookRepository(r *http.Request, b
 		"isbn":            book.Isbn,
 		"publicationYear": book.PublicationYear,
 		"quantity":        book.Quantity,
+		"available":       book.Quantity,
 	}
 
 	resultLate := helpers.ApiResponse{
@@ -167,7 +215,7 @@ func (repository *ManagementBookRepository) GetBooksDBMysql() (helpers.ApiRespon
 	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
 	defer cancel()
 
-	query := "SELECT book_id, title, author, cover, genre_id, isbn, publication_year, quantity FROM books"
+	query := "SELECT book_id, title, author, cover, genre_id, isbn, publication_year, quantity, available FROM books"
 	rows, err := repository.db.QueryContext(ctx, query)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{"error": err}).Error("Failed to execute query books")
@@ -177,7 +225,7 @@ func (repository *ManagementBookRepository) GetBooksDBMysql() (helpers.ApiRespon
 
 	for rows.Next() {
 		var book identity.Book
-		err = rows.Scan(&book.BookID, &book.Title, &book.Author, &book.Cover, &book.GenreID, &book.Isbn, &book.PublicationYear, &book.Quantity)
+		err = rows.Scan(&book.BookID, &book.Title, &book.Author, &book.Cover, &book.GenreID, &book.Isbn, &book.PublicationYear, &book.Quantity, &book.Available)
 		if err != nil {
 			repository.logLogrus.WithFields(logrus.Fields{"error": err}).Error("Failed to scan book row")
 			return helpers.ApiResponse{Message: "Failed to scan book row", Data: nil}, http.StatusInternalServerError, err
@@ -224,7 +272,7 @@ func (repository *ManagementBookRepository) GetBookRepository(r *http.Request, b
 
 	var book identity.Book
 
-	query := "SELECT book_id, title, description ,author, cover, genre_id, isbn, publication_year, quantity FROM books WHERE book_id = ?"
+	query := "SELECT book_id, title, description ,author, cover, genre_id, isbn, publication_year, quantity, available FROM books WHERE book_id = ?"
 	stmt, err := repository.db.PrepareContext(ctx, query)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
@@ -240,7 +288,7 @@ func (repository *ManagementBookRepository) GetBookRepository(r *http.Request, b
 	}
 	defer stmt.Close()
 
-	err = stmt.QueryRowContext(ctx, bookID).Scan(&book.BookID, &book.Title, &book.Description, &book.Author, &book.Cover, &book.GenreID, &book.Isbn, &book.PublicationYear, &book.Quantity)
+	err = stmt.QueryRowContext(ctx, bookID).Scan(&book.BookID, &book.Title, &book.Description, &book.Author, &book.Cover, &book.GenreID, &book.Isbn, &book.PublicationYear, &book.Quantity, &book.Available)
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
 			repository.logLogrus.WithFields(logrus.Fields{
@@ -297,15 +345,31 @@ func (repository *ManagementBookRepository) GetBookRepository(r *http.Request, b
 		return result, http.StatusInternalServerError, err
 	}
 
+	availability, err := book_copy_repository.CopyAvailability(ctx, repository.db, book.BookID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to get copies")
+
+		result := helpers.ApiResponse{
+			Message: "Failed to get copies",
+			Data:    nil,
+		}
+		return result, http.StatusInternalServerError, err
+	}
+
 	bookResp := struct {
-		Title           string `json:"title"`
-		Description     string `json:"description"`
-		Author          string `json:"author"`
-		Cover           string `json:"cover"`
-		Genre           string `json:"genre"`
-		Isbn            string `json:"isbn"`
-		PublicationYear int    `json:"publication_year"`
-		Quantity        int    `json:"quantity"`
+		Title           string                        `json:"title"`
+		Description     string                        `json:"description"`
+		Author          string                        `json:"author"`
+		Cover           string                        `json:"cover"`
+		Genre           string                        `json:"genre"`
+		Isbn            string                        `json:"isbn"`
+		PublicationYear int                           `json:"publication_year"`
+		Quantity        int                           `json:"quantity"`
+		Available       int                           `json:"available"`
+		Copies          identity.BookCopyAvailability `json:"copies"`
 	}{
 		Title:           book.Title,
 		Description:     book.Description,
@@ -314,6 +378,8 @@ func (repository *ManagementBookRepository) GetBookRepository(r *http.Request, b
 		Isbn:            book.Isbn,
 		PublicationYear: book.PublicationYear,
 		Quantity:        book.Quantity,
+		Available:       book.Available,
+		Copies:          availability,
 	}
 
 	resultLate := helpers.ApiResponse{
@@ -323,6 +389,18 @@ func (repository *ManagementBookRepository) GetBookRepository(r *http.Request, b
 	return resultLate, http.StatusOK, nil
 }
 
+// bookSnapshot loads the stored state of a book for the audit log.
+func bookSnapshot(ctx context2.Context, q circulation_policy_repository.Queryer, bookID int) (identity.Book, error) {
+	var book identity.Book
+
+	query := `SELECT b.book_id, b.title, b.description, b.author, b.cover, b.isbn, b.publication_year, b.genre_id, g.genre_name, b.quantity, b.available
+			  FROM books b JOIN genres g ON g.genre_id = b.genre_id
+			  WHERE b.book_id = ?`
+	err := q.QueryRowContext(ctx, query, bookID).Scan(&book.BookID, &book.Title, &book.Description, &book.Author, &book.Cover,
+		&book.Isbn, &book.PublicationYear, &book.GenreID, &book.Genre, &book.Quantity, &book.Available)
+	return book, err
+}
+
 func (repository *ManagementBookRepository) DeleteBookRepository(r *http.Request, bookID int) (helpers.ApiResponse, int, error) {
 	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
 	defer cancel()
@@ -370,22 +448,29 @@ func (repository *ManagementBookRepository) DeleteBookRepository(r *http.Request
 		return resultQuery, http.StatusInternalServerError, err
 	}
 
-	queryDelete := "DELETE FROM books WHERE book_id = ?"
-	stmt, err := repository.db.PrepareContext(ctx, queryDelete)
+	tx, err := repository.db.BeginTx(ctx, nil)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
 			"error": err,
 			"data":  r.Body,
-		}).Error("Failed to prepare statement")
+		}).Error("Failed to begin transaction")
 
-		resultPrepareDelete := helpers.ApiResponse{
-			Message: "Failed to Prepare Context Delete",
-			Data:    nil,
-		}
-		return resultPrepareDelete, http.StatusInternalServerError, err
+		return helpers.ApiResponse{Message: "Failed to begin transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	before, err := bookSnapshot(ctx, tx, bookID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to find book")
+
+		return helpers.ApiResponse{Message: "Failed to find book", Data: nil}, http.StatusInternalServerError, err
 	}
 
-	result, err := stmt.ExecContext(ctx, bookID)
+	queryDelete := "DELETE FROM books WHERE book_id = ?"
+	result, err := tx.ExecContext(ctx, queryDelete, bookID)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
 			"error": err,
@@ -413,6 +498,31 @@ func (repository *ManagementBookRepository) DeleteBookRepository(r *http.Request
 		return resultRowAffected, http.StatusInternalServerError, err
 	}
 
+	err = audit_repository.Record(ctx, tx, r, audit_repository.Event{
+		Action:     identity.AuditActionBookDelete,
+		EntityType: identity.AuditEntityBook,
+		EntityID:   bookID,
+		Before:     before,
+	})
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to record audit entry")
+
+		return helpers.ApiResponse{Message: "Failed to record audit entry", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to commit transaction")
+
+		return helpers.ApiResponse{Message: "Failed to commit transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+
 	repository.logLogrus.Info("Rows affected: ", rowsAffected)
 
 	resultLate := helpers.ApiResponse{
@@ -427,10 +537,25 @@ func (repository *ManagementBookRepository) UpdateBookRepository(r *http.Request
 	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
 	defer cancel()
 
-	var existingBook request_models.BookUpdate
-	query := "SELECT book_id, title, author, cover, genre_id, quantity FROM books WHERE book_id = ?"
-	err := repository.db.QueryRowContext(ctx, query, book.BookID).
-		Scan(&existingBook.BookID, &existingBook.Title, &existingBook.Author, &existingBook.Cover, &existingBook.GenreID, &existingBook.Quantity)
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to begin transaction")
+
+		return helpers.ApiResponse{Message: "Failed to begin transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	var (
+		existingBook request_models.BookUpdate
+		quantity     int
+		available    int
+	)
+	query := "SELECT book_id, title, author, cover, genre_id, quantity, available FROM books WHERE book_id = ? FOR UPDATE"
+	err = tx.QueryRowContext(ctx, query, book.BookID).
+		Scan(&existingBook.BookID, &existingBook.Title, &existingBook.Author, &existingBook.Cover, &existingBook.GenreID, &quantity, &available)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
 			"error": err,
@@ -459,15 +584,22 @@ func (repository *ManagementBookRepository) UpdateBookRepository(r *http.Request
 	if book.GenreID == 0 {
 		book.GenreID = existingBook.GenreID
 	}
-	if book.Quantity == 0 {
-		book.Quantity = existingBook.Quantity
-	}
 
 	updateQuery := `
 		UPDATE books 
-		SET title = ?, description = ?, author = ?, cover = ?, genre_id = ?, quantity = ?, updated_at = ?
+		SET title = ?, description = ?, author = ?, cover = ?, genre_id = ?, updated_at = ?
 		WHERE book_id = ?`
-	stmt, err := repository.db.PrepareContext(ctx, updateQuery)
+	before, err := bookSnapshot(ctx, tx, book.BookID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to select existing book")
+
+		return helpers.ApiResponse{Message: "Failed to find book", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, updateQuery)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
 			"error": err,
@@ -479,7 +611,7 @@ func (repository *ManagementBookRepository) UpdateBookRepository(r *http.Request
 	defer stmt.Close()
 
 	now := time.Now().Format("2006-01-02 15:04:05")
-	result, err := stmt.ExecContext(ctx, book.Title, book.Description, book.Author, book.Cover, book.GenreID, book.Quantity, now, book.BookID)
+	result, err := stmt.ExecContext(ctx, book.Title, book.Description, book.Author, book.Cover, book.GenreID, now, book.BookID)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
 			"error": err,
@@ -503,6 +635,35 @@ func (repository *ManagementBookRepository) UpdateBookRepository(r *http.Request
 		return helpers.ApiResponse{Message: "No rows updated", Data: nil}, http.StatusNotFound, nil
 	}
 
+	after, err := bookSnapshot(ctx, tx, book.BookID)
+	if err == nil {
+		err = audit_repository.Record(ctx, tx, r, audit_repository.Event{
+			Action:     identity.AuditActionBookUpdate,
+			EntityType: identity.AuditEntityBook,
+			EntityID:   book.BookID,
+			Before:     before,
+			After:      after,
+		})
+	}
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to record audit entry")
+
+		return helpers.ApiResponse{Message: "Failed to record audit entry", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to commit transaction")
+
+		return helpers.ApiResponse{Message: "Failed to commit transaction", Data: nil}, http.StatusInternalServerError, err
+	}
+
 	repository.logLogrus.WithField("rowsAffected", rowsAffected).Info("Successfully updated book")
 
 	updateResp := struct {
@@ -512,6 +673,7 @@ func (repository *ManagementBookRepository) UpdateBookRepository(r *http.Request
 		Cover     string `json:"cover"`
 		GenreID   int    `json:"genre_id"`
 		Quantity  int    `json:"quantity"`
+		Available int    `json:"available"`
 		UpdatedAt string `json:"updated_at"`
 	}{
 		BookID:    book.BookID,
@@ -519,7 +681,8 @@ func (repository *ManagementBookRepository) UpdateBookRepository(r *http.Request
 		Author:    book.Author,
 		Cover:     book.Cover,
 		GenreID:   book.GenreID,
-		Quantity:  book.Quantity,
+		Quantity:  quantity,
+		Available: available,
 		UpdatedAt: now,
 	}
 
@@ -530,7 +693,7 @@ func (repository *ManagementBookRepository) SearchBooksRepository(r *http.Reques
 	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
 	defer cancel()
 
-	query := `SELECT book_id, title, description,author, cover, genre_id, isbn, publication_year, quantity FROM books WHERE title LIKE ?`
+	query := `SELECT book_id, title, description, author, cover, genre_id, isbn, publication_year, quantity, available FROM books WHERE title LIKE ?`
 	stmt, err := repository.db.PrepareContext(ctx, query)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
@@ -557,7 +720,7 @@ func (repository *ManagementBookRepository) SearchBooksRepository(r *http.Reques
 
 	for rows.Next() {
 		var book identity.Book
-		err = rows.Scan(&book.BookID, &book.Title, &book.Author, &book.Cover, &book.GenreID, &book.Isbn, &book.PublicationYear, &book.Quantity)
+		err = rows.Scan(&book.BookID, &book.Title, &book.Description, &book.Author, &book.Cover, &book.GenreID, &book.Isbn, &book.PublicationYear, &book.Quantity, &book.Available)
 		if err != nil {
 			repository.logLogrus.WithFields(logrus.Fields{
 				"error": err,
@@ -599,120 +762,266 @@ func (repository *ManagementBookRepository) SearchBooksRepository(r *http.Reques
 }
 
 func (repository *ManagementBookRepository) BorrowedBookRepository(r *http.Request, book request_models.BookBorrowedRequest) (helpers.ApiResponse, int, error) {
+	var (
+		available int
+		copyBook  identity.BookCopy
+	)
+
 	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
 	defer cancel()
 
-	query := "INSERT INTO borrowed_books (book_id, user_id, borrow_date, return_date) VALUES (?, ?, ?, ?)"
-	stmt, err := repository.db.PrepareContext(ctx, query)
+	tx, err := repository.db.BeginTx(ctx, nil)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
 			"error": err,
 			"data":  r.Body,
-		}).Error("Failed to prepare statement")
+		}).Error("Failed to begin transaction")
 
-		return helpers.ApiResponse{Message: "Failed to prepare statement", Data: nil}, http.StatusInternalServerError, err
+		return helpers.ApiResponse{Message: "Failed to begin transaction", Data: nil}, http.StatusInternalServerError, err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.ExecContext(ctx, book.BookID, book.UserID, book.BorrowedDate, book.ReturnDate)
+	queryStock := "SELECT available FROM books WHERE book_id = ? FOR UPDATE"
+	err = tx.QueryRowContext(ctx, queryStock, book.BookID).Scan(&available)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("Book does not exist")
+
+			return helpers.ApiResponse{Message: "Book does not exist", Data: nil}, http.StatusNotFound, err
+		}
+
 		repository.logLogrus.WithFields(logrus.Fields{
 			"error": err,
 			"data":  r.Body,
-		}).Error("Failed to execute statement")
+		}).Error("Failed to execute query")
 
-		return helpers.ApiResponse{Message: "Failed to execute statement", Data: nil}, http.StatusInternalServerError, err
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
 	}
 
-	return helpers.ApiResponse{Message: "Successfully borrowed book", Data: book}, http.StatusOK, nil
-}
+	// Locking the borrower serialises their concurrent checkouts so the loan limit holds.
+	var borrowerID int
+	err = tx.QueryRowContext(ctx, "SELECT id FROM users WHERE id = ? FOR UPDATE", book.UserID).Scan(&borrowerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("User does not exist")
 
-func (repository *ManagementBookRepository) BookBorrowingDataRepository() (helpers.ApiResponse, int, error) {
-	var (
-		borrowDateStr string
-		returnDateStr string
-		timeNowStr    = time.Now().Format("2006-01-02")
-		layoutDate    = "2006-01-02"
-	)
-	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
-	defer cancel()
+			return helpers.ApiResponse{Message: "User does not exist", Data: nil}, http.StatusNotFound, err
+		}
 
-	query := "SELECT borrow_id, book_id, user_id, borrow_date, return_date FROM borrowed_books"
-	stmt, err := repository.db.PrepareContext(ctx, query)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	rolePolicy, err := circulation_policy_repository.RolePolicyFor(ctx, tx, book.UserID)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
-			"error":   err,
-			"message": "Failed to prepare statement",
-		}).Error("Failed to prepare statement")
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to resolve circulation policy")
 
-		return helpers.ApiResponse{Message: "Failed to prepare statement", Data: nil}, http.StatusInternalServerError, err
+		return helpers.ApiResponse{Message: "Failed to resolve circulation policy", Data: nil}, http.StatusInternalServerError, err
 	}
-	defer stmt.Close()
 
-	rows, err := stmt.QueryContext(ctx)
+	standing, err := borrowerStanding(ctx, tx, book.UserID, rolePolicy.Currency)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
-			"error":   err,
-			"message": "Failed to execute query",
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to check borrowing eligibility")
+
+		return helpers.ApiResponse{Message: "Failed to check borrowing eligibility", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if refusals := helpers.CheckBorrowingEligibility(rolePolicy, standing); len(refusals) > 0 {
+		err = fmt.Errorf("user %d is not eligible to borrow: %d reasons", book.UserID, len(refusals))
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("User is not eligible to borrow")
+
+		data := struct {
+			Standing identity.BorrowerStanding `json:"standing"`
+			Reasons  []identity.BorrowRefusal  `json:"reasons"`
+		}{
+			Standing: standing,
+			Reasons:  refusals,
+		}
+
+		return helpers.ApiResponse{Message: "User is not eligible to borrow", Data: data}, http.StatusForbidden, err
+	}
+
+	// A patron whose hold is ready picks up the copy set aside for them.
+	claimedCopyID, claimed, err := hold_repository.ClaimReadyHold(ctx, tx, book.BookID, book.UserID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to check ready holds")
+
+		return helpers.ApiResponse{Message: "Failed to check ready holds", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if !claimed && available <= 0 {
+		err = errors.New("no copies available")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("No copies available")
+
+		return helpers.ApiResponse{Message: "No copies of this book are available", Data: nil}, http.StatusConflict, err
+	}
+
+	queryCopy := "SELECT copy_id, barcode, status FROM book_copies WHERE book_id = ? AND status = ? ORDER BY copy_id LIMIT 1 FOR UPDATE"
+	args := []any{book.BookID, identity.CopyStatusAvailable}
+	if claimed {
+		queryCopy = "SELECT copy_id, barcode, status FROM book_copies WHERE book_id = ? AND copy_id = ? FOR UPDATE"
+		args = []any{book.BookID, claimedCopyID}
+	} else if book.Barcode != "" {
+		queryCopy = "SELECT copy_id, barcode, status FROM book_copies WHERE book_id = ? AND barcode = ? FOR UPDATE"
+		args = []any{book.BookID, book.Barcode}
+	}
+
+	err = tx.QueryRowContext(ctx, queryCopy, args...).Scan(&copyBook.CopyID, &copyBook.Barcode, &copyBook.Status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.Body,
+			}).Error("No copy available")
+
+			if book.Barcode != "" {
+				return helpers.ApiResponse{Message: "Copy does not exist", Data: nil}, http.StatusNotFound, err
+			}
+			return helpers.ApiResponse{Message: "No copies of this book are available", Data: nil}, http.StatusConflict, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
 		}).Error("Failed to execute query")
 
 		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
 	}
-	defer rows.Close()
 
-	var borrowed []identity.BookBorrowingData
+	if !claimed && copyBook.Status != identity.CopyStatusAvailable {
+		err = fmt.Errorf("copy %s is %s", copyBook.Barcode, copyBook.Status)
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Copy not available")
 
-	for rows.Next() {
-		var borrow identity.BookBorrowingData
-		err = rows.Scan(&borrow.Borrowed.BorrowedID, &borrow.Borrowed.BookID, &borrow.Borrowed.UserID, &borrow.Borrowed.BorrowDate, &borrow.Borrowed.ReturnDate)
+		return helpers.ApiResponse{Message: "This copy is not available", Data: nil}, http.StatusConflict, err
+	}
+
+	queryLend := "UPDATE book_copies SET status = ?, updated_at = ? WHERE copy_id = ?"
+	_, err = tx.ExecContext(ctx, queryLend, identity.CopyStatusOnLoan, time.Now().Format(layoutDateTime), copyBook.CopyID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to update copy status")
+
+		return helpers.ApiResponse{Message: "Failed to update copy status", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	err = book_copy_repository.SyncBookStock(ctx, tx, book.BookID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to update available copies")
+
+		return helpers.ApiResponse{Message: "Failed to update available copies", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	// The loan period of the circulation policy applies unless staff set the return date explicitly, which
+	// the controller only lets callers with loan:manage do.
+	if book.BorrowedDate == "" {
+		book.BorrowedDate = time.Now().Format(layoutDate)
+	}
+	borrowDate, err := time.Parse(layoutDate, book.BorrowedDate)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to parse date")
+
+		return helpers.ApiResponse{Message: "Borrowed date must be formatted as YYYY-MM-DD", Data: nil}, http.StatusBadRequest, err
+	}
+
+	if book.ReturnDate == "" {
+		policy, err := circulation_policy_repository.PolicyFor(ctx, tx, book.UserID, book.BookID)
 		if err != nil {
 			repository.logLogrus.WithFields(logrus.Fields{
-				"error":   err,
-				"message": "Failed to scan row",
-			}).Error("Failed to scan row")
+				"error": err,
+				"data":  r.Body,
+			}).Error("Failed to resolve circulation policy")
 
-			return helpers.ApiResponse{Message: "Failed to scan row", Data: nil}, http.StatusInternalServerError, err
+			return helpers.ApiResponse{Message: "Failed to resolve circulation policy", Data: nil}, http.StatusInternalServerError, err
 		}
 
-		borrowDateStr = borrow.Borrowed.BorrowDate
-		returnDateStr = borrow.Borrowed.ReturnDate
-
-		returnDate, err := time.Parse(layoutDate, returnDateStr)
+		book.ReturnDate = helpers.LoanDueDate(policy, borrowDate).Format(layoutDate)
+	} else {
+		returnDate, err := time.Parse(layoutDate, book.ReturnDate)
 		if err != nil {
 			repository.logLogrus.WithFields(logrus.Fields{
-				"error":   err,
-				"message": "Failed to parse date",
+				"error": err,
+				"data":  r.Body,
 			}).Error("Failed to parse date")
 
-			return helpers.ApiResponse{Message: "Failed to parse date", Data: nil}, http.StatusInternalServerError, err
+			return helpers.ApiResponse{Message: "Return date must be formatted as YYYY-MM-DD", Data: nil}, http.StatusBadRequest, err
 		}
 
-		timeNow, err := time.Parse(layoutDate, timeNowStr)
-		if err != nil {
+		if returnDate.Before(borrowDate) {
+			err = errors.New("return date before borrowed date")
 			repository.logLogrus.WithFields(logrus.Fields{
-				"error":   err,
-				"message": "Failed to parse time",
-			}).Error("Failed to parse time")
+				"error": err,
+				"data":  r.Body,
+			}).Error("Invalid loan dates")
 
-			return helpers.ApiResponse{Message: "Failed to parse time", Data: nil}, http.StatusInternalServerError, err
+			return helpers.ApiResponse{Message: "Return date must not be before the borrowed date", Data: nil}, http.StatusBadRequest, err
 		}
+	}
+
+	query := "INSERT INTO borrowed_books (book_id, copy_id, user_id, checked_out_by, borrow_date, return_date) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err = tx.ExecContext(ctx, query, book.BookID, copyBook.CopyID, book.UserID, book.CheckedOutBy, book.BorrowedDate, book.ReturnDate)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to execute statement")
 
-		if timeNow.After(returnDate) {
-			difference := timeNow.Sub(returnDate).Hours() / 24
-			fineMoneyInt := int(difference) * 20000
+		return helpers.ApiResponse{Message: "Failed to execute statement", Data: nil}, http.StatusInternalServerError, err
+	}
 
-			fineMoneyFloat := float64(fineMoneyInt)
-			fineMoney := helpers.NewFormatterMoney().FormaterCurrency(fineMoneyFloat, "IDR")
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.Body,
+		}).Error("Failed to commit transaction")
 
-			borrow.DueDateResponse.AmountOfFine = fineMoney
-			borrow.DueDateResponse.Difference = int(difference)
-			borrow.Borrowed.BorrowDate = borrowDateStr
-		}
+		return helpers.ApiResponse{Message: "Failed to commit transaction", Data: nil}, http.StatusInternalServerError, err
+	}
 
-		borrowed = append(borrowed, borrow)
+	err = repository.rdb.Del(ctx, "Books").Err()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{"error": err}).Warn("Failed to invalidate books cache")
 	}
 
-	return helpers.ApiResponse{Message: "Success", Data: borrowed}, http.StatusOK, nil
+	book.Barcode = copyBook.Barcode
+
+	return helpers.ApiResponse{Message: "Successfully borrowed book", Data: book}, http.StatusOK, nil
 }
 
 func (repository *ManagementBookRepository) GetBooksGenreRepository(genreID int) (helpers.ApiResponse, int, error) {
@@ -721,7 +1030,7 @@ func (repository *ManagementBookRepository) GetBooksGenreRepository(genreID int)
 	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
 	defer cancel()
 
-	query := "SELECT book_id, title, description,author, cover, genre_id, isbn, publication_year, quantity FROM books WHERE genre_id = ?"
+	query := "SELECT book_id, title, description, author, cover, genre_id, isbn, publication_year, quantity, available FROM books WHERE genre_id = ?"
 	stmt, err := repository.db.PrepareContext(ctx, query)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
@@ -746,7 +1055,7 @@ func (repository *ManagementBookRepository) GetBooksGenreRepository(genreID int)
 
 	for rows.Next() {
 		var book identity.Book
-		err = rows.Scan(&book.BookID, &book.Title, &book.Author, &book.Cover, &book.GenreID, &book.Isbn, &book.PublicationYear, &book.Quantity)
+		err = rows.Scan(&book.BookID, &book.Title, &book.Description, &book.Author, &book.Cover, &book.GenreID, &book.Isbn, &book.PublicationYear, &book.Quantity, &book.Available)
 		if err != nil {
 			repository.logLogrus.WithFields(logrus.Fields{
 				"error":   err,
@@ -859,7 +1168,7 @@ func (repository *ManagementBookRepository) AddFavoriteBookRepository(userID, bo
 		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
 	}
 
-	queryBook := "SELECT book_id, title, description,author, cover, genre_id, isbn, publication_year, quantity FROM books WHERE book_id = ?"
+	queryBook := "SELECT book_id, title, description, author, cover, genre_id, isbn, publication_year, quantity, available FROM books WHERE book_id = ?"
 	stmt, err = repository.db.PrepareContext(ctx, queryBook)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
@@ -871,7 +1180,7 @@ func (repository *ManagementBookRepository) AddFavoriteBookRepository(userID, bo
 	}
 	defer stmt.Close()
 
-	err = stmt.QueryRowContext(ctx, bookID).Scan(&book.BookID, &book.Title, &book.Author, &book.Cover, &book.GenreID, &book.Isbn, &book.PublicationYear, &book.Quantity)
+	err = stmt.QueryRowContext(ctx, bookID).Scan(&book.BookID, &book.Title, &book.Description, &book.Author, &book.Cover, &book.GenreID, &book.Isbn, &book.PublicationYear, &book.Quantity, &book.Available)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			repository.logLogrus.WithFields(logrus.Fields{
@@ -1037,8 +1346,8 @@ func (repository *ManagementBookRepository) GetFavoriteBooksDBMysql(userID int)
 			return helpers.ApiResponse{Message: "Failed to scan rows", Data: nil}, http.StatusInternalServerError, err
 		}
 
-		queryBooks := "SELECT title, author, description,cover, genre_id, isbn, publication_year, quantity FROM books WHERE book_id = ?"
-		err = repository.db.QueryRowContext(ctx, queryBooks, book.BookID).Scan(&book.Title, &book.Author, &book.Cover, &book.GenreID, &book.Isbn, &book.PublicationYear, &book.Quantity)
+		queryBooks := "SELECT title, author, description, cover, genre_id, isbn, publication_year, quantity, available FROM books WHERE book_id = ?"
+		err = repository.db.QueryRowContext(ctx, queryBooks, book.BookID).Scan(&book.Title, &book.Author, &book.Description, &book.Cover, &book.GenreID, &book.Isbn, &book.PublicationYear, &book.Quantity, &book.Available)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				repository.logLogrus.WithFields(logrus.Fields{
@@ -4,8 +4,11 @@ import (
 	context2 "context"
 	"database/sql"
 	"errors"
+	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"go-libraryschool/helpers"
+	"go-libraryschool/repository/mail_outbox_repository"
+	"go-libraryschool/repository/password_reset_repository"
 	"net/http"
 	"time"
 )
@@ -13,12 +16,19 @@ import (
 type OtpEmailRepository struct {
 	Db        *sql.DB
 	logLogrus *logrus.Logger
+	rdb       *redis.Client
+
+	outboxRepo *mail_outbox_repository.MailOutboxRepository
+	resetRepo  *password_reset_repository.PasswordResetRepository
 }
 
-func NewOtpEmailRepository(db *sql.DB, logLogrus *logrus.Logger) *OtpEmailRepository {
+func NewOtpEmailRepository(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *OtpEmailRepository {
 	return &OtpEmailRepository{
-		Db:        db,
-		logLogrus: logLogrus,
+		Db:         db,
+		logLogrus:  logLogrus,
+		rdb:        rdb,
+		outboxRepo: mail_outbox_repository.NewMailOutboxRepository(db, logLogrus),
+		resetRepo:  password_reset_repository.NewPasswordResetRepository(db, logLogrus, rdb),
 	}
 }
 
@@ -0,0 +1,424 @@
+package otp_email_repository
+
+import (
+	context2 "context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/mailer"
+	"go-libraryschool/models/request_models"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+const (
+	// OtpTTL is how long a sent OTP can be verified.
+	OtpTTL = 2 * time.Minute
+
+	// MaxOtpAttempts is how many times an OTP can be checked. The last wrong guess invalidates it.
+	MaxOtpAttempts = 5
+
+	// otpEmailCooldown is the wait between two codes sent to the same email, whatever their purpose.
+	otpEmailCooldown = time.Minute
+
+	// otpIPLimit codes can be requested from one IP per otpIPWindow.
+	otpIPLimit  = 10
+	otpIPWindow = 15 * time.Minute
+
+	// resetRequestedMessage and verificationRequestedMessage answer every request for their purpose,
+	// so the endpoints do not tell which emails are registered or verified.
+	resetRequestedMessage        = "If the email is registered, a reset code has been sent to it"
+	verificationRequestedMessage = "If the email belongs to an unverified account, a verification code has been sent to it"
+)
+
+func otpKey(purpose, email string) string {
+	if purpose == request_models.OtpPurposeRegister {
+		return fmt.Sprintf("Otp %s: ", email)
+	}
+	return fmt.Sprintf("Otp %s %s: ", purpose, email)
+}
+
+func otpAttemptsKey(purpose, email string) string {
+	return fmt.Sprintf("otp-attempts:%s:%s", purpose, email)
+}
+
+func otpEmailCooldownKey(email string) string {
+	return fmt.Sprintf("otp-cooldown:email:%s", email)
+}
+
+func otpIPCooldownKey(ip string) string {
+	return fmt.Sprintf("otp-cooldown:ip:%s", ip)
+}
+
+func generateOtp() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil // OTP 6 digit
+}
+
+// sendCooldown reserves a send for the client IP and the email. It returns how long to wait when either
+// already used up its budget.
+func (repository *OtpEmailRepository) sendCooldown(ctx context2.Context, email, clientIP string) (time.Duration, error) {
+	ipKey := otpIPCooldownKey(clientIP)
+
+	sends, err := repository.rdb.Incr(ctx, ipKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if sends == 1 {
+		err = repository.rdb.Expire(ctx, ipKey, otpIPWindow).Err()
+		if err != nil {
+			return 0, err
+		}
+	}
+	if sends > otpIPLimit {
+		return repository.rdb.TTL(ctx, ipKey).Result()
+	}
+
+	emailKey := otpEmailCooldownKey(email)
+
+	reserved, err := repository.rdb.SetNX(ctx, emailKey, clientIP, otpEmailCooldown).Result()
+	if err != nil {
+		return 0, err
+	}
+	if !reserved {
+		return repository.rdb.TTL(ctx, emailKey).Result()
+	}
+
+	return 0, nil
+}
+
+func otpPurpose(purpose string) string {
+	if purpose == "" {
+		return request_models.OtpPurposeRegister
+	}
+	return purpose
+}
+
+// SendOtpRepository generates an OTP for the purpose of the request, stores it and mails it to the email.
+// The language of the request is used for emails that do not belong to an account yet.
+// Sends are throttled per email and per client IP.
+func (repository *OtpEmailRepository) SendOtpRepository(request request_models.RequestOtpEmail, clientIP string) (helpers.ApiResponse, int, error) {
+	purpose := otpPurpose(request.Purpose)
+	minutes := int(OtpTTL / time.Minute)
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	wait, err := repository.sendCooldown(ctx, request.Email, clientIP)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to check otp cooldown",
+		}).Error("Failed to check otp cooldown")
+
+		return helpers.ApiResponse{Message: "Failed to check otp cooldown"}, http.StatusInternalServerError, err
+	}
+	if wait > 0 {
+		err = errors.New("otp requested too often")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"email": request.Email,
+			"ip":    clientIP,
+		}).Warn("Otp requested too often")
+
+		return helpers.ApiResponse{Message: "Too many code requests, please wait before asking for a new one", Data: helpers.NewRetryAfter(wait)}, http.StatusTooManyRequests, err
+	}
+
+	otp, err := generateOtp()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to generate otp",
+		}).Error("Failed to generate otp")
+
+		return helpers.ApiResponse{Message: "Failed to generate otp"}, http.StatusInternalServerError, err
+	}
+
+	var (
+		template string
+		language string
+		data     any
+		reply    string
+	)
+
+	switch purpose {
+	case request_models.OtpPurposeRegister:
+		responseRepo, code, err := repository.VerificationEmail(request.Email)
+		if err != nil {
+			return responseRepo, code, err
+		}
+
+		template, language, data = mailer.TemplateOtp, request.Language, mailer.OtpData{Otp: otp, Minutes: minutes}
+	case request_models.OtpPurposeResetPassword, request_models.OtpPurposeVerifyEmail:
+		account, ok, err := repository.resetRepo.FindAccount(ctx, request.Email)
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Failed to find account",
+			}).Error("Failed to find account")
+
+			return helpers.ApiResponse{Message: "Failed to find account"}, http.StatusInternalServerError, err
+		}
+
+		if purpose == request_models.OtpPurposeResetPassword {
+			reply = resetRequestedMessage
+			template, data = mailer.TemplatePasswordReset, mailer.PasswordResetData{Username: account.Username, Otp: otp, Minutes: minutes}
+		} else {
+			reply = verificationRequestedMessage
+			template, data = mailer.TemplateVerifyEmail, mailer.VerifyEmailData{Username: account.Username, Otp: otp, Minutes: minutes}
+
+			// Verified accounts have nothing to verify, answer as if the email was unknown.
+			ok = ok && !account.EmailVerified
+		}
+
+		if !ok {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"email":   request.Email,
+				"purpose": purpose,
+			}).Warn("Otp requested for an email without a matching account")
+
+			return helpers.ApiResponse{Message: reply}, http.StatusOK, nil
+		}
+		language = account.Language
+	default:
+		err := errors.New("unknown otp purpose")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"purpose": purpose,
+		}).Error("Unknown otp purpose")
+
+		return helpers.ApiResponse{Message: "Purpose must be register, reset_password or verify_email"}, http.StatusBadRequest, err
+	}
+
+	otpJson, err := json.Marshal(otp)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to marshal otp email",
+		}).Error("Failed to marshal otp email")
+
+		return helpers.ApiResponse{Message: "Failed to marshal otp email"}, http.StatusInternalServerError, err
+	}
+
+	// A new code comes with a fresh attempt budget.
+	_, err = repository.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, otpKey(purpose, request.Email), otpJson, OtpTTL)
+		pipe.Del(ctx, otpAttemptsKey(purpose, request.Email))
+		return nil
+	})
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to set otp store",
+		}).Error("Failed to set otp store")
+
+		return helpers.ApiResponse{Message: "Failed to set otp store"}, http.StatusInternalServerError, err
+	}
+
+	responseRepo, code, err := repository.sendEmail(request.Email, template, language, data)
+	if err != nil || reply == "" {
+		return responseRepo, code, err
+	}
+
+	return helpers.ApiResponse{Message: reply}, http.StatusOK, nil
+}
+
+func (repository *OtpEmailRepository) sendEmail(to, template, language string, data any) (helpers.ApiResponse, int, error) {
+	message, err := mailer.Render(to, template, language, data)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"to":    to,
+		}).Error("Failed to render email")
+
+		return helpers.ApiResponse{Message: "Failed to send email", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 30*time.Second)
+	defer cancel()
+
+	mailID, sent, err := repository.outboxRepo.SendNow(ctx, message)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"to":    to,
+		}).Error("Failed to queue email")
+
+		return helpers.ApiResponse{Message: "Failed to send email", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	if !sent {
+		return helpers.ApiResponse{Message: "Email queued for delivery", Data: map[string]int{"mail_id": mailID}}, http.StatusAccepted, nil
+	}
+
+	return helpers.ApiResponse{Message: "Successfully sent email"}, http.StatusOK, nil
+}
+
+// VerifyOtpRepository checks an OTP against the one sent for its purpose and completes that purpose:
+// a reset code yields a reset token and a verification code marks the email as verified.
+func (repository *OtpEmailRepository) VerifyOtpRepository(request request_models.VerificationOtpEmail) (helpers.ApiResponse, int, error) {
+	purpose := otpPurpose(request.Purpose)
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	// The attempt is counted before the code is compared, so parallel guesses each take a number of their own
+	// and no more than MaxOtpAttempts of them are ever compared against one code.
+	attempts, err := repository.countAttempt(ctx, purpose, request.Email)
+	if err != nil {
+		return helpers.ApiResponse{Message: "Failed to count otp attempt"}, http.StatusInternalServerError, err
+	}
+	if attempts > MaxOtpAttempts {
+		return repository.invalidateOtp(ctx, purpose, request.Email, attempts)
+	}
+
+	otpJson, err := repository.rdb.Get(ctx, otpKey(purpose, request.Email)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": fmt.Sprintf("Otp %s does not exist", request.Email),
+			}).Error("Otp does not exist")
+
+			return helpers.ApiResponse{Message: "Otp does not exist"}, http.StatusNotFound, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to get otp store",
+		}).Error("Failed to get otp store")
+
+		return helpers.ApiResponse{Message: "Failed to get otp store"}, http.StatusInternalServerError, err
+	}
+
+	var otp string
+	err = json.Unmarshal([]byte(otpJson), &otp)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to unmarshal otp store",
+		}).Error("Failed to unmarshal otp store")
+
+		return helpers.ApiResponse{Message: "Failed to unmarshal otp store"}, http.StatusInternalServerError, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(otp), []byte(request.Otp)) != 1 {
+		return repository.failedAttempt(ctx, purpose, request.Email, attempts)
+	}
+
+	// A code is consumed by the first request that deletes it, so it verifies only once.
+	deleted, err := repository.rdb.Del(ctx, otpKey(purpose, request.Email), otpAttemptsKey(purpose, request.Email)).Result()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to delete otp store",
+		}).Error("Failed to delete otp store")
+
+		return helpers.ApiResponse{Message: "Failed to delete otp store"}, http.StatusInternalServerError, err
+	}
+	if deleted == 0 {
+		err = errors.New("otp already used")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": fmt.Sprintf("Otp %s already used", request.Email),
+		}).Error("Otp does not exist")
+
+		return helpers.ApiResponse{Message: "Otp does not exist"}, http.StatusNotFound, err
+	}
+
+	if purpose == request_models.OtpPurposeRegister {
+		var result = struct {
+			Email string `json:"email"`
+			Otp   string `json:"otp"`
+		}{
+			Email: request.Email,
+			Otp:   otp,
+		}
+
+		return helpers.ApiResponse{Message: "Successfully verified otp store", Data: result}, http.StatusOK, nil
+	}
+
+	if purpose == request_models.OtpPurposeResetPassword {
+		return repository.resetRepo.IssueResetTokenRepository(request.Email)
+	}
+
+	return repository.markEmailVerified(ctx, request.Email)
+}
+
+// countAttempt counts one verification of the current code, the first one starts the window of OtpTTL.
+func (repository *OtpEmailRepository) countAttempt(ctx context2.Context, purpose, email string) (int64, error) {
+	key := otpAttemptsKey(purpose, email)
+
+	attempts, err := repository.rdb.Incr(ctx, key).Result()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to count otp attempt",
+		}).Error("Failed to count otp attempt")
+
+		return 0, err
+	}
+	if attempts == 1 {
+		repository.rdb.Expire(ctx, key, OtpTTL)
+	}
+
+	return attempts, nil
+}
+
+// invalidateOtp deletes a code whose attempts are used up.
+func (repository *OtpEmailRepository) invalidateOtp(ctx context2.Context, purpose, email string, attempts int64) (helpers.ApiResponse, int, error) {
+	repository.rdb.Del(ctx, otpKey(purpose, email), otpAttemptsKey(purpose, email))
+
+	err := errors.New("invalid otp")
+	repository.logLogrus.WithFields(logrus.Fields{
+		"error":    err,
+		"email":    email,
+		"attempts": attempts,
+	}).Warn("Otp invalidated after too many failed attempts")
+
+	return helpers.ApiResponse{Message: "Too many wrong codes, please request a new one"}, http.StatusBadRequest, err
+}
+
+// failedAttempt answers a wrong guess and invalidates the code once it was the last of MaxOtpAttempts.
+func (repository *OtpEmailRepository) failedAttempt(ctx context2.Context, purpose, email string, attempts int64) (helpers.ApiResponse, int, error) {
+	if attempts >= MaxOtpAttempts {
+		return repository.invalidateOtp(ctx, purpose, email, attempts)
+	}
+
+	err := errors.New("invalid otp")
+	repository.logLogrus.WithFields(logrus.Fields{
+		"error":    err,
+		"email":    email,
+		"attempts": attempts,
+	}).Warn("Invalid otp")
+
+	return helpers.ApiResponse{
+		Message: "Invalid otp",
+		Data:    map[string]int64{"remaining_attempts": MaxOtpAttempts - attempts},
+	}, http.StatusBadRequest, err
+}
+
+func (repository *OtpEmailRepository) markEmailVerified(ctx context2.Context, email string) (helpers.ApiResponse, int, error) {
+	query := "UPDATE users SET email_verified_at = ? WHERE email = ? AND email_verified_at IS NULL"
+	_, err := repository.Db.ExecContext(ctx, query, time.Now().Format("2006-01-02 15:04:05"), email)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to verify email",
+		}).Error("Failed to verify email")
+
+		return helpers.ApiResponse{Message: "Failed to verify email"}, http.StatusInternalServerError, err
+	}
+
+	repository.logLogrus.Infof("Email verified: %s", email)
+
+	return helpers.ApiResponse{Message: "Email verified, you can log in now", Data: map[string]string{"email": email}}, http.StatusOK, nil
+}
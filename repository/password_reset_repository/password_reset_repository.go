@@ -0,0 +1,227 @@
+package password_reset_repository
+
+import (
+	context2 "context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/refresh_token_repository"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// ResetTokenTTL is how long the token issued for a verified reset OTP can be used.
+	ResetTokenTTL = 15 * time.Minute
+
+	minPasswordLength = 6
+)
+
+// Account is the user an OTP was requested for.
+type Account struct {
+	UserID        int
+	Username      string
+	Email         string
+	Language      string
+	EmailVerified bool
+}
+
+type PasswordResetRepository struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+	rdb       *redis.Client
+}
+
+func NewPasswordResetRepository(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db, logLogrus: logLogrus, rdb: rdb}
+}
+
+func resetTokenKey(token string) string {
+	return fmt.Sprintf("reset-password:%s", token)
+}
+
+// FindAccount looks up the user owning the email. It returns false when nobody registered with it.
+func (repository *PasswordResetRepository) FindAccount(ctx context2.Context, email string) (Account, bool, error) {
+	account := Account{Email: email}
+
+	query := "SELECT id, username, language, email_verified_at IS NOT NULL FROM users WHERE email = ?"
+	err := repository.db.QueryRowContext(ctx, query, email).Scan(&account.UserID, &account.Username, &account.Language, &account.EmailVerified)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Account{}, false, nil
+		}
+		return Account{}, false, err
+	}
+
+	return account, true, nil
+}
+
+// IssueResetTokenRepository hands out the single-use token that lets the owner of a verified email set a new password.
+func (repository *PasswordResetRepository) IssueResetTokenRepository(email string) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	account, ok, err := repository.FindAccount(ctx, email)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to find account",
+		}).Error("Failed to find account")
+
+		return helpers.ApiResponse{Message: "Failed to find account"}, http.StatusInternalServerError, err
+	}
+	if !ok {
+		err = errors.New("user not found")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "User not found",
+		}).Error("User not found")
+
+		return helpers.ApiResponse{Message: "User not found"}, http.StatusNotFound, err
+	}
+
+	buf := make([]byte, 32)
+	_, err = rand.Read(buf)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to generate reset token",
+		}).Error("Failed to generate reset token")
+
+		return helpers.ApiResponse{Message: "Failed to generate reset token"}, http.StatusInternalServerError, err
+	}
+	token := hex.EncodeToString(buf)
+
+	err = repository.rdb.Set(ctx, resetTokenKey(token), account.UserID, ResetTokenTTL).Err()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to store reset token",
+		}).Error("Failed to store reset token")
+
+		return helpers.ApiResponse{Message: "Failed to store reset token"}, http.StatusInternalServerError, err
+	}
+
+	data := struct {
+		Email      string `json:"email"`
+		ResetToken string `json:"reset_token"`
+		ExpiresIn  int    `json:"expires_in"`
+	}{
+		Email:      account.Email,
+		ResetToken: token,
+		ExpiresIn:  int(ResetTokenTTL.Seconds()),
+	}
+
+	return helpers.ApiResponse{Message: "Otp verified, use the reset token to set a new password", Data: data}, http.StatusOK, nil
+}
+
+// ResetPasswordRepository consumes a reset token, stores the new password and signs the user out everywhere.
+func (repository *PasswordResetRepository) ResetPasswordRepository(request request_models.ResetPasswordRequest) (helpers.ApiResponse, int, error) {
+	if len(request.Password) < minPasswordLength {
+		err := errors.New("password too short")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Password too short",
+		}).Error("Password too short")
+
+		return helpers.ApiResponse{Message: fmt.Sprintf("Password must be at least %d characters!", minPasswordLength)}, http.StatusBadRequest, err
+	}
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	// GETDEL makes the token single-use even when two requests race with it.
+	value, err := repository.rdb.GetDel(ctx, resetTokenKey(request.ResetToken)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Invalid or expired reset token",
+			}).Error("Invalid or expired reset token")
+
+			return helpers.ApiResponse{Message: "Invalid or expired reset token"}, http.StatusUnauthorized, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to get reset token",
+		}).Error("Failed to get reset token")
+
+		return helpers.ApiResponse{Message: "Failed to get reset token"}, http.StatusInternalServerError, err
+	}
+
+	userID, err := strconv.Atoi(value)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Invalid reset token store",
+		}).Error("Invalid reset token store")
+
+		return helpers.ApiResponse{Message: "Invalid reset token store"}, http.StatusInternalServerError, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to hash password",
+		}).Error("Failed to hash password")
+
+		return helpers.ApiResponse{Message: "Failed to hash password"}, http.StatusInternalServerError, err
+	}
+
+	result, err := repository.db.ExecContext(ctx, "UPDATE users SET password = ? WHERE id = ?", string(hashedPassword), userID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to update password",
+		}).Error("Failed to update password")
+
+		return helpers.ApiResponse{Message: "Failed to update password"}, http.StatusInternalServerError, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to get rows affected",
+		}).Error("Failed to get rows affected")
+
+		return helpers.ApiResponse{Message: "Failed to get rows affected"}, http.StatusInternalServerError, err
+	}
+	if rowsAffected == 0 {
+		err = errors.New("user not found")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "User not found",
+		}).Error("User not found")
+
+		return helpers.ApiResponse{Message: "User not found"}, http.StatusNotFound, err
+	}
+
+	err = middlewares.RevokeUserTokens(ctx, userID)
+	if err == nil {
+		err = refresh_token_repository.RevokeUser(ctx, repository.rdb, userID)
+	}
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to revoke sessions",
+		}).Error("Failed to revoke sessions")
+
+		return helpers.ApiResponse{Message: "Password updated but failed to revoke existing sessions"}, http.StatusInternalServerError, err
+	}
+
+	repository.logLogrus.Infof("Password reset, userID: %d", userID)
+
+	return helpers.ApiResponse{Message: "Successfully reset password, please log in again"}, http.StatusOK, nil
+}
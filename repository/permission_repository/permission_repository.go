@@ -0,0 +1,394 @@
+package permission_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/audit_repository"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// cacheTTL bounds how long another instance keeps serving a mapping after a Manager changed it.
+const cacheTTL = 30 * time.Second
+
+type cachedRole struct {
+	permissions map[string]bool
+	loadedAt    time.Time
+}
+
+var (
+	cacheMu sync.RWMutex
+	cache   = map[string]cachedRole{}
+)
+
+func invalidateCache() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	cache = map[string]cachedRole{}
+}
+
+type PermissionRepository struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+}
+
+func NewPermissionRepository(db *sql.DB, logLogrus *logrus.Logger) *PermissionRepository {
+	return &PermissionRepository{db: db, logLogrus: logLogrus}
+}
+
+// HasPermission reports whether the role holds the permission. Mappings are cached for cacheTTL.
+func (repository *PermissionRepository) HasPermission(ctx context2.Context, role, permission string) (bool, error) {
+	cacheMu.RLock()
+	cached, ok := cache[role]
+	cacheMu.RUnlock()
+
+	if !ok || time.Since(cached.loadedAt) > cacheTTL {
+		permissions, err := repository.rolePermissions(ctx, role)
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Failed to load role permissions",
+			}).Error("Failed to load role permissions")
+
+			return false, err
+		}
+
+		cached = cachedRole{permissions: permissions, loadedAt: time.Now()}
+
+		cacheMu.Lock()
+		cache[role] = cached
+		cacheMu.Unlock()
+	}
+
+	return cached.permissions[permission], nil
+}
+
+func (repository *PermissionRepository) rolePermissions(ctx context2.Context, role string) (map[string]bool, error) {
+	query := `SELECT p.name FROM role_permissions rp
+			  JOIN roles r ON r.id = rp.role_id
+			  JOIN permissions p ON p.permission_id = rp.permission_id
+			  WHERE r.role = ?`
+	rows, err := repository.db.QueryContext(ctx, query, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := map[string]bool{}
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		permissions[name] = true
+	}
+
+	return permissions, rows.Err()
+}
+
+// GetPermissionsRepository lists every permission a role can be given.
+func (repository *PermissionRepository) GetPermissionsRepository() (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	rows, err := repository.db.QueryContext(ctx, "SELECT permission_id, name, description FROM permissions ORDER BY name")
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to get permissions",
+		}).Error("Failed to get permissions")
+
+		return helpers.ApiResponse{Message: "Failed to get permissions"}, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+
+	permissions := []identity.Permission{}
+	for rows.Next() {
+		var permission identity.Permission
+		err = rows.Scan(&permission.PermissionID, &permission.Name, &permission.Description)
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Failed to scan permission",
+			}).Error("Failed to scan permission")
+
+			return helpers.ApiResponse{Message: "Failed to get permissions"}, http.StatusInternalServerError, err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return helpers.ApiResponse{Message: "Success!", Data: permissions}, http.StatusOK, nil
+}
+
+// GetRolesRepository lists every role with the permissions it holds.
+func (repository *PermissionRepository) GetRolesRepository() (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	query := `SELECT r.id, r.role, IFNULL(p.name, '') FROM roles r
+			  LEFT JOIN role_permissions rp ON rp.role_id = r.id
+			  LEFT JOIN permissions p ON p.permission_id = rp.permission_id
+			  ORDER BY r.id, p.name`
+	rows, err := repository.db.QueryContext(ctx, query)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to get roles",
+		}).Error("Failed to get roles")
+
+		return helpers.ApiResponse{Message: "Failed to get roles"}, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+
+	roles := []*identity.Role{}
+	for rows.Next() {
+		var (
+			role       identity.Role
+			permission string
+		)
+		err = rows.Scan(&role.RoleID, &role.Role, &permission)
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Failed to scan role",
+			}).Error("Failed to scan role")
+
+			return helpers.ApiResponse{Message: "Failed to get roles"}, http.StatusInternalServerError, err
+		}
+
+		if len(roles) == 0 || roles[len(roles)-1].RoleID != role.RoleID {
+			role.Permissions = []string{}
+			roles = append(roles, &role)
+		}
+		if permission != "" {
+			last := roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, permission)
+		}
+	}
+
+	return helpers.ApiResponse{Message: "Success!", Data: roles}, http.StatusOK, nil
+}
+
+// rolePermission is the audit snapshot of a single role to permission mapping.
+type rolePermission struct {
+	Role       string `json:"role"`
+	Permission string `json:"permission"`
+}
+
+// findMapping resolves the role name and the permission ID of a request, failing when either does not exist.
+func (repository *PermissionRepository) findMapping(ctx context2.Context, request request_models.RolePermissionRequest) (string, int, helpers.ApiResponse, int, error) {
+	var (
+		role         string
+		permissionID int
+	)
+
+	err := repository.db.QueryRowContext(ctx, "SELECT role FROM roles WHERE id = ?", request.RoleID).Scan(&role)
+	if err == nil {
+		err = repository.db.QueryRowContext(ctx, "SELECT permission_id FROM permissions WHERE name = ?", request.Permission).Scan(&permissionID)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Role or permission not found",
+			}).Error("Role or permission not found")
+
+			return "", 0, helpers.ApiResponse{Message: "Role or permission not found"}, http.StatusNotFound, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to query role permission",
+		}).Error("Failed to query role permission")
+
+		return "", 0, helpers.ApiResponse{Message: "Failed to query role permission"}, http.StatusInternalServerError, err
+	}
+
+	return role, permissionID, helpers.ApiResponse{}, http.StatusOK, nil
+}
+
+// GrantPermissionRepository gives a role a permission. Granting one the role already holds is not an error.
+func (repository *PermissionRepository) GrantPermissionRepository(r *http.Request, request request_models.RolePermissionRequest, managerID int) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	role, permissionID, response, code, err := repository.findMapping(ctx, request)
+	if err != nil {
+		return response, code, err
+	}
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to begin transaction",
+		}).Error("Failed to begin transaction")
+
+		return helpers.ApiResponse{Message: "Failed to grant permission"}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "INSERT IGNORE INTO role_permissions (role_id, permission_id) VALUES (?, ?)", request.RoleID, permissionID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to grant permission",
+		}).Error("Failed to grant permission")
+
+		return helpers.ApiResponse{Message: "Failed to grant permission"}, http.StatusInternalServerError, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to get rows affected",
+		}).Error("Failed to get rows affected")
+
+		return helpers.ApiResponse{Message: "Failed to grant permission"}, http.StatusInternalServerError, err
+	}
+
+	// Granting a permission the role already holds changes nothing, so there is nothing to audit either.
+	if rowsAffected > 0 {
+		err = audit_repository.Record(ctx, tx, r, audit_repository.Event{
+			Action:     identity.AuditActionPermissionGrant,
+			EntityType: identity.AuditEntityRole,
+			EntityID:   request.RoleID,
+			After:      rolePermission{Role: role, Permission: request.Permission},
+		})
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Failed to record audit entry",
+			}).Error("Failed to record audit entry")
+
+			return helpers.ApiResponse{Message: "Failed to grant permission"}, http.StatusInternalServerError, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to commit transaction",
+		}).Error("Failed to commit transaction")
+
+		return helpers.ApiResponse{Message: "Failed to grant permission"}, http.StatusInternalServerError, err
+	}
+
+	invalidateCache()
+	repository.logLogrus.Infof("Permission %s granted to role %s, by managerID: %d", request.Permission, role, managerID)
+
+	return helpers.ApiResponse{Message: "Permission granted"}, http.StatusOK, nil
+}
+
+// RevokePermissionRepository takes a permission away from a role. The last role able to manage roles keeps
+// role:manage, otherwise nobody could edit the mappings any more.
+func (repository *PermissionRepository) RevokePermissionRepository(r *http.Request, request request_models.RolePermissionRequest, managerID int) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	role, permissionID, response, code, err := repository.findMapping(ctx, request)
+	if err != nil {
+		return response, code, err
+	}
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to begin transaction",
+		}).Error("Failed to begin transaction")
+
+		return helpers.ApiResponse{Message: "Failed to revoke permission"}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	if request.Permission == identity.PermissionRoleManage {
+		var holders int
+		err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM role_permissions WHERE permission_id = ? AND role_id <> ? FOR UPDATE", permissionID, request.RoleID).Scan(&holders)
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Failed to count role managers",
+			}).Error("Failed to count role managers")
+
+			return helpers.ApiResponse{Message: "Failed to revoke permission"}, http.StatusInternalServerError, err
+		}
+		if holders == 0 {
+			err = errors.New("last role manager")
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Cannot revoke role:manage from the last role holding it",
+			}).Error("Cannot revoke role:manage from the last role holding it")
+
+			return helpers.ApiResponse{Message: "At least one role must keep role:manage"}, http.StatusConflict, err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE role_id = ? AND permission_id = ?", request.RoleID, permissionID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to revoke permission",
+		}).Error("Failed to revoke permission")
+
+		return helpers.ApiResponse{Message: "Failed to revoke permission"}, http.StatusInternalServerError, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to get rows affected",
+		}).Error("Failed to get rows affected")
+
+		return helpers.ApiResponse{Message: "Failed to revoke permission"}, http.StatusInternalServerError, err
+	}
+	if rowsAffected == 0 {
+		err = errors.New("permission not granted")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Role does not hold the permission",
+		}).Error("Role does not hold the permission")
+
+		return helpers.ApiResponse{Message: "Role does not hold the permission"}, http.StatusNotFound, err
+	}
+
+	err = audit_repository.Record(ctx, tx, r, audit_repository.Event{
+		Action:     identity.AuditActionPermissionRevoke,
+		EntityType: identity.AuditEntityRole,
+		EntityID:   request.RoleID,
+		Before:     rolePermission{Role: role, Permission: request.Permission},
+	})
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to record audit entry",
+		}).Error("Failed to record audit entry")
+
+		return helpers.ApiResponse{Message: "Failed to revoke permission"}, http.StatusInternalServerError, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to commit transaction",
+		}).Error("Failed to commit transaction")
+
+		return helpers.ApiResponse{Message: "Failed to revoke permission"}, http.StatusInternalServerError, err
+	}
+
+	invalidateCache()
+	repository.logLogrus.Infof("Permission %s revoked from role %s, by managerID: %d", request.Permission, role, managerID)
+
+	return helpers.ApiResponse{Message: "Permission revoked"}, http.StatusOK, nil
+}
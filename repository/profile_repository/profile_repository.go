@@ -9,6 +9,7 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"go-libraryschool/helpers"
+	"go-libraryschool/mailer"
 	request "go-libraryschool/models/request_models"
 	response "go-libraryschool/models/response_models"
 	"net/http"
@@ -62,7 +63,7 @@ func (repository *ProfileRepository) getProfileDBMysql(userID int) (helpers.ApiR
 	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
 	defer cancel()
 
-	query := "SELECT username, email, roleID FROM users WHERE id = ?"
+	query := "SELECT username, email, roleID, language FROM users WHERE id = ?"
 	stmt, err := repository.Db.PrepareContext(ctx, query)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
@@ -74,7 +75,7 @@ func (repository *ProfileRepository) getProfileDBMysql(userID int) (helpers.ApiR
 	}
 	defer stmt.Close()
 
-	err = stmt.QueryRowContext(ctx, userID).Scan(&profile.Username, &profile.Email, &profile.RoleID)
+	err = stmt.QueryRowContext(ctx, userID).Scan(&profile.Username, &profile.Email, &profile.RoleID, &profile.Language)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			repository.logLogrus.WithFields(logrus.Fields{
@@ -153,7 +154,7 @@ func (repository *ProfileRepository) UpdateProfileRepository(profile *request.Pr
 	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
 	defer cancel()
 
-	query := "SELECT username, email FROM users WHERE id = ?"
+	query := "SELECT username, email, language FROM users WHERE id = ?"
 	stmt, err := repository.Db.PrepareContext(ctx, query)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
@@ -164,7 +165,7 @@ func (repository *ProfileRepository) UpdateProfileRepository(profile *request.Pr
 		return helpers.ApiResponse{Message: "Failed to prepare statement"}, http.StatusInternalServerError, err
 	}
 
-	err = stmt.QueryRowContext(ctx, profileID).Scan(&existingProfile.Username, &existingProfile.Email)
+	err = stmt.QueryRowContext(ctx, profileID).Scan(&existingProfile.Username, &existingProfile.Email, &existingProfile.Language)
 	stmt.Close()
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -189,6 +190,9 @@ func (repository *ProfileRepository) UpdateProfileRepository(profile *request.Pr
 	if strings.TrimSpace(profile.Email) == "" {
 		profile.Email = existingProfile.Email
 	}
+	if strings.TrimSpace(profile.Language) == "" {
+		profile.Language = existingProfile.Language
+	}
 
 	if existingProfile.Email != profile.Email {
 		queryCheckEmail := "SELECT id FROM users WHERE email = ? AND id != ?"
@@ -232,7 +236,19 @@ func (repository *ProfileRepository) UpdateProfileRepository(profile *request.Pr
 		return helpers.ApiResponse{Message: "Invalid email format"}, http.StatusBadRequest, err
 	}
 
-	updateQuery := "UPDATE users SET username = ?, email = ? WHERE id = ?"
+	language, ok := mailer.SupportedLanguage(profile.Language)
+	if !ok {
+		err = errors.New("unsupported language")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "unsupported language",
+		}).Error("unsupported language")
+
+		return helpers.ApiResponse{Message: "Language must be id or en"}, http.StatusBadRequest, err
+	}
+	profile.Language = language
+
+	updateQuery := "UPDATE users SET username = ?, email = ?, language = ? WHERE id = ?"
 	stmt, err = repository.Db.PrepareContext(ctx, updateQuery)
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
@@ -242,7 +258,7 @@ func (repository *ProfileRepository) UpdateProfileRepository(profile *request.Pr
 
 		return helpers.ApiResponse{Message: "Failed to prepare statement"}, http.StatusInternalServerError, err
 	}
-	result, err := stmt.ExecContext(ctx, profile.Username, profile.Email, profileID)
+	result, err := stmt.ExecContext(ctx, profile.Username, profile.Email, profile.Language, profileID)
 	stmt.Close()
 	if err != nil {
 		repository.logLogrus.WithFields(logrus.Fields{
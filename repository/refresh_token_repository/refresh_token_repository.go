@@ -0,0 +1,393 @@
+package refresh_token_repository
+
+import (
+	context2 "context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/request_models"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	// RefreshTokenLifetime is how long a refresh token, and the family it belongs to, stays usable without being rotated.
+	RefreshTokenLifetime = 7 * 24 * time.Hour
+
+	layoutDateTime = "2006-01-02 15:04:05"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+// Only hashes of refresh tokens are stored, so a copy of Redis cannot be used to refresh.
+func tokenKey(hash string) string {
+	return fmt.Sprintf("refresh-token:%s", hash)
+}
+
+func usedKey(hash string) string {
+	return fmt.Sprintf("refresh-used:%s", hash)
+}
+
+func familyKey(familyID string) string {
+	return fmt.Sprintf("refresh-family:%s", familyID)
+}
+
+func userFamiliesKey(userID int) string {
+	return fmt.Sprintf("refresh-families:%d", userID)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Issue starts a new refresh token family for a login from the given device and returns it with its first token.
+func Issue(ctx context2.Context, rdb *redis.Client, userID int, userAgent, ip string) (identity.RefreshFamily, string, error) {
+	familyID, err := randomHex(16)
+	if err != nil {
+		return identity.RefreshFamily{}, "", err
+	}
+
+	token, err := randomHex(32)
+	if err != nil {
+		return identity.RefreshFamily{}, "", err
+	}
+
+	family := identity.RefreshFamily{
+		FamilyID:  familyID,
+		UserID:    userID,
+		UserAgent: userAgent,
+		IP:        ip,
+		CreatedAt: time.Now().Format(layoutDateTime),
+	}
+
+	value, err := json.Marshal(family)
+	if err != nil {
+		return identity.RefreshFamily{}, "", err
+	}
+
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, familyKey(familyID), value, RefreshTokenLifetime)
+		pipe.Set(ctx, tokenKey(hashToken(token)), familyID, RefreshTokenLifetime)
+		pipe.SAdd(ctx, userFamiliesKey(userID), familyID)
+		pipe.Expire(ctx, userFamiliesKey(userID), RefreshTokenLifetime)
+		return nil
+	})
+	if err != nil {
+		return identity.RefreshFamily{}, "", err
+	}
+
+	return family, token, nil
+}
+
+// Issuer starts the refresh token family of a login.
+type Issuer interface {
+	Issue(ctx context2.Context, userID int, userAgent, ip string) (identity.RefreshFamily, string, error)
+}
+
+// RedisIssuer issues families with Issue.
+type RedisIssuer struct {
+	rdb *redis.Client
+}
+
+func NewRedisIssuer(rdb *redis.Client) *RedisIssuer {
+	return &RedisIssuer{rdb: rdb}
+}
+
+func (issuer *RedisIssuer) Issue(ctx context2.Context, userID int, userAgent, ip string) (identity.RefreshFamily, string, error) {
+	return Issue(ctx, issuer.rdb, userID, userAgent, ip)
+}
+
+// FamilyOf returns the family a refresh token belongs to. It returns false for unknown tokens and revoked families.
+func FamilyOf(ctx context2.Context, rdb *redis.Client, token string) (identity.RefreshFamily, bool, error) {
+	familyID, err := rdb.Get(ctx, tokenKey(hashToken(token))).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return identity.RefreshFamily{}, false, nil
+		}
+		return identity.RefreshFamily{}, false, err
+	}
+
+	return LoadFamily(ctx, rdb, familyID)
+}
+
+// LoadFamily returns the family with the given ID. It returns false once the family expired or was revoked.
+func LoadFamily(ctx context2.Context, rdb *redis.Client, familyID string) (identity.RefreshFamily, bool, error) {
+	var family identity.RefreshFamily
+
+	value, err := rdb.Get(ctx, familyKey(familyID)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return family, false, nil
+		}
+		return family, false, err
+	}
+
+	err = json.Unmarshal([]byte(value), &family)
+	if err != nil {
+		return family, false, err
+	}
+
+	return family, true, nil
+}
+
+// Rotate exchanges a refresh token for the next one of its family. Every token can be rotated once:
+// presenting it again means it leaked, so the whole family is revoked and ErrRefreshTokenReused returned.
+func Rotate(ctx context2.Context, rdb *redis.Client, token string) (identity.RefreshFamily, string, error) {
+	hash := hashToken(token)
+
+	familyID, err := rdb.Get(ctx, tokenKey(hash)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return identity.RefreshFamily{}, "", ErrInvalidRefreshToken
+		}
+		return identity.RefreshFamily{}, "", err
+	}
+
+	// The first request to mark the token used wins, any later one is a replay.
+	fresh, err := rdb.SetNX(ctx, usedKey(hash), familyID, RefreshTokenLifetime).Result()
+	if err != nil {
+		return identity.RefreshFamily{}, "", err
+	}
+	if !fresh {
+		err = RevokeFamily(ctx, rdb, familyID)
+		if err != nil {
+			return identity.RefreshFamily{}, "", err
+		}
+		return identity.RefreshFamily{}, "", ErrRefreshTokenReused
+	}
+
+	family, ok, err := LoadFamily(ctx, rdb, familyID)
+	if err != nil {
+		return family, "", err
+	}
+	if !ok {
+		return family, "", ErrInvalidRefreshToken
+	}
+
+	next, err := randomHex(32)
+	if err != nil {
+		return family, "", err
+	}
+
+	family.RotatedAt = time.Now().Format(layoutDateTime)
+	value, err := json.Marshal(family)
+	if err != nil {
+		return family, "", err
+	}
+
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, familyKey(familyID), value, RefreshTokenLifetime)
+		pipe.Set(ctx, tokenKey(hashToken(next)), familyID, RefreshTokenLifetime)
+		pipe.Expire(ctx, userFamiliesKey(family.UserID), RefreshTokenLifetime)
+		return nil
+	})
+	if err != nil {
+		return family, "", err
+	}
+
+	return family, next, nil
+}
+
+// ListFamilies returns the live families of the user, oldest first. Expired families are dropped from the user's set.
+func ListFamilies(ctx context2.Context, rdb *redis.Client, userID int) ([]identity.RefreshFamily, error) {
+	familyIDs, err := rdb.SMembers(ctx, userFamiliesKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	families := []identity.RefreshFamily{}
+	for _, familyID := range familyIDs {
+		family, ok, err := LoadFamily(ctx, rdb, familyID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			rdb.SRem(ctx, userFamiliesKey(userID), familyID)
+			continue
+		}
+		families = append(families, family)
+	}
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].CreatedAt < families[j].CreatedAt
+	})
+
+	return families, nil
+}
+
+// RevokeFamily makes every refresh token of the family unusable.
+func RevokeFamily(ctx context2.Context, rdb *redis.Client, familyID string) error {
+	family, ok, err := LoadFamily(ctx, rdb, familyID)
+	if err != nil {
+		return err
+	}
+
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, familyKey(familyID))
+		if ok {
+			pipe.SRem(ctx, userFamiliesKey(family.UserID), familyID)
+		}
+		return nil
+	})
+	return err
+}
+
+// RevokeUser revokes every refresh token family of the user.
+func RevokeUser(ctx context2.Context, rdb *redis.Client, userID int) error {
+	familyIDs, err := rdb.SMembers(ctx, userFamiliesKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, familyID := range familyIDs {
+			pipe.Del(ctx, familyKey(familyID))
+		}
+		pipe.Del(ctx, userFamiliesKey(userID))
+		return nil
+	})
+	return err
+}
+
+type RefreshTokenRepository struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+	rdb       *redis.Client
+}
+
+func NewRefreshTokenRepository(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db, logLogrus: logLogrus, rdb: rdb}
+}
+
+// RefreshRepository rotates the refresh token and issues a new access token carrying the user's current role.
+func (repository *RefreshTokenRepository) RefreshRepository(request request_models.RefreshTokenRequest) (helpers.ApiResponseAuthorization, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	// Everything that may fail for a passing reason runs before the token is consumed, so a client whose
+	// refresh failed can retry with the token it still holds instead of tripping reuse detection.
+	family, ok, err := FamilyOf(ctx, repository.rdb, request.RefreshToken)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to load refresh token",
+		}).Error("Failed to load refresh token")
+
+		return helpers.ApiResponseAuthorization{Message: "Failed to rotate refresh token"}, http.StatusInternalServerError, err
+	}
+	if !ok {
+		err = ErrInvalidRefreshToken
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Invalid or expired refresh token",
+		}).Error("Invalid or expired refresh token")
+
+		return helpers.ApiResponseAuthorization{Message: "Invalid or expired refresh token"}, http.StatusUnauthorized, err
+	}
+
+	var (
+		email, role string
+		suspended   bool
+	)
+
+	query := "SELECT u.email, r.role, u.suspended_at IS NOT NULL FROM users u JOIN roles r ON r.id = u.roleID WHERE u.id = ?"
+	err = repository.db.QueryRowContext(ctx, query, family.UserID).Scan(&email, &role, &suspended)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			_ = RevokeFamily(ctx, repository.rdb, family.FamilyID)
+
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "User not found",
+			}).Error("User not found")
+
+			return helpers.ApiResponseAuthorization{Message: "User not found"}, http.StatusUnauthorized, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to query user",
+		}).Error("Failed to query user")
+
+		return helpers.ApiResponseAuthorization{Message: "Failed to query user"}, http.StatusInternalServerError, err
+	}
+
+	if suspended {
+		_ = RevokeFamily(ctx, repository.rdb, family.FamilyID)
+
+		err = errors.New("account suspended")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Account suspended",
+		}).Error("Account suspended")
+
+		return helpers.ApiResponseAuthorization{Message: "Account suspended"}, http.StatusForbidden, err
+	}
+
+	token, err := helpers.GenerateToken(family.UserID, email, role, family.FamilyID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to generate token",
+		}).Error("Failed to generate token")
+
+		return helpers.ApiResponseAuthorization{Message: "Failed to generate token!"}, http.StatusInternalServerError, err
+	}
+
+	_, refreshToken, err := Rotate(ctx, repository.rdb, request.RefreshToken)
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Refresh token reused, family revoked",
+			}).Warn("Refresh token reused, family revoked")
+
+			return helpers.ApiResponseAuthorization{Message: "Refresh token already used, please log in again"}, http.StatusUnauthorized, err
+		}
+
+		if errors.Is(err, ErrInvalidRefreshToken) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Invalid or expired refresh token",
+			}).Error("Invalid or expired refresh token")
+
+			return helpers.ApiResponseAuthorization{Message: "Invalid or expired refresh token"}, http.StatusUnauthorized, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to rotate refresh token",
+		}).Error("Failed to rotate refresh token")
+
+		return helpers.ApiResponseAuthorization{Message: "Failed to rotate refresh token"}, http.StatusInternalServerError, err
+	}
+
+	return helpers.ApiResponseAuthorization{
+		Message:      "Success!",
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(helpers.AccessTokenLifetime.Seconds()),
+	}, http.StatusOK, nil
+}
@@ -0,0 +1,198 @@
+package reminder_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/mailer"
+	"go-libraryschool/repository/circulation_policy_repository"
+	"go-libraryschool/repository/mail_outbox_repository"
+	"time"
+)
+
+const (
+	layoutDate     = "2006-01-02"
+	layoutDateTime = "2006-01-02 15:04:05"
+
+	ReminderDueSoon = "due_soon"
+	ReminderOverdue = "overdue"
+)
+
+type reminderLoan struct {
+	BorrowID int
+	Kind     string
+	mailer.ReminderLoan
+}
+
+type reminder struct {
+	UserID   int
+	Username string
+	Email    string
+	Language string
+	Loans    []reminderLoan
+}
+
+type ReminderRepository struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+}
+
+func NewReminderRepository(db *sql.DB, logLogrus *logrus.Logger) *ReminderRepository {
+	return &ReminderRepository{db: db, logLogrus: logLogrus}
+}
+
+// SendLoanRemindersRepository emails every borrower whose loans are due tomorrow or overdue.
+// A loan is reminded at most once per kind and day, so running the job again the same day sends nothing new.
+// The mails go through the outbox, which takes care of retrying failed deliveries.
+func (repository *ReminderRepository) SendLoanRemindersRepository(ctx context2.Context) error {
+	now := time.Now()
+	today := now.Format(layoutDate)
+	tomorrow := now.AddDate(0, 0, 1).Format(layoutDate)
+
+	policies, err := circulation_policy_repository.LoadPolicies(ctx, repository.db)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT bb.borrow_id, bb.user_id, u.username, u.email, u.language, u.roleID, b.title, b.genre_id, bb.return_date
+			  FROM borrowed_books bb
+			  JOIN users u ON u.id = bb.user_id
+			  JOIN books b ON b.book_id = bb.book_id
+			  WHERE bb.returned_at IS NULL AND (bb.return_date = ? OR bb.return_date < ?)
+			  ORDER BY bb.user_id, bb.return_date`
+	rows, err := repository.db.QueryContext(ctx, query, tomorrow, today)
+	if err != nil {
+		return err
+	}
+
+	var reminders []*reminder
+	formatter := helpers.NewFormatterMoney()
+	for rows.Next() {
+		var (
+			loan    reminderLoan
+			userID  int
+			name    string
+			email   string
+			lang    string
+			roleID  int
+			genreID int
+		)
+
+		err = rows.Scan(&loan.BorrowID, &userID, &name, &email, &lang, &roleID, &loan.Title, &genreID, &loan.ReturnDate)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		loan.Kind = ReminderDueSoon
+		if loan.ReturnDate < today {
+			returnDate, err := time.Parse(layoutDate, loan.ReturnDate)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+
+			policy := helpers.ResolveCirculationPolicy(policies, roleID, genreID)
+			days, fine := helpers.CalculateFine(policy, returnDate, now)
+
+			loan.Kind = ReminderOverdue
+			loan.DaysLate = days
+			if fine > 0 {
+				loan.Fine = formatter.FormatMinorUnits(fine, policy.Currency)
+			}
+		}
+
+		if len(reminders) == 0 || reminders[len(reminders)-1].UserID != userID {
+			reminders = append(reminders, &reminder{UserID: userID, Username: name, Email: email, Language: lang})
+		}
+		current := reminders[len(reminders)-1]
+		current.Loans = append(current.Loans, loan)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	// A borrower whose reminder cannot be queued must not hold back the reminders of the others.
+	queued, failed := 0, 0
+	for _, item := range reminders {
+		ok, err := repository.queue(ctx, item, today)
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"user_id": item.UserID,
+			}).Error("Failed to queue loan reminder")
+
+			failed++
+			continue
+		}
+		if ok {
+			queued++
+		}
+	}
+
+	repository.logLogrus.Infof("Loan reminders queued for %d borrowers", queued)
+
+	if failed > 0 {
+		return fmt.Errorf("failed to queue loan reminders for %d of %d borrowers", failed, len(reminders))
+	}
+
+	return nil
+}
+
+// queue claims today's reminder for each loan of a borrower and puts one mail for the loans that had
+// not been reminded yet in the outbox. Claims and mail are written in the same transaction.
+func (repository *ReminderRepository) queue(ctx context2.Context, item *reminder, today string) (bool, error) {
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var claimed []reminderLoan
+
+	query := "INSERT IGNORE INTO loan_reminders (borrow_id, kind, sent_on, created_at) VALUES (?, ?, ?, ?)"
+	for _, loan := range item.Loans {
+		result, err := tx.ExecContext(ctx, query, loan.BorrowID, loan.Kind, today, time.Now().Format(layoutDateTime))
+		if err != nil {
+			return false, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+
+		if affected == 1 {
+			claimed = append(claimed, loan)
+		}
+	}
+
+	if len(claimed) == 0 {
+		return false, nil
+	}
+	item.Loans = claimed
+
+	message, err := reminderMessage(item)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = mail_outbox_repository.Enqueue(ctx, tx, message)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func reminderMessage(item *reminder) (mailer.Message, error) {
+	data := mailer.LoanReminderData{Username: item.Username}
+	for _, loan := range item.Loans {
+		data.Loans = append(data.Loans, loan.ReminderLoan)
+	}
+
+	return mailer.Render(item.Email, mailer.TemplateLoanReminder, item.Language, data)
+}
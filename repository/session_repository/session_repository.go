@@ -0,0 +1,139 @@
+package session_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/response_models"
+	"go-libraryschool/repository/refresh_token_repository"
+	"net/http"
+	"time"
+)
+
+// A session is the refresh token family of a login, see refresh_token_repository.
+type SessionRepository struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+	rdb       *redis.Client
+}
+
+func NewSessionRepository(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *SessionRepository {
+	return &SessionRepository{db: db, logLogrus: logLogrus, rdb: rdb}
+}
+
+// GetSessionsRepository lists the active sessions of the user, flagging the one currentSessionID belongs to.
+func (repository *SessionRepository) GetSessionsRepository(userID int, currentSessionID string) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	families, err := refresh_token_repository.ListFamilies(ctx, repository.rdb, userID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to get sessions",
+		}).Error("Failed to get sessions")
+
+		return helpers.ApiResponse{Message: "Failed to get sessions"}, http.StatusInternalServerError, err
+	}
+
+	sessions := make([]response_models.SessionResponse, 0, len(families))
+	for _, family := range families {
+		sessions = append(sessions, response_models.SessionResponse{
+			SessionID:  family.FamilyID,
+			UserAgent:  family.UserAgent,
+			IP:         family.IP,
+			IssuedAt:   family.CreatedAt,
+			LastUsedAt: family.RotatedAt,
+			Current:    family.FamilyID == currentSessionID,
+		})
+	}
+
+	return helpers.ApiResponse{Message: "Success!", Data: sessions}, http.StatusOK, nil
+}
+
+// RevokeSessionRepository logs out one session of the user. Sessions of other users are reported as not found.
+func (repository *SessionRepository) RevokeSessionRepository(userID int, sessionID string) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	family, ok, err := refresh_token_repository.LoadFamily(ctx, repository.rdb, sessionID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to get session",
+		}).Error("Failed to get session")
+
+		return helpers.ApiResponse{Message: "Failed to get session"}, http.StatusInternalServerError, err
+	}
+	if !ok || family.UserID != userID {
+		err = errors.New("session not found")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Session not found",
+		}).Error("Session not found")
+
+		return helpers.ApiResponse{Message: "Session not found"}, http.StatusNotFound, err
+	}
+
+	err = refresh_token_repository.RevokeFamily(ctx, repository.rdb, sessionID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to revoke session",
+		}).Error("Failed to revoke session")
+
+		return helpers.ApiResponse{Message: "Failed to revoke session"}, http.StatusInternalServerError, err
+	}
+
+	repository.logLogrus.Infof("Session revoked, userID: %d", userID)
+
+	return helpers.ApiResponse{Message: "Session revoked"}, http.StatusOK, nil
+}
+
+// ForceLogoutRepository ends every session of the user, e.g. when a student card is lost.
+func (repository *SessionRepository) ForceLogoutRepository(userID, managerID int) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	var id int
+	err := repository.db.QueryRowContext(ctx, "SELECT id FROM users WHERE id = ?", userID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "User not found",
+			}).Error("User not found")
+
+			return helpers.ApiResponse{Message: "User not found"}, http.StatusNotFound, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to query user",
+		}).Error("Failed to query user")
+
+		return helpers.ApiResponse{Message: "Failed to query user"}, http.StatusInternalServerError, err
+	}
+
+	// Revoking the families ends sessions, the marker also covers access tokens issued without a session.
+	err = refresh_token_repository.RevokeUser(ctx, repository.rdb, userID)
+	if err == nil {
+		err = middlewares.RevokeUserTokens(ctx, userID)
+	}
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to revoke sessions",
+		}).Error("Failed to revoke sessions")
+
+		return helpers.ApiResponse{Message: "Failed to revoke sessions"}, http.StatusInternalServerError, err
+	}
+
+	repository.logLogrus.Infof("User logged out everywhere, userID: %d, by managerID: %d", userID, managerID)
+
+	return helpers.ApiResponse{Message: "User logged out from every session"}, http.StatusOK, nil
+}
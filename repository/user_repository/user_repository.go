@@ -0,0 +1,630 @@
+package user_repository
+
+import (
+	context2 "context"
+	"database/sql"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/mailer"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/models/response_models"
+	"go-libraryschool/repository/audit_repository"
+	"go-libraryschool/repository/refresh_token_repository"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	layoutDateTime = "2006-01-02 15:04:05"
+
+	// UserStatusActive and UserStatusSuspended filter the user list.
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+
+	// suspensionTTL bounds how long another instance keeps letting a just suspended user through. Suspending
+	// also revokes the user's tokens, so in practice they are refused right away everywhere.
+	suspensionTTL = 30 * time.Second
+)
+
+type cachedSuspension struct {
+	suspended bool
+	loadedAt  time.Time
+}
+
+var (
+	cacheMu sync.RWMutex
+	cache   = map[int]cachedSuspension{}
+)
+
+func forgetSuspension(userID int) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	delete(cache, userID)
+}
+
+const userColumns = `u.id, u.username, u.email, u.roleID, r.role, u.language, u.email_verified_at IS NOT NULL,
+			  IFNULL(u.suspended_at, ''), IFNULL(u.suspended_reason, ''), u.created_at
+			  FROM users u JOIN roles r ON r.id = u.roleID`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (response_models.UserResponse, error) {
+	var user response_models.UserResponse
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.RoleID, &user.Role, &user.Language, &user.EmailVerified,
+		&user.SuspendedAt, &user.SuspendedReason, &user.CreatedAt)
+	user.Suspended = user.SuspendedAt != ""
+	return user, err
+}
+
+type UserRepository struct {
+	db        *sql.DB
+	logLogrus *logrus.Logger
+	rdb       *redis.Client
+}
+
+func NewUserRepository(db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *UserRepository {
+	return &UserRepository{db: db, logLogrus: logLogrus, rdb: rdb}
+}
+
+// IsSuspended reports whether the account is suspended. Answers are cached for suspensionTTL.
+func (repository *UserRepository) IsSuspended(ctx context2.Context, userID int) (bool, error) {
+	cacheMu.RLock()
+	cached, ok := cache[userID]
+	cacheMu.RUnlock()
+
+	if ok && time.Since(cached.loadedAt) <= suspensionTTL {
+		return cached.suspended, nil
+	}
+
+	var suspended bool
+	err := repository.db.QueryRowContext(ctx, "SELECT suspended_at IS NOT NULL FROM users WHERE id = ?", userID).Scan(&suspended)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to check account status",
+		}).Error("Failed to check account status")
+
+		return false, err
+	}
+
+	cacheMu.Lock()
+	cache[userID] = cachedSuspension{suspended: suspended, loadedAt: time.Now()}
+	cacheMu.Unlock()
+
+	return suspended, nil
+}
+
+// GetUsersRepository lists users matching the filters. search matches the username or the email.
+func (repository *UserRepository) GetUsersRepository(r *http.Request, search string, roleID int, status string, pagination helpers.Pagination) (helpers.ApiResponse, int, error) {
+	var (
+		conditions []string
+		args       []any
+		total      int
+	)
+
+	switch status {
+	case "":
+	case UserStatusActive:
+		conditions = append(conditions, "u.suspended_at IS NULL")
+	case UserStatusSuspended:
+		conditions = append(conditions, "u.suspended_at IS NOT NULL")
+	default:
+		err := errors.New("unknown user status")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Invalid user status")
+
+		return helpers.ApiResponse{Message: "Status must be active or suspended", Data: nil}, http.StatusBadRequest, err
+	}
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	if search != "" {
+		conditions = append(conditions, "(u.username LIKE ? OR u.email LIKE ?)")
+		args = append(args, "%"+search+"%", "%"+search+"%")
+	}
+	if roleID > 0 {
+		conditions = append(conditions, "u.roleID = ?")
+		args = append(args, roleID)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	err := repository.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users u"+where, args...).Scan(&total)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+
+	query := "SELECT " + userColumns + where + " ORDER BY u.id LIMIT ? OFFSET ?"
+	rows, err := repository.db.QueryContext(ctx, query, append(args, pagination.PerPage, pagination.Offset())...)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error": err,
+			"data":  r.URL.RawQuery,
+		}).Error("Failed to execute query")
+
+		return helpers.ApiResponse{Message: "Failed to execute query", Data: nil}, http.StatusInternalServerError, err
+	}
+	defer rows.Close()
+
+	users := []response_models.UserResponse{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error": err,
+				"data":  r.URL.RawQuery,
+			}).Error("Failed to scan row")
+
+			return helpers.ApiResponse{Message: "Failed to scan row", Data: nil}, http.StatusInternalServerError, err
+		}
+		users = append(users, user)
+	}
+
+	return helpers.ApiResponse{Message: "Success", Data: helpers.NewPaginatedData(users, pagination, total)}, http.StatusOK, nil
+}
+
+// getUser loads one user, answering 404 when there is none.
+func (repository *UserRepository) getUser(ctx context2.Context, userID int) (response_models.UserResponse, helpers.ApiResponse, int, error) {
+	user, err := scanUser(repository.db.QueryRowContext(ctx, "SELECT "+userColumns+" WHERE u.id = ?", userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "User not found",
+			}).Error("User not found")
+
+			return user, helpers.ApiResponse{Message: "User not found"}, http.StatusNotFound, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to query user",
+		}).Error("Failed to query user")
+
+		return user, helpers.ApiResponse{Message: "Failed to query user"}, http.StatusInternalServerError, err
+	}
+
+	return user, helpers.ApiResponse{}, http.StatusOK, nil
+}
+
+// roleName looks up the name of a role, answering 404 when the role does not exist.
+func (repository *UserRepository) roleName(ctx context2.Context, roleID int) (string, helpers.ApiResponse, int, error) {
+	var role string
+	err := repository.db.QueryRowContext(ctx, "SELECT role FROM roles WHERE id = ?", roleID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repository.logLogrus.WithFields(logrus.Fields{
+				"error":   err,
+				"message": "Role not found",
+			}).Error("Role not found")
+
+			return "", helpers.ApiResponse{Message: "Role not found"}, http.StatusNotFound, err
+		}
+
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to query role",
+		}).Error("Failed to query role")
+
+		return "", helpers.ApiResponse{Message: "Failed to query role"}, http.StatusInternalServerError, err
+	}
+
+	return role, helpers.ApiResponse{}, http.StatusOK, nil
+}
+
+// commitWithAudit records the event in the transaction of the action and commits both.
+func (repository *UserRepository) commitWithAudit(ctx context2.Context, tx *sql.Tx, r *http.Request, event audit_repository.Event) error {
+	err := audit_repository.Record(ctx, tx, r, event)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to record audit entry",
+		}).Error("Failed to record audit entry")
+
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to commit transaction",
+		}).Error("Failed to commit transaction")
+	}
+	return err
+}
+
+func (repository *UserRepository) begin(ctx context2.Context) (*sql.Tx, error) {
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to begin transaction",
+		}).Error("Failed to begin transaction")
+	}
+	return tx, err
+}
+
+// revokeAccess ends every session of the user and invalidates the access tokens issued so far.
+func (repository *UserRepository) revokeAccess(ctx context2.Context, userID int) error {
+	err := middlewares.RevokeUserTokens(ctx, userID)
+	if err == nil {
+		err = refresh_token_repository.RevokeUser(ctx, repository.rdb, userID)
+	}
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to revoke sessions",
+		}).Error("Failed to revoke sessions")
+	}
+	return err
+}
+
+// CreateUserRepository creates an account with any role, e.g. for Librarians and Managers. Accounts created by a
+// Manager don't go through email verification.
+func (repository *UserRepository) CreateUserRepository(r *http.Request, request request_models.CreateUserRequest, managerID int) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	_, response, code, err := repository.roleName(ctx, request.RoleID)
+	if err != nil {
+		return response, code, err
+	}
+
+	var existingID int
+	err = repository.db.QueryRowContext(ctx, "SELECT id FROM users WHERE email = ?", request.Email).Scan(&existingID)
+	if err == nil {
+		err = errors.New("email already exists")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Email already exists",
+		}).Error("Email already exists")
+
+		return helpers.ApiResponse{Message: "Email already exists!"}, http.StatusConflict, err
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to query user",
+		}).Error("Failed to query user")
+
+		return helpers.ApiResponse{Message: "Failed to query user"}, http.StatusInternalServerError, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to hash password",
+		}).Error("Failed to hash password")
+
+		return helpers.ApiResponse{Message: "Failed to hash password"}, http.StatusInternalServerError, err
+	}
+
+	tx, err := repository.begin(ctx)
+	if err != nil {
+		return helpers.ApiResponse{Message: "Failed to create user"}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Format(layoutDateTime)
+	query := "INSERT INTO users (username, email, password, roleID, language, created_at, email_verified_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	result, err := tx.ExecContext(ctx, query, request.Username, request.Email, string(hashedPassword), request.RoleID, mailer.NormalizeLanguage(request.Language), now, now)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to create user",
+		}).Error("Failed to create user")
+
+		return helpers.ApiResponse{Message: "Failed to create user"}, http.StatusInternalServerError, err
+	}
+
+	userID, err := result.LastInsertId()
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to get last insert id",
+		}).Error("Failed to get last insert id")
+
+		return helpers.ApiResponse{Message: "Failed to create user"}, http.StatusInternalServerError, err
+	}
+
+	err = repository.commitWithAudit(ctx, tx, r, audit_repository.Event{
+		Action:     identity.AuditActionUserCreate,
+		EntityType: identity.AuditEntityUser,
+		EntityID:   userID,
+		After: map[string]any{
+			"username": request.Username,
+			"email":    request.Email,
+			"role_id":  request.RoleID,
+			"language": mailer.NormalizeLanguage(request.Language),
+		},
+	})
+	if err != nil {
+		return helpers.ApiResponse{Message: "Failed to create user"}, http.StatusInternalServerError, err
+	}
+
+	user, response, code, err := repository.getUser(ctx, int(userID))
+	if err != nil {
+		return response, code, err
+	}
+
+	repository.logLogrus.Infof("User created, userID: %d, role: %s, by managerID: %d", user.ID, user.Role, managerID)
+
+	return helpers.ApiResponse{Message: "User created", Data: user}, http.StatusCreated, nil
+}
+
+// ChangeRoleRepository moves a user to another role. The user's access tokens are revoked so the next refresh
+// issues one carrying the new role; their sessions stay logged in.
+func (repository *UserRepository) ChangeRoleRepository(r *http.Request, request request_models.ChangeRoleRequest, managerID int) (helpers.ApiResponse, int, error) {
+	if request.UserID == managerID {
+		err := errors.New("cannot change own role")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Manager tried to change their own role",
+		}).Error("Manager tried to change their own role")
+
+		return helpers.ApiResponse{Message: "You cannot change your own role"}, http.StatusConflict, err
+	}
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	role, response, code, err := repository.roleName(ctx, request.RoleID)
+	if err != nil {
+		return response, code, err
+	}
+
+	previous, response, code, err := repository.getUser(ctx, request.UserID)
+	if err != nil {
+		return response, code, err
+	}
+
+	tx, err := repository.begin(ctx)
+	if err != nil {
+		return helpers.ApiResponse{Message: "Failed to change role"}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET roleID = ? WHERE id = ?", request.RoleID, request.UserID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to change role",
+		}).Error("Failed to change role")
+
+		return helpers.ApiResponse{Message: "Failed to change role"}, http.StatusInternalServerError, err
+	}
+
+	user := previous
+	user.RoleID, user.Role = request.RoleID, role
+
+	err = repository.commitWithAudit(ctx, tx, r, audit_repository.Event{
+		Action:     identity.AuditActionUserChangeRole,
+		EntityType: identity.AuditEntityUser,
+		EntityID:   request.UserID,
+		Before:     previous,
+		After:      user,
+	})
+	if err != nil {
+		return helpers.ApiResponse{Message: "Failed to change role"}, http.StatusInternalServerError, err
+	}
+
+	err = middlewares.RevokeUserTokens(ctx, request.UserID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to revoke tokens",
+		}).Error("Failed to revoke tokens")
+
+		return helpers.ApiResponse{Message: "Role changed but failed to revoke existing tokens"}, http.StatusInternalServerError, err
+	}
+
+	repository.logLogrus.Infof("Role changed, userID: %d, from: %s, to: %s, by managerID: %d", user.ID, previous.Role, user.Role, managerID)
+
+	return helpers.ApiResponse{Message: "Role changed", Data: user}, http.StatusOK, nil
+}
+
+// SuspendUserRepository suspends an account: the user is logged out everywhere and refused until reactivated.
+func (repository *UserRepository) SuspendUserRepository(r *http.Request, request request_models.SuspendUserRequest, managerID int) (helpers.ApiResponse, int, error) {
+	if request.UserID == managerID {
+		err := errors.New("cannot suspend own account")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Manager tried to suspend their own account",
+		}).Error("Manager tried to suspend their own account")
+
+		return helpers.ApiResponse{Message: "You cannot suspend your own account"}, http.StatusConflict, err
+	}
+
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	user, response, code, err := repository.getUser(ctx, request.UserID)
+	if err != nil {
+		return response, code, err
+	}
+	if user.Suspended {
+		err = errors.New("user already suspended")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "User already suspended",
+		}).Error("User already suspended")
+
+		return helpers.ApiResponse{Message: "User is already suspended"}, http.StatusConflict, err
+	}
+
+	tx, err := repository.begin(ctx)
+	if err != nil {
+		return helpers.ApiResponse{Message: "Failed to suspend user"}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	suspended := user
+	suspended.Suspended, suspended.SuspendedAt, suspended.SuspendedReason = true, time.Now().Format(layoutDateTime), request.Reason
+
+	query := "UPDATE users SET suspended_at = ?, suspended_reason = ? WHERE id = ? AND suspended_at IS NULL"
+	_, err = tx.ExecContext(ctx, query, suspended.SuspendedAt, request.Reason, request.UserID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to suspend user",
+		}).Error("Failed to suspend user")
+
+		return helpers.ApiResponse{Message: "Failed to suspend user"}, http.StatusInternalServerError, err
+	}
+
+	err = repository.commitWithAudit(ctx, tx, r, audit_repository.Event{
+		Action:     identity.AuditActionUserSuspend,
+		EntityType: identity.AuditEntityUser,
+		EntityID:   request.UserID,
+		Before:     user,
+		After:      suspended,
+	})
+	if err != nil {
+		return helpers.ApiResponse{Message: "Failed to suspend user"}, http.StatusInternalServerError, err
+	}
+
+	forgetSuspension(request.UserID)
+
+	err = repository.revokeAccess(ctx, request.UserID)
+	if err != nil {
+		return helpers.ApiResponse{Message: "User suspended but failed to revoke existing sessions"}, http.StatusInternalServerError, err
+	}
+
+	repository.logLogrus.Infof("User suspended, userID: %d, by managerID: %d", request.UserID, managerID)
+
+	return helpers.ApiResponse{Message: "User suspended"}, http.StatusOK, nil
+}
+
+// ReactivateUserRepository lifts a suspension. The user has to log in again.
+func (repository *UserRepository) ReactivateUserRepository(r *http.Request, request request_models.ReactivateUserRequest, managerID int) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	user, response, code, err := repository.getUser(ctx, request.UserID)
+	if err != nil {
+		return response, code, err
+	}
+	if !user.Suspended {
+		err = errors.New("user not suspended")
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "User is not suspended",
+		}).Error("User is not suspended")
+
+		return helpers.ApiResponse{Message: "User is not suspended"}, http.StatusConflict, err
+	}
+
+	tx, err := repository.begin(ctx)
+	if err != nil {
+		return helpers.ApiResponse{Message: "Failed to reactivate user"}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET suspended_at = NULL, suspended_reason = NULL WHERE id = ?", request.UserID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to reactivate user",
+		}).Error("Failed to reactivate user")
+
+		return helpers.ApiResponse{Message: "Failed to reactivate user"}, http.StatusInternalServerError, err
+	}
+
+	reactivated := user
+	reactivated.Suspended, reactivated.SuspendedAt, reactivated.SuspendedReason = false, "", ""
+
+	err = repository.commitWithAudit(ctx, tx, r, audit_repository.Event{
+		Action:     identity.AuditActionUserReactivate,
+		EntityType: identity.AuditEntityUser,
+		EntityID:   request.UserID,
+		Before:     user,
+		After:      reactivated,
+	})
+	if err != nil {
+		return helpers.ApiResponse{Message: "Failed to reactivate user"}, http.StatusInternalServerError, err
+	}
+
+	forgetSuspension(request.UserID)
+	repository.logLogrus.Infof("User reactivated, userID: %d, by managerID: %d", request.UserID, managerID)
+
+	return helpers.ApiResponse{Message: "User reactivated"}, http.StatusOK, nil
+}
+
+// ResetUserPasswordRepository sets a new password for the user, e.g. one handed over at the desk, and logs them
+// out everywhere.
+func (repository *UserRepository) ResetUserPasswordRepository(r *http.Request, request request_models.ResetUserPasswordRequest, managerID int) (helpers.ApiResponse, int, error) {
+	ctx, cancel := context2.WithTimeout(context2.Background(), 4*time.Second)
+	defer cancel()
+
+	_, response, code, err := repository.getUser(ctx, request.UserID)
+	if err != nil {
+		return response, code, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to hash password",
+		}).Error("Failed to hash password")
+
+		return helpers.ApiResponse{Message: "Failed to hash password"}, http.StatusInternalServerError, err
+	}
+
+	tx, err := repository.begin(ctx)
+	if err != nil {
+		return helpers.ApiResponse{Message: "Failed to update password"}, http.StatusInternalServerError, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET password = ? WHERE id = ?", string(hashedPassword), request.UserID)
+	if err != nil {
+		repository.logLogrus.WithFields(logrus.Fields{
+			"error":   err,
+			"message": "Failed to update password",
+		}).Error("Failed to update password")
+
+		return helpers.ApiResponse{Message: "Failed to update password"}, http.StatusInternalServerError, err
+	}
+
+	// The password itself never goes into the audit log.
+	err = repository.commitWithAudit(ctx, tx, r, audit_repository.Event{
+		Action:     identity.AuditActionUserResetPassword,
+		EntityType: identity.AuditEntityUser,
+		EntityID:   request.UserID,
+	})
+	if err != nil {
+		return helpers.ApiResponse{Message: "Failed to update password"}, http.StatusInternalServerError, err
+	}
+
+	err = repository.revokeAccess(ctx, request.UserID)
+	if err != nil {
+		return helpers.ApiResponse{Message: "Password updated but failed to revoke existing sessions"}, http.StatusInternalServerError, err
+	}
+
+	repository.logLogrus.Infof("Password reset, userID: %d, by managerID: %d", request.UserID, managerID)
+
+	return helpers.ApiResponse{Message: "Password reset, the user has been logged out everywhere"}, http.StatusOK, nil
+}
@@ -0,0 +1,30 @@
+package audit_routes
+
+import (
+	"database/sql"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/audit_controller"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"net/http"
+)
+
+func AuditRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger) {
+	controller := audit_controller.NewAuditController(db, logLogrus)
+
+	registerRoute := func(path string, method string, permission string, handlerFunc func(http.ResponseWriter, *http.Request)) {
+		mux.Handle(path, middlewares.JWTMiddleware()(middlewares.PermissionMiddleware(permission)(middlewares.RateLimitMiddleware(path)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
+					Message: "Method Not Allowed",
+				})
+				return
+			}
+			handlerFunc(w, r)
+		})))))
+	}
+
+	registerRoute("/audit/get-logs", http.MethodGet, identity.PermissionAuditRead, controller.GetAuditLog)
+	registerRoute("/audit/export-logs", http.MethodGet, identity.PermissionAuditRead, controller.ExportAuditLog)
+}
@@ -0,0 +1,22 @@
+package auth_routes
+
+import (
+	"go-libraryschool/controllers/auth_controller"
+	"go-libraryschool/helpers"
+	"net/http"
+)
+
+func JWKSRoute(mux *http.ServeMux) {
+	controller := auth_controller.NewJWKSController()
+
+	mux.HandleFunc("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			controller.JWKS(w, r)
+		} else {
+			helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
+				Message: "Method Not Allowed",
+				Data:    nil,
+			})
+		}
+	})
+}
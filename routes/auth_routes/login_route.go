@@ -2,15 +2,17 @@ package auth_routes
 
 import (
 	"database/sql"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
 	"go-libraryschool/controllers/auth_controller"
 	"go-libraryschool/helpers"
 	"net/http"
 )
 
-func LoginRoute(mux *http.ServeMux, db *sql.DB) {
+func LoginRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) {
 	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "POST" {
-			controller := auth_controller.NewLoginController(db)
+			controller := auth_controller.NewLoginController(db, logLogrus, rdb)
 			controller.Login(w, r)
 		} else {
 			helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
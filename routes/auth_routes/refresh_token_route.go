@@ -0,0 +1,26 @@
+package auth_routes
+
+import (
+	"database/sql"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/auth_controller"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"net/http"
+)
+
+func RefreshTokenRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) {
+	controller := auth_controller.NewRefreshTokenController(db, logLogrus, rdb)
+
+	mux.Handle("/token/refresh", middlewares.RateLimitMiddleware("/token/refresh")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			controller.RefreshToken(w, r)
+		} else {
+			helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
+				Message: "Method Not Allowed",
+				Data:    nil,
+			})
+		}
+	})))
+}
@@ -2,21 +2,23 @@ package auth_routes
 
 import (
 	"database/sql"
+	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"go-libraryschool/controllers/auth_controller"
 	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
 	"net/http"
 )
 
-func RegisterRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger) {
-	mux.HandleFunc("/register", func(w http.ResponseWriter, r *http.Request) {
+func RegisterRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) {
+	mux.Handle("/register", middlewares.RateLimitMiddleware("/register")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "POST" {
-			controller := auth_controller.NewRegisterController(db, logLogrus)
+			controller := auth_controller.NewRegisterController(db, logLogrus, rdb)
 			controller.Register(w, r)
 		} else {
 			helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
 				Message: "Invalid Method",
 			})
 		}
-	})
+	})))
 }
@@ -0,0 +1,26 @@
+package auth_routes
+
+import (
+	"database/sql"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/auth_controller"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"net/http"
+)
+
+func ResetPasswordRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) {
+	controller := auth_controller.NewResetPasswordController(db, logLogrus, rdb)
+
+	mux.Handle("/reset-password", middlewares.RateLimitMiddleware("/reset-password")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			controller.ResetPassword(w, r)
+		} else {
+			helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
+				Message: "Method Not Allowed",
+				Data:    nil,
+			})
+		}
+	})))
+}
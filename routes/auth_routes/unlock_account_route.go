@@ -0,0 +1,27 @@
+package auth_routes
+
+import (
+	"database/sql"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/auth_controller"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"net/http"
+)
+
+func UnlockAccountRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) {
+	controller := auth_controller.NewUnlockAccountController(db, logLogrus, rdb)
+
+	mux.Handle("/unlock-account", middlewares.JWTMiddleware()(middlewares.PermissionMiddleware(identity.PermissionUserUnlock)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			controller.UnlockAccount(w, r)
+		} else {
+			helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
+				Message: "Method Not Allowed",
+				Data:    nil,
+			})
+		}
+	}))))
+}
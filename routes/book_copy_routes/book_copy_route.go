@@ -0,0 +1,32 @@
+package book_copy_routes
+
+import (
+	"database/sql"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/book_copy_controller"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"net/http"
+)
+
+func BookCopyRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) {
+	controller := book_copy_controller.NewBookCopyController(db, logLogrus, rdb)
+
+	registerRoute := func(path string, method string, permission string, handlerFunc func(http.ResponseWriter, *http.Request)) {
+		mux.Handle(path, middlewares.JWTMiddleware()(middlewares.PermissionMiddleware(permission)(middlewares.RateLimitMiddleware(path)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
+					Message: "Method Not Allowed",
+				})
+				return
+			}
+			handlerFunc(w, r)
+		})))))
+	}
+
+	registerRoute("/book/copies/add-copy", http.MethodPost, identity.PermissionCopyManage, controller.AddCopy)
+	registerRoute("/book/copies/get-copies", http.MethodGet, identity.PermissionCopyManage, controller.GetCopies)
+	registerRoute("/book/copies/update-copy", http.MethodPut, identity.PermissionCopyManage, controller.UpdateCopy)
+}
@@ -0,0 +1,32 @@
+package circulation_policy_routes
+
+import (
+	"database/sql"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/circulation_policy_controller"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"net/http"
+)
+
+func CirculationPolicyRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger) {
+	controller := circulation_policy_controller.NewCirculationPolicyController(db, logLogrus)
+
+	registerRoute := func(path string, method string, permission string, handlerFunc func(http.ResponseWriter, *http.Request)) {
+		mux.Handle(path, middlewares.JWTMiddleware()(middlewares.PermissionMiddleware(permission)(middlewares.RateLimitMiddleware(path)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
+					Message: "Method Not Allowed",
+				})
+				return
+			}
+			handlerFunc(w, r)
+		})))))
+	}
+
+	registerRoute("/circulation-policy/get-policies", http.MethodGet, identity.PermissionPolicyRead, controller.GetPolicies)
+	registerRoute("/circulation-policy/add-policy", http.MethodPost, identity.PermissionPolicyManage, controller.AddPolicy)
+	registerRoute("/circulation-policy/update-policy", http.MethodPut, identity.PermissionPolicyManage, controller.UpdatePolicy)
+	registerRoute("/circulation-policy/delete-policy", http.MethodDelete, identity.PermissionPolicyManage, controller.DeletePolicy)
+}
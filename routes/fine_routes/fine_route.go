@@ -0,0 +1,32 @@
+package fine_routes
+
+import (
+	"database/sql"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/fine_controller"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"net/http"
+)
+
+func FineRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger) {
+	controller := fine_controller.NewFineController(db, logLogrus)
+
+	registerRoute := func(path string, method string, permission string, handlerFunc func(http.ResponseWriter, *http.Request)) {
+		mux.Handle(path, middlewares.JWTMiddleware()(middlewares.PermissionMiddleware(permission)(middlewares.RateLimitMiddleware(path)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
+					Message: "Method Not Allowed",
+				})
+				return
+			}
+			handlerFunc(w, r)
+		})))))
+	}
+
+	registerRoute("/fine/pay-fine", http.MethodPost, identity.PermissionFineCollect, controller.PayFine)
+	registerRoute("/fine/waive-fine", http.MethodPost, identity.PermissionFineWaive, controller.WaiveFine)
+	registerRoute("/fine/my-fines", http.MethodGet, identity.PermissionFineRead, controller.MyFines)
+	registerRoute("/fine/get-fines", http.MethodGet, identity.PermissionFineCollect, controller.GetFines)
+}
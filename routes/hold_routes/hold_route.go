@@ -0,0 +1,33 @@
+package hold_routes
+
+import (
+	"database/sql"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/hold_controller"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"net/http"
+)
+
+func HoldRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) {
+	controller := hold_controller.NewHoldController(db, logLogrus, rdb)
+
+	registerRoute := func(path string, method string, permission string, handlerFunc func(http.ResponseWriter, *http.Request)) {
+		mux.Handle(path, middlewares.JWTMiddleware()(middlewares.PermissionMiddleware(permission)(middlewares.RateLimitMiddleware(path)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
+					Message: "Method Not Allowed",
+				})
+				return
+			}
+			handlerFunc(w, r)
+		})))))
+	}
+
+	registerRoute("/hold/place-hold", http.MethodPost, identity.PermissionHoldPlace, controller.PlaceHold)
+	registerRoute("/hold/my-holds", http.MethodGet, identity.PermissionHoldPlace, controller.MyHolds)
+	registerRoute("/hold/get-holds", http.MethodGet, identity.PermissionHoldManage, controller.GetHolds)
+	registerRoute("/hold/cancel-hold", http.MethodDelete, identity.PermissionHoldPlace, controller.CancelHold)
+}
@@ -0,0 +1,30 @@
+package mail_outbox_routes
+
+import (
+	"database/sql"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/mail_outbox_controller"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"net/http"
+)
+
+func MailOutboxRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger) {
+	controller := mail_outbox_controller.NewMailOutboxController(db, logLogrus)
+
+	registerRoute := func(path string, method string, permission string, handlerFunc func(http.ResponseWriter, *http.Request)) {
+		mux.Handle(path, middlewares.JWTMiddleware()(middlewares.PermissionMiddleware(permission)(middlewares.RateLimitMiddleware(path)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
+					Message: "Method Not Allowed",
+				})
+				return
+			}
+			handlerFunc(w, r)
+		})))))
+	}
+
+	registerRoute("/mail/outbox", http.MethodGet, identity.PermissionMailManage, controller.GetMails)
+	registerRoute("/mail/retry-mail", http.MethodPost, identity.PermissionMailManage, controller.RetryMail)
+}
@@ -7,14 +7,15 @@ import (
 	"go-libraryschool/controllers/management_book_controller"
 	"go-libraryschool/helpers"
 	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
 	"net/http"
 )
 
 func ManagementBookRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) {
 	controller := management_book_controller.NewManagementBookController(db, logLogrus, rdb)
 
-	registerRoute := func(path string, method string, roles []string, handlerFunc func(http.ResponseWriter, *http.Request)) {
-		mux.Handle(path, middlewares.JWTMiddleware(roles...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	registerRoute := func(path string, method string, permission string, handlerFunc func(http.ResponseWriter, *http.Request)) {
+		mux.Handle(path, middlewares.JWTMiddleware()(middlewares.PermissionMiddleware(permission)(middlewares.RateLimitMiddleware(path)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.Method != method {
 				helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
 					Message: "Method Not Allowed",
@@ -22,19 +23,22 @@ func ManagementBookRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logge
 				return
 			}
 			handlerFunc(w, r)
-		})))
+		})))))
 	}
 
-	registerRoute("/book/add-book", http.MethodPost, []string{"Manager", "Librarian"}, controller.AddBook)
-	registerRoute("/book/get-books", http.MethodGet, []string{"Manager", "Librarian", "Student"}, controller.GetBooks)
-	registerRoute("/book/get-book", http.MethodGet, []string{"Manager", "Librarian", "Student"}, controller.GetBook)
-	registerRoute("/book/search-book", http.MethodGet, []string{"Manager", "Librarian", "Student"}, controller.SearchBooks)
-	registerRoute("/book/delete-book", http.MethodDelete, []string{"Manager", "Librarian"}, controller.DeleteBook)
-	registerRoute("/book/update-book", http.MethodPut, []string{"Manager"}, controller.UpdateBook)
-	registerRoute("/book/borrowed-book", http.MethodPost, []string{"Manager", "Student"}, controller.BorrowedBook)
-	registerRoute("/book/book-borrowing-data", http.MethodGet, []string{"Manager", "Librarian"}, controller.BookBorrowingData)
-	registerRoute("/book/category-books", http.MethodGet, []string{"Manager", "Librarian", "Student"}, controller.GetBooksCategory)
-	registerRoute("/book/add-favorite-book", http.MethodPost, []string{"Librarian", "Student"}, controller.AddFavoriteBook)
-	registerRoute("/book/delete-favorite-book", http.MethodDelete, []string{"Librarian", "Student"}, controller.DeleteFavoriteBook)
-	registerRoute("/book/get-favorite-book", http.MethodGet, []string{"Librarian", "Student"}, controller.GetFavoriteBooks)
+	registerRoute("/book/add-book", http.MethodPost, identity.PermissionBookCreate, controller.AddBook)
+	registerRoute("/book/get-books", http.MethodGet, identity.PermissionBookRead, controller.GetBooks)
+	registerRoute("/book/get-book", http.MethodGet, identity.PermissionBookRead, controller.GetBook)
+	registerRoute("/book/search-book", http.MethodGet, identity.PermissionBookRead, controller.SearchBooks)
+	registerRoute("/book/delete-book", http.MethodDelete, identity.PermissionBookDelete, controller.DeleteBook)
+	registerRoute("/book/update-book", http.MethodPut, identity.PermissionBookUpdate, controller.UpdateBook)
+	registerRoute("/book/borrowed-book", http.MethodPost, identity.PermissionLoanCheckout, controller.BorrowedBook)
+	registerRoute("/book/return-book", http.MethodPost, identity.PermissionLoanManage, controller.ReturnBook)
+	registerRoute("/book/renew-book", http.MethodPost, identity.PermissionLoanCheckout, controller.RenewBook)
+	registerRoute("/book/my-loans", http.MethodGet, identity.PermissionLoanCheckout, controller.MyLoans)
+	registerRoute("/book/book-borrowing-data", http.MethodGet, identity.PermissionLoanManage, controller.BookBorrowingData)
+	registerRoute("/book/category-books", http.MethodGet, identity.PermissionBookRead, controller.GetBooksCategory)
+	registerRoute("/book/add-favorite-book", http.MethodPost, identity.PermissionFavoriteManage, controller.AddFavoriteBook)
+	registerRoute("/book/delete-favorite-book", http.MethodDelete, identity.PermissionFavoriteManage, controller.DeleteFavoriteBook)
+	registerRoute("/book/get-favorite-book", http.MethodGet, identity.PermissionFavoriteManage, controller.GetFavoriteBooks)
 }
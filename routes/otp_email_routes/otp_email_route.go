@@ -6,13 +6,14 @@ import (
 	"github.com/sirupsen/logrus"
 	"go-libraryschool/controllers/otp_email_controller"
 	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
 	"net/http"
 )
 
 func OtpEmailRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) {
 	controller := otp_email_controller.NewOtpEmailController(db, logLogrus, rdb)
 
-	mux.HandleFunc("/otp/send-otp", func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/otp/send-otp", middlewares.RateLimitMiddleware("/otp/send-otp")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			controller.OtpEmail(w, r)
 		} else {
@@ -20,9 +21,9 @@ func OtpEmailRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb
 				Message: "Invalid Method",
 			})
 		}
-	})
+	})))
 
-	mux.HandleFunc("/otp/verify-otp", func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/otp/verify-otp", middlewares.RateLimitMiddleware("/otp/verify-otp")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			controller.VerifyOtp(w, r)
 		} else {
@@ -30,5 +31,15 @@ func OtpEmailRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb
 				Message: "Invalid Method",
 			})
 		}
-	})
+	})))
+
+	mux.Handle("/otp/resend-verification", middlewares.RateLimitMiddleware("/otp/resend-verification")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			controller.ResendVerification(w, r)
+		} else {
+			helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
+				Message: "Invalid Method",
+			})
+		}
+	})))
 }
@@ -7,14 +7,15 @@ import (
 	"go-libraryschool/controllers/profile_controller"
 	"go-libraryschool/helpers"
 	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
 	"net/http"
 )
 
 func ProfileRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) {
 	controller := profile_controller.NewProfileController(db, logLogrus, rdb)
 
-	registerRoute := func(path string, method string, roles []string, handlerFunc func(http.ResponseWriter, *http.Request)) {
-		mux.Handle(path, middlewares.JWTMiddleware(roles...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	registerRoute := func(path string, method string, permission string, handlerFunc func(http.ResponseWriter, *http.Request)) {
+		mux.Handle(path, middlewares.JWTMiddleware()(middlewares.PermissionMiddleware(permission)(middlewares.RateLimitMiddleware(path)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.Method != method {
 				helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
 					Message: "Method Not Allowed",
@@ -22,10 +23,10 @@ func ProfileRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb
 				return
 			}
 			handlerFunc(w, r)
-		})))
+		})))))
 	}
 
-	registerRoute("/profile", http.MethodGet, []string{"Manager", "Librarian", "Student"}, controller.GetProfile)
-	registerRoute("/profile/update-profile", http.MethodPut, []string{"Manager", "Librarian", "Student"}, controller.UpdateProfile)
-	registerRoute("/profile/photo-profile", http.MethodPut, []string{"Manager", "Librarian", "Student"}, controller.UpdatePhotoProfile)
+	registerRoute("/profile", http.MethodGet, identity.PermissionProfileManage, controller.GetProfile)
+	registerRoute("/profile/update-profile", http.MethodPut, identity.PermissionProfileManage, controller.UpdateProfile)
+	registerRoute("/profile/photo-profile", http.MethodPut, identity.PermissionProfileManage, controller.UpdatePhotoProfile)
 }
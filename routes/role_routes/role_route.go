@@ -0,0 +1,32 @@
+package role_routes
+
+import (
+	"database/sql"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/role_controller"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"net/http"
+)
+
+func RoleRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger) {
+	controller := role_controller.NewRoleController(db, logLogrus)
+
+	registerRoute := func(path string, method string, permission string, handlerFunc func(http.ResponseWriter, *http.Request)) {
+		mux.Handle(path, middlewares.JWTMiddleware()(middlewares.PermissionMiddleware(permission)(middlewares.RateLimitMiddleware(path)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
+					Message: "Method Not Allowed",
+				})
+				return
+			}
+			handlerFunc(w, r)
+		})))))
+	}
+
+	registerRoute("/role/get-roles", http.MethodGet, identity.PermissionRoleManage, controller.GetRoles)
+	registerRoute("/role/get-permissions", http.MethodGet, identity.PermissionRoleManage, controller.GetPermissions)
+	registerRoute("/role/grant-permission", http.MethodPost, identity.PermissionRoleManage, controller.GrantPermission)
+	registerRoute("/role/revoke-permission", http.MethodDelete, identity.PermissionRoleManage, controller.RevokePermission)
+}
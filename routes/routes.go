@@ -7,26 +7,49 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go-libraryschool/controllers"
 	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	AuditRoutes "go-libraryschool/routes/audit_routes"
 	AuthRoutes "go-libraryschool/routes/auth_routes"
+	BookCopyRoutes "go-libraryschool/routes/book_copy_routes"
+	CirculationPolicyRoutes "go-libraryschool/routes/circulation_policy_routes"
+	FineRoutes "go-libraryschool/routes/fine_routes"
+	HoldRoutes "go-libraryschool/routes/hold_routes"
+	MailOutboxRoutes "go-libraryschool/routes/mail_outbox_routes"
 	ManagementBookRoutes "go-libraryschool/routes/management_book_routes"
 	OtpRoutes "go-libraryschool/routes/otp_email_routes"
 	ProfileRoutes "go-libraryschool/routes/profile_routes"
+	RoleRoutes "go-libraryschool/routes/role_routes"
+	SessionRoutes "go-libraryschool/routes/session_routes"
+	UserRoutes "go-libraryschool/routes/user_routes"
 	"net/http"
 )
 
 func Router(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) *http.ServeMux {
-	AuthRoutes.LoginRoute(mux, db)
-	AuthRoutes.RegisterRoute(mux, db, logLogrus)
+	AuthRoutes.LoginRoute(mux, db, logLogrus, rdb)
+	AuthRoutes.RegisterRoute(mux, db, logLogrus, rdb)
 	AuthRoutes.LogoutRoute(mux, rdb, logLogrus)
 	AuthRoutes.UpdatePasswordRoute(mux, db, logLogrus)
+	AuthRoutes.ResetPasswordRoute(mux, db, logLogrus, rdb)
+	AuthRoutes.RefreshTokenRoute(mux, db, logLogrus, rdb)
+	AuthRoutes.JWKSRoute(mux)
+	AuthRoutes.UnlockAccountRoute(mux, db, logLogrus, rdb)
+	SessionRoutes.SessionRoute(mux, db, logLogrus, rdb)
+	RoleRoutes.RoleRoute(mux, db, logLogrus)
+	UserRoutes.UserRoute(mux, db, logLogrus, rdb)
+	AuditRoutes.AuditRoute(mux, db, logLogrus)
 
 	OtpRoutes.OtpEmailRoute(mux, db, logLogrus, rdb)
+	MailOutboxRoutes.MailOutboxRoute(mux, db, logLogrus)
 
-	mux.Handle("/user/profile", middlewares.JWTMiddleware("Student", "Manager", "Librarian")(http.HandlerFunc(controllers.GetProfile)))
+	mux.Handle("/user/profile", middlewares.JWTMiddleware()(middlewares.PermissionMiddleware(identity.PermissionProfileManage)(http.HandlerFunc(controllers.GetProfile))))
 
 	mux.HandleFunc("/swagger/", httpSwagger.WrapHandler)
 
 	ManagementBookRoutes.ManagementBookRoute(mux, db, logLogrus, rdb)
+	BookCopyRoutes.BookCopyRoute(mux, db, logLogrus, rdb)
+	HoldRoutes.HoldRoute(mux, db, logLogrus, rdb)
+	CirculationPolicyRoutes.CirculationPolicyRoute(mux, db, logLogrus)
+	FineRoutes.FineRoute(mux, db, logLogrus)
 
 	ProfileRoutes.ProfileRoute(mux, db, logLogrus, rdb)
 
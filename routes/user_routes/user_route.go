@@ -0,0 +1,35 @@
+package user_routes
+
+import (
+	"database/sql"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/user_controller"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"net/http"
+)
+
+func UserRoute(mux *http.ServeMux, db *sql.DB, logLogrus *logrus.Logger, rdb *redis.Client) {
+	controller := user_controller.NewUserController(db, logLogrus, rdb)
+
+	registerRoute := func(path string, method string, permission string, handlerFunc func(http.ResponseWriter, *http.Request)) {
+		mux.Handle(path, middlewares.JWTMiddleware()(middlewares.PermissionMiddleware(permission)(middlewares.RateLimitMiddleware(path)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				helpers.SendJson(w, http.StatusMethodNotAllowed, helpers.ApiResponse{
+					Message: "Method Not Allowed",
+				})
+				return
+			}
+			handlerFunc(w, r)
+		})))))
+	}
+
+	registerRoute("/user/get-users", http.MethodGet, identity.PermissionUserManage, controller.GetUsers)
+	registerRoute("/user/create-user", http.MethodPost, identity.PermissionUserManage, controller.CreateUser)
+	registerRoute("/user/change-role", http.MethodPut, identity.PermissionUserManage, controller.ChangeRole)
+	registerRoute("/user/suspend-user", http.MethodPost, identity.PermissionUserManage, controller.SuspendUser)
+	registerRoute("/user/reactivate-user", http.MethodPost, identity.PermissionUserManage, controller.ReactivateUser)
+	registerRoute("/user/reset-password", http.MethodPost, identity.PermissionUserManage, controller.ResetUserPassword)
+}
@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// Job is a background task run by the Scheduler. Next returns the moment the job should run after now.
+// RunOnStart also runs the job once when the scheduler starts, so a restart does not skip a day.
+type Job struct {
+	Name       string
+	Next       func(now time.Time) time.Time
+	Run        func(ctx context.Context) error
+	Timeout    time.Duration
+	RunOnStart bool
+}
+
+type Scheduler struct {
+	logLogrus *logrus.Logger
+	jobs      []Job
+}
+
+func NewScheduler(logLogrus *logrus.Logger) *Scheduler {
+	return &Scheduler{logLogrus: logLogrus}
+}
+
+// Daily schedules a job every day at the given local time.
+func Daily(hour, minute int) func(now time.Time) time.Time {
+	return func(now time.Time) time.Time {
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	}
+}
+
+// Every schedules a job at a fixed interval.
+func Every(interval time.Duration) func(now time.Time) time.Time {
+	return func(now time.Time) time.Time {
+		return now.Add(interval)
+	}
+}
+
+func (scheduler *Scheduler) Add(job Job) {
+	if job.Timeout == 0 {
+		job.Timeout = time.Minute
+	}
+	scheduler.jobs = append(scheduler.jobs, job)
+}
+
+// Start runs every job in its own goroutine until ctx is cancelled.
+func (scheduler *Scheduler) Start(ctx context.Context) {
+	for _, job := range scheduler.jobs {
+		go scheduler.loop(ctx, job)
+	}
+}
+
+func (scheduler *Scheduler) loop(ctx context.Context, job Job) {
+	if job.RunOnStart {
+		scheduler.run(ctx, job)
+	}
+
+	for {
+		next := job.Next(time.Now())
+		scheduler.logLogrus.WithFields(logrus.Fields{"job": job.Name, "next_run": next}).Debug("Job scheduled")
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		scheduler.run(ctx, job)
+	}
+}
+
+func (scheduler *Scheduler) run(ctx context.Context, job Job) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			scheduler.logLogrus.WithFields(logrus.Fields{"job": job.Name, "panic": recovered}).Error("Job panicked")
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	err := job.Run(ctx)
+	if err != nil {
+		scheduler.logLogrus.WithFields(logrus.Fields{"job": job.Name, "error": err}).Error("Job failed")
+		return
+	}
+
+	scheduler.logLogrus.WithFields(logrus.Fields{"job": job.Name, "duration": time.Since(started)}).Info("Job finished")
+}
@@ -0,0 +1,118 @@
+package auth_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/auth_controller"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/login_attempt_repository"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLogin_LockoutAfterFailedLogins(t *testing.T) {
+	rdb := testRedis(t)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	email := "locked@gmail.com"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+
+	expectUser := func() {
+		mock.ExpectQuery("SELECT id, username, email, password, roleID, created_at, email_verified_at, suspended_at IS NOT NULL FROM users WHERE email = ?").
+			WithArgs(email).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "password", "roleID", "created_at", "email_verified_at", "suspended"}).
+				AddRow(3, "locked", email, string(hashedPassword), 1, time.Now(), time.Now(), false))
+	}
+
+	controller := auth_controller.NewLoginController(db, logrus.New(), rdb)
+
+	login := func(password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"email": email, "password": password})
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		controller.Login(rr, req)
+		return rr
+	}
+
+	for i := 1; i < login_attempt_repository.MaxFailedLogins; i++ {
+		expectUser()
+		if rr := login("wrong"); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d", i, rr.Code)
+		}
+	}
+
+	expectUser()
+	mock.ExpectQuery("SELECT username, language FROM users WHERE email = ?").
+		WithArgs(email).
+		WillReturnRows(sqlmock.NewRows([]string{"username", "language"}).AddRow("locked", "en"))
+	mock.ExpectExec("INSERT INTO mail_outbox").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	rr := login("wrong")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 once the account is locked, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	// While locked even the right password is refused, without looking up the user.
+	if rr := login("password123"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 for a locked account, got %d", rr.Code)
+	}
+
+	repository := login_attempt_repository.NewLoginAttemptRepository(db, logrus.New(), rdb)
+	_, code, _ := repository.UnlockAccountRepository(request_models.UnlockAccountRequest{Email: email}, 1)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 unlocking the account, got %d", code)
+	}
+	_, code, _ = repository.UnlockAccountRepository(request_models.UnlockAccountRequest{Email: email}, 1)
+	if code != http.StatusNotFound {
+		t.Errorf("expected 404 unlocking an account that is not locked, got %d", code)
+	}
+
+	expectUser()
+	if rr := login("wrong"); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 after the unlock, got %d", rr.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestLogin_RateLimitPerIP(t *testing.T) {
+	rdb := testRedis(t)
+
+	repository := login_attempt_repository.NewLoginAttemptRepository(nil, logrus.New(), rdb)
+
+	for i := 0; i < 20; i++ {
+		_, code, _ := repository.CheckLoginRepository("user"+string(rune('a'+i))+"@gmail.com", "203.0.113.9")
+		if code != http.StatusOK {
+			t.Fatalf("attempt %d: expected 200, got %d", i+1, code)
+		}
+	}
+
+	response, code, _ := repository.CheckLoginRepository("another@gmail.com", "203.0.113.9")
+	if code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 once the IP used up its attempts, got %d", code)
+	}
+	if retry, ok := response.Data.(helpers.RetryAfter); !ok || retry.Seconds <= 0 {
+		t.Errorf("expected the wait in the response, got %v", response.Data)
+	}
+
+	if _, code, _ := repository.CheckLoginRepository("another@gmail.com", "203.0.113.10"); code != http.StatusOK {
+		t.Errorf("expected other IPs to be unaffected, got %d", code)
+	}
+}
@@ -2,9 +2,13 @@ package auth_test
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"go-libraryschool/controllers/auth_controller"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/ratelimit"
+	"go-libraryschool/repository/login_attempt_repository"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -12,9 +16,26 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// fakeIssuer hands out refresh token families without Redis.
+type fakeIssuer struct{}
+
+func (fakeIssuer) Issue(_ context.Context, userID int, userAgent, ip string) (identity.RefreshFamily, string, error) {
+	return identity.RefreshFamily{FamilyID: "family-1", UserID: userID, UserAgent: userAgent, IP: ip}, "refresh-token", nil
+}
+
+// testLoginController keeps login attempts in memory, so login tests run without Redis.
+func testLoginController(db *sql.DB) *auth_controller.LoginController {
+	logLogrus := logrus.New()
+	attemptRepo := login_attempt_repository.NewLoginAttemptRepositoryWithStore(db, logLogrus,
+		login_attempt_repository.NewMemoryAttemptStore(), ratelimit.NewMemoryLimiter())
+
+	return auth_controller.NewLoginControllerWith(db, logLogrus, attemptRepo, fakeIssuer{})
+}
+
 func TestLoginHandler(t *testing.T) {
 	log.Println("Starting TestLoginHandler")
 
@@ -29,16 +50,16 @@ func TestLoginHandler(t *testing.T) {
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 
 	log.Println("Setting up user and role mock rows")
-	rows := sqlmock.NewRows([]string{"id", "username", "email", "password", "roleID", "created_at"}).
-		AddRow(1, "TestUser", email, string(hashedPassword), 2, time.Now())
-	mock.ExpectQuery("SELECT id, username, email, password, roleID, created_at FROM users WHERE email = ?").
+	rows := sqlmock.NewRows([]string{"id", "username", "email", "password", "roleID", "created_at", "email_verified_at", "suspended"}).
+		AddRow(1, "TestUser", email, string(hashedPassword), 2, time.Now(), time.Now(), false)
+	mock.ExpectQuery("SELECT id, username, email, password, roleID, created_at, email_verified_at, suspended_at IS NOT NULL FROM users WHERE email = ?").
 		WithArgs(email).WillReturnRows(rows)
 
 	roleRows := sqlmock.NewRows([]string{"role"}).AddRow("Admin")
 	mock.ExpectQuery("SELECT role FROM roles WHERE id = ?").
 		WithArgs(2).WillReturnRows(roleRows)
 
-	controller := auth_controller.NewLoginController(db)
+	controller := testLoginController(db)
 
 	log.Println("Creating login request")
 	loginBody := map[string]string{
@@ -83,11 +104,11 @@ func TestLogin_UserNotFound(t *testing.T) {
 	password := "somepassword"
 
 	log.Println("Expecting no user row returned")
-	mock.ExpectQuery("SELECT id, username, email, password, roleID, created_at FROM users WHERE email = ?").
+	mock.ExpectQuery("SELECT id, username, email, password, roleID, created_at, email_verified_at, suspended_at IS NOT NULL FROM users WHERE email = ?").
 		WithArgs(email).
 		WillReturnError(sql.ErrNoRows)
 
-	controller := auth_controller.NewLoginController(db)
+	controller := testLoginController(db)
 
 	loginBody := map[string]string{
 		"email":    email,
@@ -118,13 +139,13 @@ func TestLogin_WrongPassword(t *testing.T) {
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(correctPassword), bcrypt.DefaultCost)
 
 	log.Println("Mocking user row with correct password")
-	mock.ExpectQuery("SELECT id, username, email, password, roleID, created_at FROM users WHERE email = ?").
+	mock.ExpectQuery("SELECT id, username, email, password, roleID, created_at, email_verified_at, suspended_at IS NOT NULL FROM users WHERE email = ?").
 		WithArgs(email).
 		WillReturnRows(sqlmock.NewRows([]string{
-			"id", "username", "email", "password", "roleID", "created_at",
-		}).AddRow(1, "User", email, hashedPassword, 1, time.Now()))
+			"id", "username", "email", "password", "roleID", "created_at", "email_verified_at", "suspended",
+		}).AddRow(1, "User", email, hashedPassword, 1, time.Now(), time.Now(), false))
 
-	controller := auth_controller.NewLoginController(db)
+	controller := testLoginController(db)
 
 	loginBody := map[string]string{
 		"email":    email,
@@ -143,13 +164,83 @@ func TestLogin_WrongPassword(t *testing.T) {
 	}
 }
 
+func TestLogin_UnverifiedEmail(t *testing.T) {
+	log.Println("Starting TestLogin_UnverifiedEmail")
+
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	email := "new@gmail.com"
+	password := "secret123"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	mock.ExpectQuery("SELECT id, username, email, password, roleID, created_at, email_verified_at, suspended_at IS NOT NULL FROM users WHERE email = ?").
+		WithArgs(email).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "username", "email", "password", "roleID", "created_at", "email_verified_at", "suspended",
+		}).AddRow(1, "User", email, hashedPassword, 3, time.Now(), nil, false))
+
+	controller := testLoginController(db)
+
+	loginBody := map[string]string{
+		"email":    email,
+		"password": password,
+	}
+	jsonBody, _ := json.Marshal(loginBody)
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	controller.Login(rr, req)
+
+	log.Printf("Response status: %d\n", rr.Code)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for an unverified email, got %d", rr.Code)
+	}
+}
+
+func TestLogin_Suspended(t *testing.T) {
+	log.Println("Starting TestLogin_Suspended")
+
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	email := "suspended@gmail.com"
+	password := "secret123"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	mock.ExpectQuery("SELECT id, username, email, password, roleID, created_at, email_verified_at, suspended_at IS NOT NULL FROM users WHERE email = ?").
+		WithArgs(email).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "username", "email", "password", "roleID", "created_at", "email_verified_at", "suspended",
+		}).AddRow(1, "User", email, hashedPassword, 3, time.Now(), time.Now(), true))
+
+	controller := testLoginController(db)
+
+	loginBody := map[string]string{
+		"email":    email,
+		"password": password,
+	}
+	jsonBody, _ := json.Marshal(loginBody)
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	controller.Login(rr, req)
+
+	log.Printf("Response status: %d\n", rr.Code)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a suspended account, got %d", rr.Code)
+	}
+}
+
 func TestLogin_InvalidEmailFormat(t *testing.T) {
 	log.Println("Starting TestLogin_InvalidEmailFormat")
 
 	db, _, _ := sqlmock.New()
 	defer db.Close()
 
-	controller := auth_controller.NewLoginController(db)
+	controller := auth_controller.NewLoginController(db, logrus.New(), nil)
 
 	loginBody := map[string]string{
 		"email":    "invalidemail",
@@ -0,0 +1,112 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/otp_email_controller"
+	"go-libraryschool/repository/otp_email_repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendOtp_EmailCooldown(t *testing.T) {
+	rdb := testRedis(t)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id, username, language, email_verified_at IS NOT NULL FROM users WHERE email = ?").
+		WithArgs("nobody@gmail.com").
+		WillReturnError(sql.ErrNoRows)
+
+	controller := otp_email_controller.NewOtpEmailController(db, logrus.New(), rdb)
+
+	send := func() *httptest.ResponseRecorder {
+		body := []byte(`{"email":"nobody@gmail.com","purpose":"reset_password"}`)
+		req := httptest.NewRequest(http.MethodPost, "/otp/send-otp", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		controller.OtpEmail(rr, req)
+		return rr
+	}
+
+	if rr := send(); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for the first request, got %d", rr.Code)
+	}
+
+	rr := send()
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for a request within the cooldown, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+}
+
+func TestVerifyOtp_InvalidatedAfterMaxAttempts(t *testing.T) {
+	rdb := testRedis(t)
+
+	email := "student@gmail.com"
+	err := rdb.Set(context.Background(), "Otp reset_password "+email+": ", `"123456"`, 0).Err()
+	if err != nil {
+		t.Fatalf("failed to store otp: %v", err)
+	}
+
+	controller := otp_email_controller.NewOtpEmailController(nil, logrus.New(), rdb)
+
+	verify := func(otp string) int {
+		body := []byte(`{"email":"` + email + `","otp":"` + otp + `","purpose":"reset_password"}`)
+		req := httptest.NewRequest(http.MethodPost, "/otp/verify-otp", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		controller.VerifyOtp(rr, req)
+		return rr.Code
+	}
+
+	for i := 0; i < otp_email_repository.MaxOtpAttempts; i++ {
+		if code := verify("000000"); code != http.StatusBadRequest {
+			t.Fatalf("attempt %d: expected 400 for a wrong code, got %d", i+1, code)
+		}
+	}
+
+	// The right code no longer works once the attempts are used up.
+	if code := verify("123456"); code != http.StatusNotFound {
+		t.Errorf("expected 404 after too many attempts, got %d", code)
+	}
+}
+
+func TestVerifyOtp_CountsAttemptBeforeComparing(t *testing.T) {
+	rdb := testRedis(t)
+	ctx := context.Background()
+
+	email := "student@gmail.com"
+	err := rdb.Set(ctx, "Otp reset_password "+email+": ", `"123456"`, 0).Err()
+	if err != nil {
+		t.Fatalf("failed to store otp: %v", err)
+	}
+
+	// Guesses running in parallel have already taken every attempt, none of them compared yet.
+	err = rdb.Set(ctx, "otp-attempts:reset_password:"+email, otp_email_repository.MaxOtpAttempts, 0).Err()
+	if err != nil {
+		t.Fatalf("failed to store attempts: %v", err)
+	}
+
+	controller := otp_email_controller.NewOtpEmailController(nil, logrus.New(), rdb)
+
+	body := []byte(`{"email":"` + email + `","otp":"123456","purpose":"reset_password"}`)
+	req := httptest.NewRequest(http.MethodPost, "/otp/verify-otp", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	controller.VerifyOtp(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 once the attempts are used up, even for the right code, got %d", rr.Code)
+	}
+	if exists, _ := rdb.Exists(ctx, "Otp reset_password "+email+": ").Result(); exists != 0 {
+		t.Errorf("expected the otp to be invalidated")
+	}
+}
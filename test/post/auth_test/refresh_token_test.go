@@ -0,0 +1,128 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/auth_controller"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/refresh_token_repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRefreshToken_RotationAndReuse(t *testing.T) {
+	rdb := testRedis(t)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	_, first, err := refresh_token_repository.Issue(context.Background(), rdb, 7, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to issue refresh token: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT u.email, r.role, u.suspended_at IS NOT NULL FROM users u JOIN roles r ON r.id = u.roleID WHERE u.id = ?").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "role", "suspended"}).AddRow("student@gmail.com", "Student", false))
+
+	controller := auth_controller.NewRefreshTokenController(db, logrus.New(), rdb)
+
+	refresh := func(token string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"refresh_token": token})
+		req := httptest.NewRequest(http.MethodPost, "/token/refresh", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		controller.RefreshToken(rr, req)
+		return rr
+	}
+
+	rr := refresh(first)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a fresh refresh token, got %d", rr.Code)
+	}
+
+	var response struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	if response.Token == "" || response.RefreshToken == "" || response.RefreshToken == first {
+		t.Fatalf("expected a new access and refresh token, got %+v", response)
+	}
+
+	// Replaying the rotated token revokes the family, so the token issued from it stops working too.
+	mock.ExpectQuery("SELECT u.email, r.role, u.suspended_at IS NOT NULL FROM users u JOIN roles r ON r.id = u.roleID WHERE u.id = ?").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "role", "suspended"}).AddRow("student@gmail.com", "Student", false))
+	if rr := refresh(first); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 when reusing a refresh token, got %d", rr.Code)
+	}
+	if rr := refresh(response.RefreshToken); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a token of a revoked family, got %d", rr.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRefreshToken_RetryAfterFailure(t *testing.T) {
+	rdb := testRedis(t)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	_, token, err := refresh_token_repository.Issue(context.Background(), rdb, 7, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to issue refresh token: %v", err)
+	}
+
+	query := "SELECT u.email, r.role, u.suspended_at IS NOT NULL FROM users u JOIN roles r ON r.id = u.roleID WHERE u.id = ?"
+	mock.ExpectQuery(query).WithArgs(7).WillReturnError(errors.New("connection reset"))
+	mock.ExpectQuery(query).WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "role", "suspended"}).AddRow("student@gmail.com", "Student", false))
+
+	repository := refresh_token_repository.NewRefreshTokenRepository(db, logrus.New(), rdb)
+	request := request_models.RefreshTokenRequest{RefreshToken: token}
+
+	if _, code, _ := repository.RefreshRepository(request); code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 while the user cannot be read, got %d", code)
+	}
+
+	// The failed attempt did not consume the token, so retrying it is not mistaken for a replay.
+	if _, code, err := repository.RefreshRepository(request); code != http.StatusOK {
+		t.Errorf("expected the retry to succeed, got %d, %v", code, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRefreshToken_Unknown(t *testing.T) {
+	rdb := testRedis(t)
+
+	controller := auth_controller.NewRefreshTokenController(nil, logrus.New(), rdb)
+
+	body := []byte(`{"refresh_token":"does-not-exist"}`)
+	req := httptest.NewRequest(http.MethodPost, "/token/refresh", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	controller.RefreshToken(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an unknown refresh token, got %d", rr.Code)
+	}
+}
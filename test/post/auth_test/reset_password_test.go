@@ -0,0 +1,95 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/auth_controller"
+	"go-libraryschool/controllers/otp_email_controller"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResetPassword_ShortPassword(t *testing.T) {
+	controller := auth_controller.NewResetPasswordController(nil, logrus.New(), nil)
+
+	body := []byte(`{"reset_token":"abc","password":"123"}`)
+	req := httptest.NewRequest(http.MethodPost, "/reset-password", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	controller.ResetPassword(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a short password, got %d", rr.Code)
+	}
+}
+
+func TestResetPassword_MissingToken(t *testing.T) {
+	controller := auth_controller.NewResetPasswordController(nil, logrus.New(), nil)
+
+	body := []byte(`{"password":"secret123"}`)
+	req := httptest.NewRequest(http.MethodPost, "/reset-password", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	controller.ResetPassword(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a reset token, got %d", rr.Code)
+	}
+}
+
+func TestSendResetOtp_UnknownEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id, username, language, email_verified_at IS NOT NULL FROM users WHERE email = ?").
+		WithArgs("nobody@gmail.com").
+		WillReturnError(sql.ErrNoRows)
+
+	rdb := testRedis(t)
+
+	controller := otp_email_controller.NewOtpEmailController(db, logrus.New(), rdb)
+
+	body := []byte(`{"email":"nobody@gmail.com","purpose":"reset_password"}`)
+	req := httptest.NewRequest(http.MethodPost, "/otp/send-otp", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	controller.OtpEmail(rr, req)
+
+	// Unknown emails get the same answer as registered ones, and nothing is sent.
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200 for an unknown email, got %d", rr.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// testRedis connects to the Redis used by the auth tests and skips the test when it is not running.
+func testRedis(t *testing.T) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{
+		Addr: "localhost:6380",
+		DB:   10,
+	})
+
+	err := rdb.Ping(context.Background()).Err()
+	if err != nil {
+		rdb.Close()
+		t.Skipf("redis not available: %v", err)
+	}
+
+	t.Cleanup(func() {
+		rdb.FlushDB(context.Background())
+		rdb.Close()
+	})
+
+	return rdb
+}
@@ -0,0 +1,121 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/repository/refresh_token_repository"
+	"go-libraryschool/routes/session_routes"
+	"go-libraryschool/test/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessions_ListRevokeAndForceLogout(t *testing.T) {
+	rdb := testRedis(t)
+	middlewares.SetRedisClientMiddleware(rdb)
+	defer middlewares.SetRedisClientMiddleware(nil)
+	middlewares.SetPermissionChecker(testutil.StaticPermissions{
+		"Student": {identity.PermissionSessionManage},
+		"Manager": {identity.PermissionSessionManage, identity.PermissionSessionRevokeAny},
+	})
+	defer middlewares.SetPermissionChecker(nil)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	laptop, _, err := refresh_token_repository.Issue(ctx, rdb, 7, "laptop", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to issue session: %v", err)
+	}
+	phone, _, err := refresh_token_repository.Issue(ctx, rdb, 7, "phone", "10.0.0.2")
+	if err != nil {
+		t.Fatalf("failed to issue session: %v", err)
+	}
+	manager, _, err := refresh_token_repository.Issue(ctx, rdb, 1, "desk", "10.0.0.3")
+	if err != nil {
+		t.Fatalf("failed to issue session: %v", err)
+	}
+
+	studentToken, _ := helpers.GenerateToken(7, "student@gmail.com", "Student", laptop.FamilyID)
+	managerToken, _ := helpers.GenerateToken(1, "manager@gmail.com", "Manager", manager.FamilyID)
+
+	mux := http.NewServeMux()
+	session_routes.SessionRoute(mux, db, logrus.New(), rdb)
+
+	do := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	listSessions := func() []map[string]any {
+		rr := do(http.MethodGet, "/session/my-sessions", studentToken, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200 listing sessions, got %d", rr.Code)
+		}
+		var response struct {
+			Data []map[string]any `json:"data"`
+		}
+		_ = json.Unmarshal(rr.Body.Bytes(), &response)
+		return response.Data
+	}
+
+	sessions := listSessions()
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+	for _, session := range sessions {
+		current := session["session_id"] == laptop.FamilyID
+		if session["current"] != current {
+			t.Errorf("expected only the laptop session to be current, got %v", session)
+		}
+		if current && (session["user_agent"] != "laptop" || session["ip"] != "10.0.0.1") {
+			t.Errorf("expected the device of the laptop session, got %v", session)
+		}
+	}
+
+	if rr := do(http.MethodDelete, "/session/revoke-session", studentToken, map[string]string{"session_id": manager.FamilyID}); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 revoking another user's session, got %d", rr.Code)
+	}
+
+	if rr := do(http.MethodDelete, "/session/revoke-session", studentToken, map[string]string{"session_id": phone.FamilyID}); rr.Code != http.StatusOK {
+		t.Errorf("expected 200 revoking own session, got %d", rr.Code)
+	}
+	if sessions := listSessions(); len(sessions) != 1 {
+		t.Errorf("expected 1 session left, got %d", len(sessions))
+	}
+
+	if rr := do(http.MethodPost, "/session/force-logout", studentToken, map[string]int{"user_id": 7}); rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a student forcing a logout, got %d", rr.Code)
+	}
+
+	mock.ExpectQuery("SELECT id FROM users WHERE id = ?").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	if rr := do(http.MethodPost, "/session/force-logout", managerToken, map[string]int{"user_id": 7}); rr.Code != http.StatusOK {
+		t.Errorf("expected 200 forcing a logout, got %d", rr.Code)
+	}
+
+	if rr := do(http.MethodGet, "/session/my-sessions", studentToken, nil); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 after a forced logout, got %d", rr.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
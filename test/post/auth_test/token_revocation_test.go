@@ -0,0 +1,77 @@
+package auth_test
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func pingWithToken(token string) int {
+	handler := middlewares.JWTMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestRevokeUserTokens_SameSecond(t *testing.T) {
+	rdb := testRedis(t)
+	middlewares.SetRedisClientMiddleware(rdb)
+	defer middlewares.SetRedisClientMiddleware(nil)
+
+	before, _ := helpers.GenerateToken(7, "student@gmail.com", "Student", "")
+
+	if err := middlewares.RevokeUserTokens(context.Background(), 7); err != nil {
+		t.Fatalf("failed to revoke tokens: %v", err)
+	}
+
+	// Issued within the same second as the revocation, but before it.
+	if code := pingWithToken(before); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a token issued before the revocation, got %d", code)
+	}
+
+	// Issue times are whole seconds, a token gets through once it is issued in a later second.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	after, _ := helpers.GenerateToken(7, "student@gmail.com", "Student", "")
+	if code := pingWithToken(after); code != http.StatusOK {
+		t.Errorf("expected a token issued after the revocation to get through, got %d", code)
+	}
+}
+
+func TestJWTMiddleware_RevocationCheckFailsClosed(t *testing.T) {
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer rdb.Close()
+
+	middlewares.SetRedisClientMiddleware(rdb)
+	defer middlewares.SetRedisClientMiddleware(nil)
+
+	token, _ := helpers.GenerateToken(7, "student@gmail.com", "Student", "")
+	if code := pingWithToken(token); code != http.StatusInternalServerError {
+		t.Errorf("expected 500 when the revocation markers cannot be read, got %d", code)
+	}
+}
+
+func TestJWTMiddleware_SessionCheckFailsClosed(t *testing.T) {
+	rdb := testRedis(t)
+	middlewares.SetRedisClientMiddleware(rdb)
+	defer middlewares.SetRedisClientMiddleware(nil)
+
+	// A session that cannot be read must not be mistaken for one that is still active.
+	if err := rdb.Set(context.Background(), "refresh-family:session-19", "{corrupt", time.Minute).Err(); err != nil {
+		t.Fatalf("failed to store the session: %v", err)
+	}
+
+	token, _ := helpers.GenerateToken(7, "student@gmail.com", "Student", "session-19")
+	if code := pingWithToken(token); code != http.StatusInternalServerError {
+		t.Errorf("expected 500 when the session cannot be read, got %d", code)
+	}
+}
@@ -0,0 +1,147 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/helpers"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/repository/refresh_token_repository"
+	"go-libraryschool/repository/user_repository"
+	"go-libraryschool/routes/user_routes"
+	"go-libraryschool/test/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUserAdmin_SuspendAndReactivate(t *testing.T) {
+	rdb := testRedis(t)
+	middlewares.SetRedisClientMiddleware(rdb)
+	defer middlewares.SetRedisClientMiddleware(nil)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	middlewares.SetPermissionChecker(testutil.StaticPermissions{"Manager": {identity.PermissionUserManage}})
+	defer middlewares.SetPermissionChecker(nil)
+	middlewares.SetSuspensionChecker(user_repository.NewUserRepository(db, logrus.New(), rdb))
+	defer middlewares.SetSuspensionChecker(nil)
+
+	ctx := context.Background()
+	manager, _, err := refresh_token_repository.Issue(ctx, rdb, 1, "desk", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to issue session: %v", err)
+	}
+	student, _, err := refresh_token_repository.Issue(ctx, rdb, 7, "laptop", "10.0.0.2")
+	if err != nil {
+		t.Fatalf("failed to issue session: %v", err)
+	}
+
+	managerToken, _ := helpers.GenerateToken(1, "manager@gmail.com", "Manager", manager.FamilyID)
+	studentToken, _ := helpers.GenerateToken(7, "student@gmail.com", "Student", student.FamilyID)
+
+	mux := http.NewServeMux()
+	user_routes.UserRoute(mux, db, logrus.New(), rdb)
+	mux.Handle("/ping", middlewares.JWTMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	do := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	expectStatus := func(userID int, suspended bool) {
+		mock.ExpectQuery("SELECT suspended_at IS NOT NULL FROM users WHERE id = ?").
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"suspended"}).AddRow(suspended))
+	}
+	expectStudent := func(suspendedAt string) {
+		mock.ExpectQuery("FROM users u JOIN roles r ON r.id = u.roleID WHERE u.id = ?").
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "roleID", "role", "language", "email_verified", "suspended_at", "suspended_reason", "created_at"}).
+				AddRow(7, "student", "student@gmail.com", 3, "Student", "id", true, suspendedAt, "", "2026-01-05 08:00:00"))
+	}
+
+	// The entry names the manager as the actor and the student as the entity.
+	expectAudit := func(action string) {
+		mock.ExpectExec("INSERT INTO audit_log").
+			WithArgs(int64(1), "Manager", action, identity.AuditEntityUser, "7", sqlmock.AnyArg(), sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), http.MethodPost, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+
+	expectStatus(7, false)
+	if rr := do(http.MethodGet, "/ping", studentToken, nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected an active student to get through, got %d", rr.Code)
+	}
+
+	if rr := do(http.MethodGet, "/user/get-users", studentToken, nil); rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a student listing users, got %d", rr.Code)
+	}
+
+	expectStatus(1, false)
+	expectStudent("")
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET suspended_at").
+		WithArgs(sqlmock.AnyArg(), "lost card", 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(identity.AuditActionUserSuspend)
+	mock.ExpectCommit()
+
+	if rr := do(http.MethodPost, "/user/suspend-user", managerToken, map[string]any{"user_id": 7, "reason": "lost card"}); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 suspending the student, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := do(http.MethodGet, "/ping", studentToken, nil); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected the sessions of a suspended user to be revoked, got %d", rr.Code)
+	}
+
+	// A token that is otherwise valid, e.g. issued right before the suspension reached this instance.
+	// Issue times are whole seconds, so it has to come from a later second than the revocation.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	later, _, _ := refresh_token_repository.Issue(ctx, rdb, 7, "phone", "10.0.0.3")
+	laterToken, _ := helpers.GenerateToken(7, "student@gmail.com", "Student", later.FamilyID)
+
+	expectStatus(7, true)
+	if rr := do(http.MethodGet, "/ping", laterToken, nil); rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a suspended user with a valid token, got %d", rr.Code)
+	}
+
+	if rr := do(http.MethodPost, "/user/suspend-user", managerToken, map[string]any{"user_id": 1}); rr.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a manager suspending themselves, got %d", rr.Code)
+	}
+
+	expectStudent("2026-10-17 09:00:00")
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET suspended_at = NULL").
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(identity.AuditActionUserReactivate)
+	mock.ExpectCommit()
+
+	if rr := do(http.MethodPost, "/user/reactivate-user", managerToken, map[string]int{"user_id": 7}); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 reactivating the student, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	expectStatus(7, false)
+	if rr := do(http.MethodGet, "/ping", laterToken, nil); rr.Code != http.StatusOK {
+		t.Errorf("expected a reactivated user to get through, got %d", rr.Code)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
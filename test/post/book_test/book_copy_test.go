@@ -0,0 +1,136 @@
+package book_test
+
+import (
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/book_copy_repository"
+	"go-libraryschool/repository/management_book_repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// unreachableRedis lets the books cache invalidation fail quietly, as it does when Redis is down.
+func unreachableRedis(t *testing.T) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+func TestUpdateCopy_LostFlagsOpenLoan(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repository := book_copy_repository.NewBookCopyRepository(db, logrus.New(), unreachableRedis(t))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM book_copies WHERE copy_id = \\? FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"copy_id", "book_id", "barcode", "accession_number", "condition", "shelf_location", "status", "created_at"}).
+			AddRow(3, 1, "BK000001-003", "ACC-1-3", "good", "A1", identity.CopyStatusOnLoan, "2026-01-01 00:00:00"))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM borrowed_books WHERE copy_id = \\? AND returned_at IS NULL").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec("UPDATE book_copies SET").
+		WithArgs("good", "A1", identity.CopyStatusLost, sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE borrowed_books SET lost_at").
+		WithArgs(sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE books").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	req := httptest.NewRequest(http.MethodPut, "/book/update-copy", nil)
+	_, code, err := repository.UpdateCopyRepository(req, request_models.BookCopyUpdate{CopyID: 3, Status: identity.CopyStatusLost})
+	if err != nil || code != http.StatusOK {
+		t.Fatalf("expected the copy to be reported lost, got %d, %v", code, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestUpdateCopy_LostCopyWithOpenLoanStaysLost(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repository := book_copy_repository.NewBookCopyRepository(db, logrus.New(), unreachableRedis(t))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM book_copies WHERE copy_id = \\? FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"copy_id", "book_id", "barcode", "accession_number", "condition", "shelf_location", "status", "created_at"}).
+			AddRow(3, 1, "BK000001-003", "ACC-1-3", "good", "A1", identity.CopyStatusLost, "2026-01-01 00:00:00"))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM borrowed_books WHERE copy_id = \\? AND returned_at IS NULL").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	req := httptest.NewRequest(http.MethodPut, "/book/update-copy", nil)
+	_, code, _ := repository.UpdateCopyRepository(req, request_models.BookCopyUpdate{CopyID: 3, Status: identity.CopyStatusAvailable})
+	if code != http.StatusConflict {
+		t.Errorf("expected 409 making a lost copy available while its loan is open, got %d", code)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestReturnBook_LostCopyStaysLost(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repository := management_book_repository.NewManagementBookRepository(db, logrus.New(), unreachableRedis(t))
+	dueDate := time.Now().AddDate(0, 0, 3).Format("2006-01-02")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT book_id, copy_id, user_id, return_date, returned_at, lost_at FROM borrowed_books").
+		WithArgs(11).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "copy_id", "user_id", "return_date", "returned_at", "lost_at"}).
+			AddRow(1, 3, 7, dueDate, nil, "2026-10-10 10:00:00"))
+	mock.ExpectQuery("SELECT u.roleID, b.genre_id FROM users u, books b").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"roleID", "genre_id"}).AddRow(2, 5))
+	mock.ExpectQuery("FROM circulation_policies p").WillReturnRows(sqlmock.NewRows(policyColumns))
+	mock.ExpectExec("UPDATE borrowed_books SET returned_at").
+		WithArgs(sqlmock.AnyArg(), 11).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Only the stock is recomputed, the copy is neither put back on the shelf nor handed to a hold.
+	mock.ExpectExec("UPDATE books").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	req := httptest.NewRequest(http.MethodPost, "/book/return-book", nil)
+	_, code, err := repository.ReturnBookRepository(req, request_models.BookReturnRequest{BorrowedID: 11})
+	if err != nil || code != http.StatusOK {
+		t.Fatalf("expected the loan to be returned, got %d, %v", code, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGenerateBarcode_Unique(t *testing.T) {
+	if book_copy_repository.GenerateBarcode(1234567, 1) == book_copy_repository.GenerateBarcode(123456, 7001) {
+		t.Errorf("expected barcodes of different copies to differ")
+	}
+	if barcode := book_copy_repository.GenerateBarcode(12, 3); barcode != "BK000012-003" {
+		t.Errorf("expected BK000012-003, got %s", barcode)
+	}
+}
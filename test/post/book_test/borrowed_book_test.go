@@ -0,0 +1,79 @@
+package book_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-libraryschool/controllers/management_book_controller"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/test/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+)
+
+func borrowRequest(t *testing.T, claims *jwt_models.JWTClaims, body map[string]any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	controller := management_book_controller.NewManagementBookController(db, logrus.New(), nil)
+
+	middlewares.SetPermissionChecker(testutil.StaticPermissions{"Librarian": {identity.PermissionLoanManage}})
+	defer middlewares.SetPermissionChecker(nil)
+
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/book/borrowed-book", bytes.NewBuffer(jsonBody))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserContextKey, claims))
+
+	rr := httptest.NewRecorder()
+	controller.BorrowedBook(rr, req)
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected database access: %v", err)
+	}
+
+	return rr
+}
+
+func TestBorrowedBook_StudentForAnotherUser(t *testing.T) {
+	claims := &jwt_models.JWTClaims{UserID: 7, Roles: "Student"}
+
+	rr := borrowRequest(t, claims, map[string]any{"book_id": 1, "user_id": 8})
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", rr.Code)
+	}
+}
+
+func TestBorrowedBook_StudentSetsLoanDates(t *testing.T) {
+	claims := &jwt_models.JWTClaims{UserID: 7, Roles: "Student"}
+
+	bodies := []map[string]any{
+		{"book_id": 1, "return_date": "2027-12-31"},
+		{"book_id": 1, "borrowed_date": "2026-01-01"},
+	}
+	for _, body := range bodies {
+		rr := borrowRequest(t, claims, body)
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("expected status 403 for %v, got %d", body, rr.Code)
+		}
+	}
+}
+
+func TestBorrowedBook_StaffWithoutBorrower(t *testing.T) {
+	claims := &jwt_models.JWTClaims{UserID: 2, Roles: "Librarian"}
+
+	rr := borrowRequest(t, claims, map[string]any{"book_id": 1})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,36 @@
+package book_test
+
+import (
+	"context"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/repository/hold_repository"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestClaimReadyHold_PastPickupDeadline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	// The hold is still ready because expiry has not run yet, but its deadline has passed.
+	mock.ExpectQuery("SELECT hold_id, copy_id FROM holds WHERE book_id = \\? AND user_id = \\? AND status = \\? AND pickup_deadline >= \\?").
+		WithArgs(1, 7, identity.HoldStatusReady, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"hold_id", "copy_id"}))
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, claimed, err := hold_repository.ClaimReadyHold(context.Background(), tx, 1, 7)
+	if err != nil || claimed {
+		t.Errorf("expected a hold past its pickup deadline not to be claimed, got %v, %v", claimed, err)
+	}
+}
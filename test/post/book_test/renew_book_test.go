@@ -0,0 +1,51 @@
+package book_test
+
+import (
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/management_book_repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+)
+
+var policyColumns = []string{"policy_id", "role_id", "role", "genre_id", "genre", "loan_period_days", "daily_fine", "max_fine",
+	"grace_days", "currency", "max_loans", "max_unpaid_fine", "max_renewals", "created_at", "updated_at"}
+
+func TestRenewBook_PolicyRenewalLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repository := management_book_repository.NewManagementBookRepository(db, logrus.New(), nil)
+	dueDate := time.Now().AddDate(0, 0, 3).Format("2006-01-02")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT book_id, user_id, return_date, returned_at, renewal_count FROM borrowed_books").
+		WithArgs(11).
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "user_id", "return_date", "returned_at", "renewal_count"}).
+			AddRow(1, 7, dueDate, nil, 1))
+	mock.ExpectQuery("SELECT u.roleID, b.genre_id FROM users u, books b").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"roleID", "genre_id"}).AddRow(2, 5))
+	mock.ExpectQuery("FROM circulation_policies p").
+		WillReturnRows(sqlmock.NewRows(policyColumns).
+			AddRow(1, 0, "", 0, "", 7, 2000000, 0, 0, "IDR", 3, 0, 2, "2026-01-01 00:00:00", "2026-01-01 00:00:00").
+			AddRow(2, 0, "", 5, "Reference", 3, 2000000, 0, 0, "IDR", 0, 0, 1, "2026-01-01 00:00:00", "2026-01-01 00:00:00"))
+	mock.ExpectRollback()
+
+	req := httptest.NewRequest(http.MethodPost, "/book/renew-book", nil)
+	_, code, err := repository.RenewBookRepository(req, request_models.BookRenewRequest{BorrowedID: 11}, 7, false)
+	if err == nil || code != http.StatusConflict {
+		t.Errorf("expected 409 once the renewal limit of the genre policy is reached, got %d, %v", code, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package testutil
+
+import "context"
+
+// StaticPermissions stands in for the role_permissions table, mapping each role to the permissions it is granted.
+type StaticPermissions map[string][]string
+
+func (permissions StaticPermissions) HasPermission(_ context.Context, role, permission string) (bool, error) {
+	for _, granted := range permissions[role] {
+		if granted == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FailingPermissions fails every check, as the role_permissions table does while the database is down.
+type FailingPermissions struct {
+	Err error
+}
+
+func (permissions FailingPermissions) HasPermission(_ context.Context, _, _ string) (bool, error) {
+	return false, permissions.Err
+}
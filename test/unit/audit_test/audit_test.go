@@ -0,0 +1,156 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/csv"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/controllers/audit_controller"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/audit_repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var auditColumns = []string{"audit_id", "actor_id", "actor_role", "action", "entity_type", "entity_id", "before_data",
+	"after_data", "ip", "user_agent", "method", "path", "created_at"}
+
+func TestRecord_ActorAndRequestMetadata(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	req := httptest.NewRequest(http.MethodDelete, "/book/delete-book?id=12", nil)
+	req.RemoteAddr = "192.0.2.10:51234"
+	req.Header.Set("User-Agent", "desk-client/1.0")
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserContextKey, &jwt_models.JWTClaims{UserID: 3, Roles: "Librarian"}))
+
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs(int64(3), "Librarian", identity.AuditActionBookDelete, identity.AuditEntityBook, "12",
+			`{"title":"Dune"}`, nil, "192.0.2.10", "desk-client/1.0", http.MethodDelete, "/book/delete-book", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = audit_repository.Record(context.Background(), db, req, audit_repository.Event{
+		Action:     identity.AuditActionBookDelete,
+		EntityType: identity.AuditEntityBook,
+		EntityID:   12,
+		Before:     map[string]string{"title": "Dune"},
+	})
+	if err != nil {
+		t.Fatalf("expected the event to be recorded, got %v", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestRecord_WithoutActor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/fine/waive-fine", nil)
+
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs(nil, "", identity.AuditActionFineWaive, identity.AuditEntityFine, "4", nil, sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), http.MethodPost, "/fine/waive-fine", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = audit_repository.Record(context.Background(), db, req, audit_repository.Event{
+		Action:     identity.AuditActionFineWaive,
+		EntityType: identity.AuditEntityFine,
+		EntityID:   4,
+		After:      map[string]string{"status": "waived"},
+	})
+	if err != nil {
+		t.Fatalf("expected the event to be recorded, got %v", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestAuditLog_InvalidFilter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repository := audit_repository.NewAuditRepository(db, logrus.New())
+	req := httptest.NewRequest(http.MethodGet, "/audit/get-logs", nil)
+
+	filters := map[string]request_models.AuditFilter{
+		"malformed date":         {From: "17-10-2026"},
+		"from after to":          {From: "2026-10-17", To: "2026-10-01"},
+		"entity id without type": {EntityID: "12"},
+	}
+
+	for name, filter := range filters {
+		_, code, err := repository.GetAuditLogRepository(req, filter)
+		if err == nil || code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d, %v", name, code, err)
+		}
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expected no queries for invalid filters: %v", err)
+	}
+}
+
+func TestExportAuditLog_CSV(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM audit_log WHERE action = \\? AND created_at >= \\?").
+		WithArgs(identity.AuditActionUserSuspend, "2026-10-01", 50001, 0).
+		WillReturnRows(sqlmock.NewRows(auditColumns).
+			AddRow(9, 1, "Manager", identity.AuditActionUserSuspend, identity.AuditEntityUser, "7", `{"suspended":false}`,
+				`{"suspended":true}`, "192.0.2.10", "=HYPERLINK(\"http://example.com\")", http.MethodPost, "/user/suspend-user", "2026-10-17 09:00:00"))
+
+	controller := audit_controller.NewAuditController(db, logrus.New())
+	rr := httptest.NewRecorder()
+	controller.ExportAuditLog(rr, httptest.NewRequest(http.MethodGet, "/audit/export-logs?action=user.suspend&from=2026-10-01", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+		t.Errorf("expected a CSV content type, got %q", contentType)
+	}
+	if disposition := rr.Header().Get("Content-Disposition"); !strings.HasPrefix(disposition, "attachment;") {
+		t.Errorf("expected the export to be an attachment, got %q", disposition)
+	}
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read the export: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected a header and one entry, got %d records", len(records))
+	}
+	if records[1][4] != identity.AuditActionUserSuspend || records[1][8] != `{"suspended":true}` {
+		t.Errorf("unexpected entry %v", records[1])
+	}
+	if userAgent := records[1][10]; !strings.HasPrefix(userAgent, "'=") {
+		t.Errorf("expected a value starting like a formula to be escaped, got %q", userAgent)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
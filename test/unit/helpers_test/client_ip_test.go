@@ -0,0 +1,42 @@
+package helpers_test
+
+import (
+	"go-libraryschool/helpers"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.7:51234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.1")
+
+	t.Setenv("TRUST_PROXY_HEADERS", "")
+	if got := helpers.ClientIP(req); got != "10.0.0.7" {
+		t.Errorf("expected the remote address when proxy headers are not trusted, got %q", got)
+	}
+
+	t.Setenv("TRUST_PROXY_HEADERS", "true")
+	if got := helpers.ClientIP(req); got != "203.0.113.9" {
+		t.Errorf("expected the first forwarded address, got %q", got)
+	}
+}
+
+func TestNewRetryAfter(t *testing.T) {
+	tests := []struct {
+		wait     time.Duration
+		expected int
+	}{
+		{0, 1},
+		{-time.Second, 1},
+		{1500 * time.Millisecond, 2},
+		{time.Minute, 60},
+	}
+
+	for _, tt := range tests {
+		if got := helpers.NewRetryAfter(tt.wait).Seconds; got != tt.expected {
+			t.Errorf("NewRetryAfter(%s): expected %d, got %d", tt.wait, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package helpers_test
+
+import (
+	"go-libraryschool/helpers"
+	"testing"
+)
+
+func TestFormatMinorUnits(t *testing.T) {
+	formatter := helpers.NewFormatterMoney()
+
+	tests := []struct {
+		amount   int
+		currency string
+		expected string
+	}{
+		{2000000, "IDR", "Rp20.000"},
+		{123456789, "USD", "$1,234,567.89"},
+		{1500, "JPY", "¥1,500.00"},
+		{-250, "EUR", "€-2.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.currency, func(t *testing.T) {
+			formatted := formatter.FormatMinorUnits(tt.amount, tt.currency)
+			if formatted != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, formatted)
+			}
+		})
+	}
+}
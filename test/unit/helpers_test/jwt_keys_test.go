@@ -0,0 +1,153 @@
+package helpers_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/jwt_models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testClaims() *jwt_models.JWTClaims {
+	return &jwt_models.JWTClaims{
+		UserID: 7,
+		Roles:  "Student",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+}
+
+func TestKeyManager_Rotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+
+	manager := helpers.NewKeyManager()
+	manager.Add(helpers.SigningKey{ID: "old", Method: jwt.SigningMethodHS256, SignKey: []byte("secret"), VerifyKey: []byte("secret")})
+	manager.Add(helpers.SigningKey{ID: "new", Method: jwt.SigningMethodRS256, SignKey: rsaKey, VerifyKey: &rsaKey.PublicKey})
+	manager.SetFallbackKey("old")
+
+	if err := manager.SetSigningKey("old"); err != nil {
+		t.Fatalf("failed to select signing key: %v", err)
+	}
+	oldToken, err := manager.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	if err := manager.SetSigningKey("new"); err != nil {
+		t.Fatalf("failed to select signing key: %v", err)
+	}
+	newToken, err := manager.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	for name, tokenStr := range map[string]string{"old": oldToken, "new": newToken} {
+		token, err := manager.Parse(tokenStr, &jwt_models.JWTClaims{})
+		if err != nil || !token.Valid {
+			t.Errorf("expected the %s token to verify after rotation, got %v", name, err)
+			continue
+		}
+		if token.Header["kid"] != name {
+			t.Errorf("expected kid %q, got %v", name, token.Header["kid"])
+		}
+	}
+
+	// Tokens from before key IDs were introduced carry no kid and use the fallback key.
+	legacy, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims()).SignedString([]byte("secret"))
+	if _, err := manager.Parse(legacy, &jwt_models.JWTClaims{}); err != nil {
+		t.Errorf("expected a token without kid to verify with the fallback key, got %v", err)
+	}
+
+	manager.Remove("old")
+	if _, err := manager.Parse(oldToken, &jwt_models.JWTClaims{}); err == nil {
+		t.Error("expected a token of a retired key to be rejected")
+	}
+}
+
+func TestKeyManager_RejectsAlgorithmMismatch(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+
+	manager := helpers.NewKeyManager()
+	manager.Add(helpers.SigningKey{ID: "rsa", Method: jwt.SigningMethodRS256, VerifyKey: &rsaKey.PublicKey})
+
+	// An HS256 token signed with the public key must not pass as a token of the RSA key.
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	forged.Header["kid"] = "rsa"
+	forgedStr, _ := forged.SignedString(publicPEM)
+
+	if _, err := manager.Parse(forgedStr, &jwt_models.JWTClaims{}); err == nil {
+		t.Error("expected a token whose algorithm does not match its key to be rejected")
+	}
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	unknown.Header["kid"] = "missing"
+	unknownStr, _ := unknown.SignedString([]byte("secret"))
+	if _, err := manager.Parse(unknownStr, &jwt_models.JWTClaims{}); err == nil {
+		t.Error("expected a token with an unknown kid to be rejected")
+	}
+}
+
+func TestKeyManagerFromEnv_PEMKeysAndJWKS(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ed.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	t.Setenv("JWT_KEY", "secret")
+	t.Setenv("JWT_KEY_ID", "hs-1")
+	t.Setenv("JWT_PREVIOUS_KEYS", "hs-0=older-secret")
+	t.Setenv("JWT_KEY_FILES", "ed-1="+path)
+	t.Setenv("JWT_SIGNING_KEY_ID", "ed-1")
+
+	manager, err := helpers.NewKeyManagerFromEnv()
+	if err != nil {
+		t.Fatalf("failed to load keys: %v", err)
+	}
+
+	tokenStr, err := manager.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	token, err := manager.Parse(tokenStr, &jwt_models.JWTClaims{})
+	if err != nil || token.Method.Alg() != "EdDSA" {
+		t.Fatalf("expected an EdDSA token that verifies, got %v", err)
+	}
+
+	jwks := manager.JWKS()
+	if len(jwks.Keys) != 1 {
+		t.Fatalf("expected only the Ed25519 key to be published, got %+v", jwks.Keys)
+	}
+	if key := jwks.Keys[0]; key.Kid != "ed-1" || key.Kty != "OKP" || key.Crv != "Ed25519" || key.X == "" {
+		t.Errorf("unexpected JWK %+v", key)
+	}
+
+	t.Setenv("JWT_SIGNING_KEY_ID", "hs-0")
+	if _, err := helpers.NewKeyManagerFromEnv(); err == nil {
+		t.Error("expected an error when signing with a verify-only key")
+	}
+}
@@ -0,0 +1,51 @@
+package helpers_test
+
+import (
+	"go-libraryschool/helpers"
+	"net/url"
+	"testing"
+)
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		query   string
+		page    int
+		perPage int
+		offset  int
+		invalid bool
+	}{
+		{"", 1, helpers.DefaultPerPage, 0, false},
+		{"page=3&per_page=10", 3, 10, 20, false},
+		{"page=0", 0, 0, 0, true},
+		{"per_page=1000", 0, 0, 0, true},
+		{"page=abc", 0, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+
+			pagination, err := helpers.ParsePagination(query)
+			if tt.invalid {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", pagination)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if pagination.Page != tt.page || pagination.PerPage != tt.perPage || pagination.Offset() != tt.offset {
+				t.Errorf("expected page %d, per_page %d, offset %d, got %+v offset %d", tt.page, tt.perPage, tt.offset, pagination, pagination.Offset())
+			}
+		})
+	}
+}
+
+func TestNewPaginatedData(t *testing.T) {
+	data := helpers.NewPaginatedData([]int{}, helpers.Pagination{Page: 2, PerPage: 20}, 41)
+	if data.TotalPages != 3 || data.Total != 41 {
+		t.Errorf("expected 3 pages of 41 rows, got %+v", data)
+	}
+}
@@ -0,0 +1,74 @@
+package mailer_test
+
+import (
+	"context"
+	"errors"
+	"go-libraryschool/mailer"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{0, 0},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{7, 64 * time.Minute},
+		{8, 2 * time.Hour},
+		{30, 2 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := mailer.Backoff(tt.attempts); got != tt.expected {
+			t.Errorf("Backoff(%d): expected %s, got %s", tt.attempts, tt.expected, got)
+		}
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	memory := mailer.NewMemoryMailer()
+	message := mailer.Message{To: "student@example.com", Subject: "Hello", Text: "Hi", HTML: "<p>Hi</p>"}
+
+	err := memory.Send(context.Background(), message)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	memory.Err = errors.New("smtp down")
+	if err = memory.Send(context.Background(), message); err == nil {
+		t.Errorf("expected the configured error")
+	}
+
+	if messages := memory.Messages(); len(messages) != 1 || messages[0] != message {
+		t.Errorf("expected one recorded message, got %+v", messages)
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	message := mailer.Message{To: "student@example.com", Subject: "Kode OTP", Text: "123456", HTML: "<b>123456</b>"}
+
+	err := mailer.NewFileMailer(dir).Send(context.Background(), message)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("expected one mail file, got %d", len(files))
+	}
+
+	content, _ := os.ReadFile(files[0])
+	for _, expected := range []string{"To: student@example.com", "Subject: Kode OTP", "123456", "<b>123456</b>"} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected mail file to contain %q", expected)
+		}
+	}
+}
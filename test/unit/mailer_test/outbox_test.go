@@ -0,0 +1,102 @@
+package mailer_test
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/mailer"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/repository/mail_outbox_repository"
+	"testing"
+)
+
+// expectClaim expects a message to be leased in a transaction of its own, committed before anything is sent.
+func expectClaim(mock sqlmock.Sqlmock, mailID, attempts int) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT recipient, subject, html_body, text_body, attempts FROM mail_outbox").
+		WillReturnRows(sqlmock.NewRows([]string{"recipient", "subject", "html_body", "text_body", "attempts"}).
+			AddRow("student@gmail.com", "Your code", "<p>123456</p>", "123456", attempts))
+	mock.ExpectExec("UPDATE mail_outbox SET status = \\?, attempts = \\?, locked_until = \\?").
+		WithArgs(identity.MailStatusSending, attempts+1, sqlmock.AnyArg(), sqlmock.AnyArg(), mailID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func TestOutboxDeliver_SendsOutsideTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	memory := mailer.NewMemoryMailer()
+	defer mailer.SetDefault(mailer.Default())
+	mailer.SetDefault(memory)
+
+	expectClaim(mock, 4, 0)
+	mock.ExpectExec("UPDATE mail_outbox SET status = \\?, html_body = '', text_body = ''").
+		WithArgs(identity.MailStatusSent, sqlmock.AnyArg(), sqlmock.AnyArg(), 4, identity.MailStatusSending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	sent, err := mail_outbox_repository.NewMailOutboxRepository(db, logrus.New()).Deliver(context.Background(), 4)
+	if err != nil || !sent {
+		t.Fatalf("expected the mail to be sent, got %v, %v", sent, err)
+	}
+	if len(memory.Messages()) != 1 {
+		t.Errorf("expected one message, got %d", len(memory.Messages()))
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestOutboxDeliver_FailureKeepsMessageQueued(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	memory := mailer.NewMemoryMailer()
+	memory.Err = errors.New("smtp unavailable")
+	defer mailer.SetDefault(mailer.Default())
+	mailer.SetDefault(memory)
+
+	expectClaim(mock, 4, mailer.MaxAttempts-1)
+	mock.ExpectExec("UPDATE mail_outbox SET status = \\?, last_error = \\?, locked_until = NULL").
+		WithArgs(identity.MailStatusFailed, "smtp unavailable", sqlmock.AnyArg(), sqlmock.AnyArg(), 4, identity.MailStatusSending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	sent, err := mail_outbox_repository.NewMailOutboxRepository(db, logrus.New()).Deliver(context.Background(), 4)
+	if err != nil || sent {
+		t.Fatalf("expected a failed delivery without an outbox error, got %v, %v", sent, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestOutboxDeliver_ClaimedElsewhere(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT recipient, subject, html_body, text_body, attempts FROM mail_outbox").
+		WillReturnRows(sqlmock.NewRows([]string{"recipient", "subject", "html_body", "text_body", "attempts"}))
+	mock.ExpectRollback()
+
+	sent, err := mail_outbox_repository.NewMailOutboxRepository(db, logrus.New()).Deliver(context.Background(), 4)
+	if err != nil || sent {
+		t.Errorf("expected a leased message to be left alone, got %v, %v", sent, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package mailer_test
+
+import (
+	"go-libraryschool/mailer"
+	"strings"
+	"testing"
+)
+
+func TestRenderAllTemplates(t *testing.T) {
+	data := map[string]any{
+		mailer.TemplateOtp:           mailer.OtpData{Otp: "123456", Minutes: 2},
+		mailer.TemplatePasswordReset: mailer.PasswordResetData{Username: "budi", Otp: "654321", Minutes: 10},
+		mailer.TemplateVerifyEmail:   mailer.VerifyEmailData{Username: "budi", Otp: "111111", Minutes: 2},
+		mailer.TemplateAccountLocked: mailer.AccountLockedData{Username: "budi", Attempts: 5, IP: "203.0.113.9", Minutes: 15},
+		mailer.TemplateHoldReady:     mailer.HoldReadyData{Username: "budi", Title: "Laskar Pelangi", PickupDeadline: "2025-05-03 10:00"},
+		mailer.TemplateLoanReminder: mailer.LoanReminderData{Username: "budi", Loans: []mailer.ReminderLoan{
+			{Title: "Laskar Pelangi", ReturnDate: "2025-05-01", DaysLate: 3, Fine: "Rp 60.000,00"},
+		}},
+	}
+
+	for _, language := range mailer.Languages {
+		for name, values := range data {
+			message, err := mailer.Render("budi@gmail.com", name, language, values)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", language, name, err)
+			}
+
+			if message.To != "budi@gmail.com" || message.Subject == "" || message.Text == "" {
+				t.Errorf("%s/%s: incomplete message %+v", language, name, message)
+			}
+			if !strings.Contains(message.HTML, `<html lang="`+language+`">`) {
+				t.Errorf("%s/%s: layout not applied", language, name)
+			}
+			if strings.Contains(message.Text, "{{") || strings.Contains(message.Text, "subject") {
+				t.Errorf("%s/%s: unexpected text body %q", language, name, message.Text)
+			}
+		}
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	message, err := mailer.Render("budi@gmail.com", mailer.TemplateHoldReady, mailer.LanguageEnglish,
+		mailer.HoldReadyData{Username: "budi", Title: "<script>alert(1)</script>", PickupDeadline: "2025-05-03 10:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(message.HTML, "<script>") {
+		t.Errorf("title not escaped in HTML body")
+	}
+	if !strings.Contains(message.Text, "<script>alert(1)</script>") {
+		t.Errorf("title escaped in text body: %q", message.Text)
+	}
+}
+
+func TestRenderLoanReminderSubject(t *testing.T) {
+	dueSoon := mailer.LoanReminderData{Username: "budi", Loans: []mailer.ReminderLoan{{Title: "A", ReturnDate: "2025-05-02"}}}
+	message, err := mailer.Render("budi@gmail.com", mailer.TemplateLoanReminder, mailer.LanguageEnglish, dueSoon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Subject != "Reminder: books due tomorrow - Go-libraryschool" {
+		t.Errorf("unexpected subject %q", message.Subject)
+	}
+
+	overdue := mailer.LoanReminderData{Username: "budi", Loans: []mailer.ReminderLoan{{Title: "A", ReturnDate: "2025-05-01", DaysLate: 2}, {Title: "B", ReturnDate: "2025-05-02"}}}
+	message, err = mailer.Render("budi@gmail.com", mailer.TemplateLoanReminder, mailer.LanguageIndonesian, overdue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Subject != "Pengingat: 2 buku harus dikembalikan - Go-libraryschool" {
+		t.Errorf("unexpected subject %q", message.Subject)
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":               "id",
+		"en":             "en",
+		"EN":             "en",
+		"en-US,en;q=0.9": "en",
+		"id-ID":          "id",
+		"fr":             "id",
+	}
+
+	for input, expected := range tests {
+		if got := mailer.NormalizeLanguage(input); got != expected {
+			t.Errorf("NormalizeLanguage(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestSupportedLanguage(t *testing.T) {
+	if language, ok := mailer.SupportedLanguage(" EN-us "); !ok || language != mailer.LanguageEnglish {
+		t.Errorf("expected en for EN-us, got %q, %v", language, ok)
+	}
+	if _, ok := mailer.SupportedLanguage("fr"); ok {
+		t.Error("expected fr to be unsupported")
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	_, err := mailer.Render("budi@gmail.com", "missing", mailer.LanguageEnglish, nil)
+	if err == nil {
+		t.Fatal("expected an error for an unknown template")
+	}
+}
@@ -0,0 +1,155 @@
+package permission_test
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/identity"
+	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/models/request_models"
+	"go-libraryschool/repository/permission_repository"
+	"go-libraryschool/test/testutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func servePermission(claims *jwt_models.JWTClaims, permission string) int {
+	handler := middlewares.PermissionMiddleware(permission)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if claims != nil {
+		req = req.WithContext(context.WithValue(req.Context(), middlewares.UserContextKey, claims))
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestPermissionMiddleware(t *testing.T) {
+	librarian := &jwt_models.JWTClaims{UserID: 2, Roles: "Librarian"}
+
+	middlewares.SetPermissionChecker(testutil.StaticPermissions{"Librarian": {identity.PermissionBookCreate}})
+	defer middlewares.SetPermissionChecker(nil)
+
+	if code := servePermission(librarian, identity.PermissionBookCreate); code != http.StatusOK {
+		t.Errorf("expected a granted permission to pass, got %d", code)
+	}
+	if code := servePermission(librarian, identity.PermissionBookUpdate); code != http.StatusForbidden {
+		t.Errorf("expected 403 for a permission the role lacks, got %d", code)
+	}
+	if code := servePermission(nil, identity.PermissionBookCreate); code != http.StatusForbidden {
+		t.Errorf("expected 403 without claims, got %d", code)
+	}
+
+	middlewares.SetPermissionChecker(testutil.FailingPermissions{Err: errors.New("db down")})
+	if code := servePermission(librarian, identity.PermissionBookCreate); code != http.StatusForbidden {
+		t.Errorf("expected 403 when permissions cannot be checked, got %d", code)
+	}
+
+	middlewares.SetPermissionChecker(nil)
+	if code := servePermission(librarian, identity.PermissionBookCreate); code != http.StatusForbidden {
+		t.Errorf("expected 403 without a permission checker, got %d", code)
+	}
+}
+
+func TestPermissionRepository_GrantInvalidatesCache(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repository := permission_repository.NewPermissionRepository(db, logrus.New())
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT p.name FROM role_permissions").WithArgs("Archivist").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(identity.PermissionBookRead))
+
+	for i := 0; i < 2; i++ {
+		allowed, err := repository.HasPermission(ctx, "Archivist", identity.PermissionBookRead)
+		if err != nil || !allowed {
+			t.Fatalf("call %d: expected book:read to be granted, got %v, %v", i+1, allowed, err)
+		}
+	}
+
+	mock.ExpectQuery("SELECT role FROM roles").WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("Archivist"))
+	mock.ExpectQuery("SELECT permission_id FROM permissions").WithArgs(identity.PermissionBookUpdate).
+		WillReturnRows(sqlmock.NewRows([]string{"permission_id"}).AddRow(3))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT IGNORE INTO role_permissions").WithArgs(4, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO audit_log").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	req := httptest.NewRequest(http.MethodPost, "/role/grant-permission", nil)
+	_, code, err := repository.GrantPermissionRepository(req, request_models.RolePermissionRequest{RoleID: 4, Permission: identity.PermissionBookUpdate}, 1)
+	if err != nil || code != http.StatusOK {
+		t.Fatalf("expected the grant to succeed, got %d, %v", code, err)
+	}
+
+	mock.ExpectQuery("SELECT p.name FROM role_permissions").WithArgs("Archivist").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(identity.PermissionBookRead).AddRow(identity.PermissionBookUpdate))
+
+	allowed, err := repository.HasPermission(ctx, "Archivist", identity.PermissionBookUpdate)
+	if err != nil || !allowed {
+		t.Errorf("expected the grant to be seen right away, got %v, %v", allowed, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPermissionRepository_KeepsLastRoleManager(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repository := permission_repository.NewPermissionRepository(db, logrus.New())
+	request := request_models.RolePermissionRequest{RoleID: 1, Permission: identity.PermissionRoleManage}
+
+	mock.ExpectQuery("SELECT role FROM roles").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("Manager"))
+	mock.ExpectQuery("SELECT permission_id FROM permissions").WithArgs(identity.PermissionRoleManage).
+		WillReturnRows(sqlmock.NewRows([]string{"permission_id"}).AddRow(21))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT").WithArgs(21, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+
+	req := httptest.NewRequest(http.MethodDelete, "/role/revoke-permission", nil)
+	_, code, _ := repository.RevokePermissionRepository(req, request, 1)
+	if code != http.StatusConflict {
+		t.Errorf("expected 409 revoking role:manage from the last role holding it, got %d", code)
+	}
+
+	mock.ExpectQuery("SELECT role FROM roles").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("Manager"))
+	mock.ExpectQuery("SELECT permission_id FROM permissions").WithArgs(identity.PermissionRoleManage).
+		WillReturnRows(sqlmock.NewRows([]string{"permission_id"}).AddRow(21))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT").WithArgs(21, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM role_permissions").WithArgs(1, 21).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO audit_log").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	_, code, err = repository.RevokePermissionRepository(req, request, 1)
+	if err != nil || code != http.StatusOK {
+		t.Errorf("expected the revoke to succeed while another role manages roles, got %d, %v", code, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
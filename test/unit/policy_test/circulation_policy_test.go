@@ -0,0 +1,144 @@
+package policy_test
+
+import (
+	"go-libraryschool/helpers"
+	"go-libraryschool/models/identity"
+	"testing"
+	"time"
+)
+
+func date(value string) time.Time {
+	t, _ := time.Parse("2006-01-02", value)
+	return t
+}
+
+func TestResolveCirculationPolicy(t *testing.T) {
+	const (
+		studentRole   = 1
+		librarianRole = 3
+		referenceID   = 4
+		fictionID     = 5
+	)
+
+	policies := []identity.CirculationPolicy{
+		{PolicyID: 1, LoanPeriodDays: 7},
+		{PolicyID: 2, RoleID: librarianRole, LoanPeriodDays: 30},
+		{PolicyID: 3, GenreID: referenceID, LoanPeriodDays: 1},
+		{PolicyID: 4, RoleID: librarianRole, GenreID: referenceID, LoanPeriodDays: 3},
+	}
+
+	tests := []struct {
+		name     string
+		roleID   int
+		genreID  int
+		policyID int
+	}{
+		{"catch-all", studentRole, fictionID, 1},
+		{"role only", librarianRole, fictionID, 2},
+		{"genre beats role", studentRole, referenceID, 3},
+		{"role and genre", librarianRole, referenceID, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := helpers.ResolveCirculationPolicy(policies, tt.roleID, tt.genreID)
+			if policy.PolicyID != tt.policyID {
+				t.Errorf("expected policy %d, got %d", tt.policyID, policy.PolicyID)
+			}
+		})
+	}
+}
+
+func TestResolveCirculationPolicy_Default(t *testing.T) {
+	policies := []identity.CirculationPolicy{{PolicyID: 1, RoleID: 3, LoanPeriodDays: 30}}
+
+	policy := helpers.ResolveCirculationPolicy(policies, 1, 5)
+	if policy != helpers.DefaultCirculationPolicy {
+		t.Errorf("expected default policy, got %+v", policy)
+	}
+}
+
+func TestLoanDueDate(t *testing.T) {
+	policy := identity.CirculationPolicy{LoanPeriodDays: 14}
+
+	due := helpers.LoanDueDate(policy, time.Date(2025, 1, 25, 16, 30, 0, 0, time.Local))
+	if !due.Equal(date("2025-02-08")) {
+		t.Errorf("expected 2025-02-08, got %s", due.Format("2006-01-02"))
+	}
+}
+
+func TestCalculateFine(t *testing.T) {
+	policy := identity.CirculationPolicy{DailyFine: 20000, GraceDays: 2, MaxFine: 100000, Currency: "IDR"}
+	returnDate := date("2025-03-10")
+
+	tests := []struct {
+		name       string
+		at         time.Time
+		difference int
+		fine       int
+	}{
+		{"before due date", date("2025-03-09"), 0, 0},
+		{"on due date", time.Date(2025, 3, 10, 23, 59, 0, 0, time.UTC), 0, 0},
+		{"within grace", date("2025-03-12"), 2, 0},
+		{"after grace", date("2025-03-15"), 5, 60000},
+		{"capped", date("2025-04-10"), 31, 100000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			difference, fine := helpers.CalculateFine(policy, returnDate, tt.at)
+			if difference != tt.difference || fine != tt.fine {
+				t.Errorf("expected (%d, %d), got (%d, %d)", tt.difference, tt.fine, difference, fine)
+			}
+		})
+	}
+}
+
+func TestCalculateFine_NoCap(t *testing.T) {
+	policy := identity.CirculationPolicy{DailyFine: 20000}
+
+	difference, fine := helpers.CalculateFine(policy, date("2025-03-10"), date("2025-04-10"))
+	if difference != 31 || fine != 620000 {
+		t.Errorf("expected (31, 620000), got (%d, %d)", difference, fine)
+	}
+}
+
+func TestCheckBorrowingEligibility(t *testing.T) {
+	policy := identity.CirculationPolicy{MaxLoans: 3, MaxUnpaidFine: 500000, Currency: "IDR"}
+
+	tests := []struct {
+		name     string
+		standing identity.BorrowerStanding
+		codes    []string
+	}{
+		{"eligible", identity.BorrowerStanding{ActiveLoans: 2, UnpaidFine: 500000}, nil},
+		{"loan limit", identity.BorrowerStanding{ActiveLoans: 3}, []string{identity.RefusalMaxLoans}},
+		{"unpaid fines", identity.BorrowerStanding{UnpaidFine: 500100}, []string{identity.RefusalUnpaidFines}},
+		{"every reason", identity.BorrowerStanding{ActiveLoans: 4, OverdueLoans: 1, UnpaidFine: 2000000},
+			[]string{identity.RefusalMaxLoans, identity.RefusalUnpaidFines, identity.RefusalOverdueItems}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refusals := helpers.CheckBorrowingEligibility(policy, tt.standing)
+			if len(refusals) != len(tt.codes) {
+				t.Fatalf("expected %d refusals, got %+v", len(tt.codes), refusals)
+			}
+
+			for i, code := range tt.codes {
+				if refusals[i].Code != code {
+					t.Errorf("expected refusal %s, got %s", code, refusals[i].Code)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckBorrowingEligibility_NoLoanLimit(t *testing.T) {
+	policy := identity.CirculationPolicy{Currency: "IDR"}
+
+	refusals := helpers.CheckBorrowingEligibility(policy, identity.BorrowerStanding{ActiveLoans: 50})
+	if len(refusals) != 0 {
+		t.Errorf("expected no refusals, got %+v", refusals)
+	}
+}
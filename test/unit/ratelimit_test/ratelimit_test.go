@@ -0,0 +1,134 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"go-libraryschool/middlewares"
+	"go-libraryschool/models/jwt_models"
+	"go-libraryschool/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter_SlidingWindow(t *testing.T) {
+	limiter := ratelimit.NewMemoryLimiter()
+	limit := ratelimit.Limit{Requests: 3, Window: 100 * time.Millisecond}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if wait, _ := limiter.Allow(ctx, "a", limit); wait != 0 {
+			t.Fatalf("hit %d: expected to be allowed, got wait %s", i+1, wait)
+		}
+	}
+
+	wait, _ := limiter.Allow(ctx, "a", limit)
+	if wait <= 0 || wait > limit.Window {
+		t.Fatalf("expected a wait within the window, got %s", wait)
+	}
+
+	if wait, _ := limiter.Allow(ctx, "b", limit); wait != 0 {
+		t.Errorf("expected other keys to have their own window, got wait %s", wait)
+	}
+
+	time.Sleep(wait + 5*time.Millisecond)
+	if wait, _ := limiter.Allow(ctx, "a", limit); wait != 0 {
+		t.Errorf("expected a hit once the oldest left the window, got wait %s", wait)
+	}
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string, limit ratelimit.Limit) (time.Duration, error) {
+	return 0, errors.New("redis down")
+}
+
+func TestFallbackLimiter_UsesFallbackOnError(t *testing.T) {
+	limiter := ratelimit.NewFallbackLimiter(failingLimiter{}, ratelimit.NewMemoryLimiter())
+	limit := ratelimit.Limit{Requests: 1, Window: time.Minute}
+
+	if wait, err := limiter.Allow(context.Background(), "a", limit); err != nil || wait != 0 {
+		t.Fatalf("expected the first hit through the fallback, got %s, %v", wait, err)
+	}
+	if wait, err := limiter.Allow(context.Background(), "a", limit); err != nil || wait == 0 {
+		t.Errorf("expected the fallback to enforce the limit, got %s, %v", wait, err)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	middlewares.SetRateLimiter(ratelimit.NewMemoryLimiter())
+	defer middlewares.SetRateLimiter(ratelimit.NewMemoryLimiter())
+
+	route := "/otp/send-otp"
+	budget := ratelimit.Budget(route)
+
+	handler := middlewares.RateLimitMiddleware(route)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(remoteAddr string, claims *jwt_models.JWTClaims) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, route, nil)
+		req.RemoteAddr = remoteAddr
+		if claims != nil {
+			req = req.WithContext(context.WithValue(req.Context(), middlewares.UserContextKey, claims))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < budget.Requests; i++ {
+		if rr := request("10.0.0.1:1000", nil); rr.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, rr.Code)
+		}
+	}
+
+	rr := request("10.0.0.1:1000", nil)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 once the budget is spent, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	if rr := request("10.0.0.2:1000", nil); rr.Code != http.StatusOK {
+		t.Errorf("expected another IP to have its own budget, got %d", rr.Code)
+	}
+
+	// A logged in user is counted by user ID, whatever address the request comes from.
+	if rr := request("10.0.0.1:1000", &jwt_models.JWTClaims{UserID: 9}); rr.Code != http.StatusOK {
+		t.Errorf("expected a user to have their own budget, got %d", rr.Code)
+	}
+}
+
+// contextLimiter lets everything through and remembers whether the request was cancelled while it waited.
+type contextLimiter struct {
+	cancelled chan bool
+}
+
+func (limiter contextLimiter) Allow(ctx context.Context, key string, limit ratelimit.Limit) (time.Duration, error) {
+	_, hasDeadline := ctx.Deadline()
+	limiter.cancelled <- hasDeadline && ctx.Err() != nil
+	return 0, nil
+}
+
+func TestRateLimitMiddleware_UsesRequestContext(t *testing.T) {
+	limiter := contextLimiter{cancelled: make(chan bool, 1)}
+	middlewares.SetRateLimiter(limiter)
+	defer middlewares.SetRateLimiter(ratelimit.NewMemoryLimiter())
+
+	handler := middlewares.RateLimitMiddleware("/book/search-book")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := httptest.NewRequest(http.MethodGet, "/book/search-book", nil).WithContext(ctx)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !<-limiter.cancelled {
+		t.Error("expected the limiter to see the cancellation of the request, bounded by a deadline")
+	}
+}
@@ -0,0 +1,48 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"go-libraryschool/repository/reminder_repository"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSendLoanReminders_FailureSkipsOnlyThatBorrower(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+
+	mock.ExpectQuery("FROM circulation_policies p").WillReturnRows(sqlmock.NewRows([]string{"policy_id"}))
+	mock.ExpectQuery("FROM borrowed_books bb").
+		WillReturnRows(sqlmock.NewRows([]string{"borrow_id", "user_id", "username", "email", "language", "roleID", "title", "genre_id", "return_date"}).
+			AddRow(1, 7, "budi", "budi@gmail.com", "id", 3, "Dune", 1, tomorrow).
+			AddRow(2, 8, "sari", "sari@gmail.com", "en", 3, "Emma", 1, tomorrow))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT IGNORE INTO loan_reminders").WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(errors.New("deadlock"))
+	mock.ExpectRollback()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT IGNORE INTO loan_reminders").WithArgs(2, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO mail_outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = reminder_repository.NewReminderRepository(db, logrus.New()).SendLoanRemindersRepository(context.Background())
+	if err == nil {
+		t.Error("expected the job to report the borrower whose reminder failed")
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expected the next borrower to be reminded: %v", err)
+	}
+}
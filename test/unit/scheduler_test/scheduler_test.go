@@ -0,0 +1,29 @@
+package scheduler_test
+
+import (
+	"go-libraryschool/scheduler"
+	"testing"
+	"time"
+)
+
+func TestDaily(t *testing.T) {
+	next := scheduler.Daily(7, 0)
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+	}{
+		{"before run time", time.Date(2025, 5, 1, 6, 59, 0, 0, time.UTC), time.Date(2025, 5, 1, 7, 0, 0, 0, time.UTC)},
+		{"at run time", time.Date(2025, 5, 1, 7, 0, 0, 0, time.UTC), time.Date(2025, 5, 2, 7, 0, 0, 0, time.UTC)},
+		{"end of month", time.Date(2025, 5, 31, 22, 0, 0, 0, time.UTC), time.Date(2025, 6, 1, 7, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := next(tt.now); !got.Equal(tt.expected) {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}